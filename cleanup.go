@@ -0,0 +1,89 @@
+package main
+
+import (
+	"github.com/arangodb-helper/testagent/pkg/docker"
+	arangodb "github.com/arangodb-helper/testagent/service/cluster/arangodb"
+	logging "github.com/op/go-logging"
+	"github.com/spf13/cobra"
+)
+
+var (
+	cmdCleanup = cobra.Command{
+		Use:   "cleanup",
+		Short: "Remove docker containers & volumes left behind by earlier runs",
+		Run:   cmdCleanupRun,
+	}
+	cleanupFlags struct {
+		dockerEndpoints []string
+		clusterIDs      []string
+		runIDs          []string
+		all             bool
+		force           bool
+		dryRun          bool
+	}
+)
+
+func init() {
+	f := cmdCleanup.Flags()
+	f.StringSliceVar(&cleanupFlags.dockerEndpoints, "docker-endpoint", []string{"unix:///var/run/docker.sock"}, "Endpoints used to reach the docker daemons")
+	f.StringSliceVar(&cleanupFlags.clusterIDs, "cluster-id", nil, "If set, only remove resources of the cluster with this ID (can be specified multiple times)")
+	f.StringSliceVar(&cleanupFlags.runIDs, "run-id", nil, "If set, only remove resources created by the testagent run with this ID (can be specified multiple times)")
+	f.BoolVar(&cleanupFlags.all, "all", false, "If set, remove the resources of all clusters (unless they are in use)")
+	f.BoolVar(&cleanupFlags.force, "force", false, "If set, also remove the resources of clusters that may be in use by a running testagent")
+	f.BoolVar(&cleanupFlags.dryRun, "dry-run", false, "If set, only list the resources that would be removed")
+	cmdMain.AddCommand(&cmdCleanup)
+}
+
+func cmdCleanupRun(cmd *cobra.Command, args []string) {
+	logging.SetFormatter(logging.MustStringFormatter(`%{time:15:04:05.000} %{shortfunc} %{message}`))
+
+	// The IP of the docker hosts is not needed to remove resources
+	hosts, err := docker.NewDockerHosts(cleanupFlags.dockerEndpoints, "", "")
+	if err != nil {
+		log.Fatalf("Failed to create docker clients: %v", err)
+	}
+	selection := len(cleanupFlags.clusterIDs) > 0 || len(cleanupFlags.runIDs) > 0
+	if selection && cleanupFlags.all {
+		log.Fatal("--all cannot be combined with --cluster-id or --run-id")
+	}
+	dryRun := cleanupFlags.dryRun
+	if !selection && !cleanupFlags.all {
+		log.Info("Neither --cluster-id, --run-id nor --all is set, so leftovers are only listed")
+		dryRun = true
+	}
+	inUse, err := arangodb.ClustersInUse(hosts)
+	if err != nil {
+		log.Fatalf("Failed to check which clusters are in use: %v", err)
+	}
+	found, err := arangodb.FindLeftovers(hosts, cleanupFlags.clusterIDs, cleanupFlags.runIDs)
+	if err != nil {
+		log.Fatalf("Failed to list docker resources: %v", err)
+	}
+	var leftovers []arangodb.Leftover
+	skipped := make(map[string]bool)
+	for _, l := range found {
+		if reason, found := inUse[l.ClusterID]; found && !cleanupFlags.force {
+			if !skipped[l.ClusterID] {
+				log.Warningf("Skipping cluster %s (run %s), which may be in use: %s", l.ClusterID, l.RunID, reason)
+				skipped[l.ClusterID] = true
+			}
+			continue
+		}
+		leftovers = append(leftovers, l)
+	}
+	if len(leftovers) == 0 {
+		log.Info("No leftovers found")
+		return
+	}
+	for _, l := range leftovers {
+		log.Infof("Found %s %s on %s (cluster %s, run %s) %s", l.Kind, l.Name, l.Host.Endpoint, l.ClusterID, l.RunID, l.State)
+	}
+	if dryRun {
+		log.Infof("Dry run, not removing %d leftovers", len(leftovers))
+		return
+	}
+	if failed := arangodb.RemoveLeftovers(log, leftovers); failed > 0 {
+		log.Fatalf("Failed to remove %d of %d leftovers", failed, len(leftovers))
+	}
+	log.Infof("Removed %d leftovers", len(leftovers))
+}
@@ -11,7 +11,10 @@ import (
 	"time"
 
 	service "github.com/arangodb-helper/testagent/service"
+	"github.com/arangodb-helper/testagent/service/chaos"
+	"github.com/arangodb-helper/testagent/service/cluster"
 	arangodb "github.com/arangodb-helper/testagent/service/cluster/arangodb"
+	"github.com/arangodb-helper/testagent/service/cluster/external"
 	"github.com/arangodb-helper/testagent/service/test"
 	complex "github.com/arangodb-helper/testagent/tests/complex"
 	"github.com/arangodb-helper/testagent/tests/simple"
@@ -27,6 +30,9 @@ const (
 	defaultOperationTimeout = time.Minute * 6
 	defaultRetryTimeout     = time.Minute * 8
 	defaultStepTimeout      = time.Second * 15
+
+	backendArangodb = "arangodb" // Clusters are created (and impaired) by the testagent using the arangodb starter
+	backendExternal = "external" // The testagent attaches to an existing deployment
 )
 
 var (
@@ -46,9 +52,17 @@ var (
 		complex.ComplextTestConfig
 		complex.DocColConfig
 		complex.GraphTestConf
-		logLevel string
+		logLevel       string
+		chaosWeights   []string
+		backend        string
+		externalConfig external.ExternalConfig
 	}
-	maskAny = errors.WithStack
+	maskAny           = errors.WithStack
+	memoryLimitMB     int64
+	diskFillReserveMB int64
+	diskFillMaxMB     int64
+	dataVolumeSizeMB  int64
+	deploymentMode    string
 )
 
 func init() {
@@ -59,11 +73,50 @@ func init() {
 	// Use only "simple" test by default for backwards compatibility
 	defaultTestList := []string{"simple"}
 	f.IntVar(&appFlags.AgencySize, "agency-size", 3, "Number of agents in the cluster")
+	f.StringVar(&deploymentMode, "mode", string(cluster.DeploymentModeCluster), "Deployment mode: cluster, single or activefailover")
+	f.StringVar(&appFlags.backend, "cluster-backend", backendArangodb, "How the cluster is provided: arangodb (created by the testagent) or external (an existing deployment, see --external-*)")
+	f.StringVar(&appFlags.externalConfig.StarterEndpoint, "external-starter", "", "URL of a starter of the existing deployment (external backend). All servers are discovered through the starters")
+	f.StringSliceVar(&appFlags.externalConfig.AgentEndpoints, "external-agent", nil, "URL of an agent of the existing deployment (external backend, can be specified multiple times)")
+	f.StringSliceVar(&appFlags.externalConfig.DBServerEndpoints, "external-dbserver", nil, "URL of a dbserver of the existing deployment (external backend, can be specified multiple times)")
+	f.StringSliceVar(&appFlags.externalConfig.CoordinatorEndpoints, "external-coordinator", nil, "URL of a coordinator of the existing deployment (external backend, can be specified multiple times)")
 	f.IntVar(&appFlags.port, "port", 4200, "First port of range of ports used by the testAgent")
 	f.StringVar(&appFlags.logLevel, "log-level", "debug", "Minimum log level (debug|info|warning|error)")
 	f.IntVar(&appFlags.ServiceConfig.ChaosConfig.ChaosLevel, "chaos-level", 4, "Chaos level. Default: 4.")
+	f.Int64Var(&appFlags.ChaosConfig.Seed, "chaos-seed", 0, "Seed for all random chaos decisions. If 0, a seed is derived from the current time")
+	f.StringVar(&appFlags.ChaosConfig.ReplayJournal, "chaos-replay", "", "Path of a chaos journal to replay against the new cluster")
+	f.IntVar(&appFlags.ChaosConfig.Budget.MaxFaults, "chaos-max-faults", 1, "Maximum number of chaos faults active at the same time")
+	f.IntVar(&appFlags.ChaosConfig.Budget.MaxAgents, "chaos-max-impaired-agents", 1, "Maximum number of agents impaired by chaos at any time")
+	f.IntVar(&appFlags.ChaosConfig.Budget.MaxDBServers, "chaos-max-impaired-dbservers", 1, "Maximum number of dbservers impaired by chaos at any time (typically replicationFactor-1)")
+	f.IntVar(&appFlags.ChaosConfig.Budget.MaxCoordinators, "chaos-max-impaired-coordinators", 1, "Maximum number of coordinators impaired by chaos at any time")
+	f.IntVar(&appFlags.ChaosConfig.DegradeChaosLevel, "chaos-degrade-level", 4, "Minimum chaos level at which network latency & packet loss is introduced")
+	f.DurationVar(&appFlags.ChaosConfig.DegradeLatency, "chaos-degrade-latency", time.Millisecond*300, "Latency added to network traffic by slow traffic chaos")
+	f.DurationVar(&appFlags.ChaosConfig.DegradeJitter, "chaos-degrade-jitter", time.Millisecond*100, "Jitter (+/-) of the latency added to network traffic by slow traffic chaos")
+	f.Float64Var(&appFlags.ChaosConfig.DegradePacketLoss, "chaos-degrade-loss", 5, "Percentage of network packets lost by lossy traffic chaos")
+	f.StringSliceVar(&appFlags.chaosWeights, "chaos-weight", nil, "Relative chance of a chaos action being picked, as <action>=<weight> (e.g. restart-coordinator=10). Actions not listed have weight 1, weight 0 disables an action")
+	f.DurationVar(&appFlags.ChaosConfig.Pacing.MinPause, "chaos-min-pause", time.Second*30, "Minimum pause after chaos was introduced")
+	f.DurationVar(&appFlags.ChaosConfig.Pacing.MaxPause, "chaos-max-pause", time.Second*30, "Maximum pause after chaos was introduced")
+	f.DurationVar(&appFlags.ChaosConfig.Pacing.SkipPause, "chaos-skip-pause", time.Second*2, "Pause after a chaos action could not introduce chaos")
+	f.DurationVar(&appFlags.ChaosConfig.Pacing.MinNetworkFault, "chaos-min-network-fault", time.Second*5, "Minimum duration of network faults")
+	f.DurationVar(&appFlags.ChaosConfig.Pacing.MaxNetworkFault, "chaos-max-network-fault", time.Second*64, "Maximum duration of network faults")
+	f.DurationVar(&appFlags.ChaosConfig.Pacing.MinFault, "chaos-min-fault", time.Second*5, "Minimum duration of other temporary faults (e.g. frozen servers)")
+	f.DurationVar(&appFlags.ChaosConfig.Pacing.MaxFault, "chaos-max-fault", time.Second*64, "Maximum duration of other temporary faults (e.g. frozen servers)")
+	f.IntVar(&appFlags.ChaosConfig.ResourceChaosLevel, "chaos-resource-level", 2, "Minimum chaos level at which CPU & memory of servers are limited")
+	f.Float64Var(&appFlags.ChaosConfig.ThrottleCPUs, "chaos-cpu-limit", 0.1, "Number of CPUs a server can use while its CPU is throttled by chaos")
+	f.Int64Var(&memoryLimitMB, "chaos-memory-limit", 512, "Memory (in MB) a server can use while its memory is limited by chaos")
+	f.IntVar(&appFlags.ChaosConfig.DiskChaosLevel, "chaos-disk-level", 3, "Minimum chaos level at which data volumes of machines are filled")
+	f.Int64Var(&diskFillReserveMB, "chaos-disk-reserve", 16, "Space (in MB) left free when a data volume is filled by chaos")
+	f.Int64Var(&diskFillMaxMB, "chaos-disk-max-fill", 4096, "Maximum space (in MB) written when a data volume is filled by chaos. Larger (free) volumes are not filled")
+	f.Int64Var(&dataVolumeSizeMB, "data-volume-size", 0, "If set, the data volume of each machine is a tmpfs of this size (in MB), such that it can be filled by chaos")
+	f.BoolVar(&appFlags.ChaosConfig.EnableClockSkew, "chaos-clock-skew", false, "If set, the clocks of servers are skewed. Requires an --arango-image built with Dockerfile.faketime")
+	f.DurationVar(&appFlags.ChaosConfig.MaxClockSkew, "chaos-max-clock-skew", time.Minute*5, "Maximum offset (forward or backward) of a skewed server clock")
+	f.DurationVar(&appFlags.ChaosConfig.RecoverySLA, "chaos-recovery-sla", 0, "Maximum time the cluster may need to recover from a fault (servers ready & all shards in sync). If exceeded, a failure is reported. 0 means no SLA")
+	f.BoolVar(&appFlags.ChaosConfig.CleanOutBeforeRemove, "chaos-cleanout-before-remove", false, "If set, the dbserver of a machine is cleaned out (all shards moved away) before the machine is removed by chaos")
+	f.StringVar(&appFlags.ChaosConfig.ScenarioPath, "chaos-scenario", "", "Path of a chaos scenario file (JSON) to run instead of random chaos")
+	f.StringVar(&appFlags.ChaosSchedule, "chaos-schedule", "", "Path of a chaos schedule file (JSON) that changes the chaos level over time")
 	f.StringVar(&appFlags.ArangodbImage, "arangodb-image", getEnvVar("ARANGODB_IMAGE", "arangodb/arangodb-starter"), "name of the Docker image containing arangodb (the cluster starter)")
 	f.StringVar(&appFlags.ArangoImage, "arango-image", getEnvVar("ARANGO_IMAGE", ""), "name of the Docker image containing arangod (the database)")
+	f.StringVar(&appFlags.UpgradeImage, "upgrade-image", getEnvVar("UPGRADE_IMAGE", ""), "name of the Docker image containing arangod (the database) that all machines are upgraded to, one machine at a time (agents first)")
+	f.DurationVar(&appFlags.ChaosConfig.UpgradeTimeout, "upgrade-timeout", time.Hour*2, "Maximum time the upgrade of all machines to --upgrade-image may take. If exceeded, a failure is reported. 0 means no limit")
 	f.StringVar(&appFlags.NetworkBlockerImage, "network-blocker-image", getEnvVar("NETWORK_BLOCKER_IMAGE", ""), "name of the Docker image containing network-blocker")
 	f.StringSliceVar(&appFlags.DockerEndpoints, "docker-endpoint", defaultDockerEndpoints, "Endpoints used to reach the docker daemons")
 	f.StringVar(&appFlags.DockerHostIP, "docker-host-ip", "", "IP of the docker host")
@@ -72,16 +125,22 @@ func init() {
 	f.BoolVar(&appFlags.ReplicationVersion2, "replication-version-2", false, "If set, use replication version 2")
 	f.BoolVar(&appFlags.FailedWriteConcern403, "return-403-on-failed-write-concern", false, "If set, option `--cluster.failed-write-concern-status-code` will not be set for DB servers, bringing it to the default value of 403. Otherwise this parameter will be set to 503.")
 	f.StringVar(&appFlags.DockerInterface, "docker-interface", "docker0", "Network interface used to connect docker containers to")
+	f.StringVar(&appFlags.ServiceConfig.StateDir, "state-dir", "", "If set, the state of the cluster & tests is stored in this directory, such that the run can be continued with --resume after the testagent was restarted")
+	f.BoolVar(&appFlags.Resume, "resume", false, "If set, the cluster & tests stored in --state-dir are resumed instead of creating a new cluster")
 	f.StringVar(&appFlags.ReportDir, "report-dir", getEnvVar("REPORT_DIR", "."), "Directory in which failure reports will be created")
 	f.BoolVar(&appFlags.CollectMetrics, "collect-metrics", false, "If set, metrics will be collected and saved into files.")
 	f.StringVar(&appFlags.MetricsDir, "metrics-dir", getEnvVar("METRICS_DIR", "."), "Directory in which metrics will be stored")
 	f.BoolVar(&appFlags.Privileged, "privileged", false, "If set, run all containers with `--privileged`")
+	f.StringVar(&appFlags.SSLKeyFile, "ssl-keyfile", "", "If set, start all servers with TLS using this keyfile. The path must be the same on the docker hosts & in the testagent")
+	f.BoolVar(&appFlags.SSLAutoKey, "ssl-auto-key", false, "If set, start all servers with TLS using a self-signed certificate generated by the starter")
+	f.StringVar(&appFlags.JWTSecretFile, "jwt-secret", "", "If set, start all servers with authentication using the JWT secret in this file. The path must be the same on the docker hosts & in the testagent")
 	f.IntVar(&appFlags.ChaosConfig.MaxMachines, "max-machines", 10, "Upper limit to the number of machines in a cluster")
 	f.StringSliceVar(&appFlags.EnableTests, "enable-test", defaultTestList, "Enable particular test. Default: run all tests. Available tests: simple, DocColTest, OneShardTest, CommunityGraphTest, SmartGraphTest, EnterpriseGraphTest")
 	f.IntVar(&appFlags.SimpleConfig.MaxDocuments, "simple-max-documents", 20000, "Upper limit to the number of documents created in simple test")
 	f.IntVar(&appFlags.SimpleConfig.MaxCollections, "simple-max-collections", 10, "Upper limit to the number of collections created in simple test")
 	f.DurationVar(&appFlags.SimpleConfig.OperationTimeout, "simple-operation-timeout", defaultOperationTimeout, "Timeout per database operation")
 	f.DurationVar(&appFlags.SimpleConfig.RetryTimeout, "simple-retry-timeout", defaultRetryTimeout, "How long are tests retried before giving up")
+	f.IntVar(&appFlags.SimpleConfig.CursorFaultPercentage, "simple-cursor-fault-percentage", 0, "Percentage of AQL cursor tests that request a restart of the coordinator serving the cursor")
 	f.Int64Var(&appFlags.GraphTestConf.MaxVertices, "graph-max-vertices", 150000, "Upper limit to the number of vertices (graph tests)")
 	f.IntVar(&appFlags.GraphTestConf.VertexSize, "graph-vertex-size", 4096, "The size of the payload field in bytes in all vertices (graph tests)")
 	f.IntVar(&appFlags.GraphTestConf.EdgeSize, "graph-edge-size", 8192, "The size of the payload field in bytes in all edges (graph tests)")
@@ -99,7 +158,8 @@ func init() {
 }
 
 // handleSignal listens for termination signals and stops this process onup termination.
-func handleSignal(sigChannel chan os.Signal, stopChan chan struct{}) {
+// A SIGTERM (e.g. from a reboot of the host) calls onTerminate first.
+func handleSignal(sigChannel chan os.Signal, stopChan chan struct{}, onTerminate func()) {
 	signalCount := 0
 	for s := range sigChannel {
 		signalCount++
@@ -107,6 +167,9 @@ func handleSignal(sigChannel chan os.Signal, stopChan chan struct{}) {
 		if signalCount > 1 {
 			os.Exit(1)
 		}
+		if s == syscall.SIGTERM {
+			onTerminate()
+		}
 		stopChan <- struct{}{}
 	}
 }
@@ -127,8 +190,12 @@ func cmdMainRun(cmd *cobra.Command, args []string) {
 	logging.SetLevel(level, projectName)
 	appFlags.ArangodbConfig.Verbose = appFlags.logLevel == "debug"
 
+	if appFlags.backend != backendArangodb && appFlags.backend != backendExternal {
+		log.Fatalf("Invalid --cluster-backend '%s', expected %s or %s", appFlags.backend, backendArangodb, backendExternal)
+	}
+
 	// Get host IP
-	if appFlags.ArangodbConfig.DockerHostIP == "" {
+	if appFlags.backend == backendArangodb && appFlags.ArangodbConfig.DockerHostIP == "" {
 		if !appFlags.DockerNetHost && os.Getenv("RUNNING_IN_DOCKER") != "" {
 			log.Fatal("When running in docker you must specify a --docker-host-ip")
 		}
@@ -140,6 +207,20 @@ func cmdMainRun(cmd *cobra.Command, args []string) {
 		appFlags.ArangodbConfig.DockerHostIP = ip
 	}
 
+	weights, err := chaos.ParseActionWeights(appFlags.chaosWeights)
+	if err != nil {
+		log.Fatalf("Invalid --chaos-weight: %v", err)
+	}
+	appFlags.ChaosConfig.Weights = weights
+	appFlags.ChaosConfig.MemoryLimit = memoryLimitMB * 1024 * 1024
+	appFlags.ChaosConfig.DiskFillReserve = diskFillReserveMB * 1024 * 1024
+	appFlags.ChaosConfig.DiskFillMax = diskFillMaxMB * 1024 * 1024
+	appFlags.ArangodbConfig.DataVolumeSize = dataVolumeSizeMB * 1024 * 1024
+	appFlags.ArangodbConfig.Mode = cluster.DeploymentMode(deploymentMode)
+	if err := appFlags.ArangodbConfig.Mode.Validate(); err != nil {
+		log.Fatalf("Invalid --mode: %v", err)
+	}
+
 	if appFlags.DockerNetHost {
 		// Network chaos is not supported with host networking
 		appFlags.ChaosConfig.DisableNetworkChaos = true
@@ -150,14 +231,15 @@ func cmdMainRun(cmd *cobra.Command, args []string) {
 	appFlags.ArangodbConfig.MasterPort = appFlags.port + 1
 
 	// Interrupt signal:
-	sigChannel := make(chan os.Signal)
+	sigChannel := make(chan os.Signal, 1)
 	stopChan := make(chan struct{}, 10)
 	signal.Notify(sigChannel, os.Interrupt, syscall.SIGTERM)
-	go handleSignal(sigChannel, stopChan)
-
-	// Create cluster builder
-	log.Debug("creating arangodb cluster builder")
 
+	if appFlags.backend == backendExternal && appFlags.CollectMetrics {
+		// Metrics are collected from the docker containers of the servers
+		log.Warning("Metrics cannot be collected from an external cluster, ignoring --collect-metrics")
+		appFlags.CollectMetrics = false
+	}
 	if appFlags.CollectMetrics {
 		if _, err := os.Stat(appFlags.MetricsDir); os.IsNotExist(err) {
 			log.Info("Metrics directory does not exist. Creating: %s", appFlags.MetricsDir)
@@ -167,12 +249,36 @@ func cmdMainRun(cmd *cobra.Command, args []string) {
 		}
 	}
 
-	cb, err := arangodb.NewArangodbClusterBuilder(log, appFlags.MetricsDir, appFlags.CollectMetrics, appFlags.ArangodbConfig)
+	if appFlags.Resume && appFlags.ServiceConfig.StateDir == "" {
+		log.Fatal("--resume requires a --state-dir")
+	}
+	appFlags.ArangodbConfig.StateDir = appFlags.ServiceConfig.StateDir
+
+	// Create cluster builder
+	var cb cluster.ClusterBuilder
+	if appFlags.backend == backendExternal {
+		log.Debug("creating external cluster builder")
+		appFlags.externalConfig.Mode = appFlags.ArangodbConfig.Mode
+		appFlags.externalConfig.JWTSecretFile = appFlags.ArangodbConfig.JWTSecretFile
+		cb, err = external.NewExternalClusterBuilder(log, appFlags.externalConfig)
+	} else {
+		log.Debug("creating arangodb cluster builder")
+		cb, err = arangodb.NewArangodbClusterBuilder(log, appFlags.MetricsDir, appFlags.CollectMetrics, appFlags.ArangodbConfig)
+	}
 	if err != nil {
 		log.Fatalf("Failed to create cluster builder: %#v", err)
 	}
 
 	// Create tests
+	if !appFlags.ArangodbConfig.Mode.IsCluster() {
+		// These tests need coordinators & dbservers
+		for _, name := range []string{"OneShardTest", "SmartGraphTest", "EnterpriseGraphTest"} {
+			if slices.Contains(appFlags.EnableTests, name) {
+				log.Warningf("Test %s needs a cluster, it is not run in %s mode", name, deploymentMode)
+				appFlags.EnableTests = slices.DeleteFunc(appFlags.EnableTests, func(t string) bool { return t == name })
+			}
+		}
+	}
 	tests := []test.TestScript{}
 	if slices.Contains(appFlags.EnableTests, "simple") {
 		tests = append(tests, simple.NewSimpleTest(log, appFlags.ReportDir, appFlags.SimpleConfig))
@@ -205,6 +311,8 @@ func cmdMainRun(cmd *cobra.Command, args []string) {
 	if err != nil {
 		log.Fatalf("Failed to create service: %#v", err)
 	}
+	// Keep the cluster when terminated (rather than interrupted), such that the run can be resumed
+	go handleSignal(sigChannel, stopChan, service.KeepCluster)
 
 	// Run the service
 	if err := service.Run(stopChan, true); err != nil {
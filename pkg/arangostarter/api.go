@@ -75,12 +75,13 @@ type ProcessList struct {
 type ServerType string
 
 const (
-	ServerTypeCoordinator = ServerType("coordinator")
-	ServerTypeDBServer    = ServerType("dbserver")
-	ServerTypeAgent       = ServerType("agent")
-	ServerTypeSingle      = ServerType("single")
-	ServerTypeSyncMaster  = ServerType("syncmaster")
-	ServerTypeSyncWorker  = ServerType("syncworker")
+	ServerTypeCoordinator     = ServerType("coordinator")
+	ServerTypeDBServer        = ServerType("dbserver")
+	ServerTypeAgent           = ServerType("agent")
+	ServerTypeSingle          = ServerType("single")
+	ServerTypeResilientSingle = ServerType("resilientsingle")
+	ServerTypeSyncMaster      = ServerType("syncmaster")
+	ServerTypeSyncWorker      = ServerType("syncworker")
 )
 
 // ServerProcess holds all information of a single server started by the starter.
@@ -1,5 +1,14 @@
 package networkblocker
 
+import "time"
+
+// Degradation describes netem style shaping of network traffic.
+type Degradation struct {
+	Latency    time.Duration // Delay added to every packet
+	Jitter     time.Duration // Random variation of the delay (+/-)
+	PacketLoss float64       // Percentage (0-100) of packets that are lost
+}
+
 type API interface {
 	// RejectTCP actively denies all traffic on the given TCP port
 	RejectTCP(port int) error
@@ -19,6 +28,35 @@ type API interface {
 	// AcceptAllFrom allow all traffic coming from the given IP address on the given interface
 	AcceptAllFrom(ip, intf string) error
 
+	// RejectBetween actively denies all traffic between the two given IP addresses (in both directions) on the given interface
+	RejectBetween(ip1, ip2, intf string) error
+
+	// DropBetween silently denies all traffic between the two given IP addresses (in both directions) on the given interface
+	DropBetween(ip1, ip2, intf string) error
+
+	// AcceptBetween allow all traffic between the two given IP addresses (in both directions) on the given interface
+	AcceptBetween(ip1, ip2, intf string) error
+
+	// SupportsBetween returns true if the service supports rules between 2 IP addresses
+	// (reject/drop/accept between). Older network-blocker images only support rules per port & source IP.
+	SupportsBetween() (bool, error)
+
+	// SupportsDegrade returns true if the service supports degrading traffic (degrade/restore).
+	// Older network-blocker images only support rejecting & dropping traffic.
+	SupportsDegrade() (bool, error)
+
+	// DegradeTCP applies the given degradation to all traffic on the given TCP port
+	DegradeTCP(port int, d Degradation) error
+
+	// DegradeAllFrom applies the given degradation to all traffic coming from the given IP address on the given interface
+	DegradeAllFrom(ip, intf string, d Degradation) error
+
+	// RestoreTCP removes any degradation from traffic on the given TCP port
+	RestoreTCP(port int) error
+
+	// RestoreAllFrom removes any degradation from traffic coming from the given IP address on the given interface
+	RestoreAllFrom(ip, intf string) error
+
 	// Rules returns a list of all rules injected by this service.
 	Rules() ([]string, error)
 }
@@ -6,6 +6,7 @@ import (
 	"io/ioutil"
 	"net/http"
 	"net/url"
+	"strconv"
 	"time"
 
 	"github.com/pkg/errors"
@@ -138,6 +139,139 @@ func (c *client) AcceptAllFrom(ip, intf string) error {
 	return nil
 }
 
+// RejectBetween actively denies all traffic between the two given IP addresses (in both directions) on the given interface
+func (c *client) RejectBetween(ip1, ip2, intf string) error {
+	return maskAny(c.postBetween("/api/v1/reject/between", ip1, ip2, intf))
+}
+
+// DropBetween silently denies all traffic between the two given IP addresses (in both directions) on the given interface
+func (c *client) DropBetween(ip1, ip2, intf string) error {
+	return maskAny(c.postBetween("/api/v1/drop/between", ip1, ip2, intf))
+}
+
+// AcceptBetween allow all traffic between the two given IP addresses (in both directions) on the given interface
+func (c *client) AcceptBetween(ip1, ip2, intf string) error {
+	return maskAny(c.postBetween("/api/v1/accept/between", ip1, ip2, intf))
+}
+
+// SupportsBetween returns true if the service supports rules between 2 IP addresses
+// (reject/drop/accept between). Older network-blocker images only support rules per port & source IP.
+func (c *client) SupportsBetween() (bool, error) {
+	found, err := c.probe("/api/v1/accept/between")
+	if err != nil {
+		return false, maskAny(err)
+	}
+	return found, nil
+}
+
+// SupportsDegrade returns true if the service supports degrading traffic (degrade/restore).
+// Older network-blocker images only support rejecting & dropping traffic.
+func (c *client) SupportsDegrade() (bool, error) {
+	found, err := c.probe("/api/v1/restore/tcp/0")
+	if err != nil {
+		return false, maskAny(err)
+	}
+	return found, nil
+}
+
+// DegradeTCP applies the given degradation to all traffic on the given TCP port
+func (c *client) DegradeTCP(port int, d Degradation) error {
+	return maskAny(c.post(fmt.Sprintf("/api/v1/degrade/tcp/%d", port), degradationQuery(d)))
+}
+
+// DegradeAllFrom applies the given degradation to all traffic coming from the given IP address on the given interface
+func (c *client) DegradeAllFrom(ip, intf string, d Degradation) error {
+	q := degradationQuery(d)
+	if ip != "" {
+		q.Set("ip", ip)
+	}
+	if intf != "" {
+		q.Set("intf", intf)
+	}
+	return maskAny(c.post("/api/v1/degrade/from", q))
+}
+
+// RestoreTCP removes any degradation from traffic on the given TCP port
+func (c *client) RestoreTCP(port int) error {
+	return maskAny(c.post(fmt.Sprintf("/api/v1/restore/tcp/%d", port), nil))
+}
+
+// RestoreAllFrom removes any degradation from traffic coming from the given IP address on the given interface
+func (c *client) RestoreAllFrom(ip, intf string) error {
+	q := url.Values{}
+	if ip != "" {
+		q.Set("ip", ip)
+	}
+	if intf != "" {
+		q.Set("intf", intf)
+	}
+	return maskAny(c.post("/api/v1/restore/from", q))
+}
+
+// degradationQuery creates query parameters for the given degradation.
+func degradationQuery(d Degradation) url.Values {
+	q := url.Values{}
+	if d.Latency > 0 {
+		q.Set("latency", d.Latency.String())
+	}
+	if d.Jitter > 0 {
+		q.Set("jitter", d.Jitter.String())
+	}
+	if d.PacketLoss > 0 {
+		q.Set("loss", strconv.FormatFloat(d.PacketLoss, 'f', -1, 64))
+	}
+	return q
+}
+
+// postBetween performs a POST request for a rule between two IP addresses.
+func (c *client) postBetween(urlPath, ip1, ip2, intf string) error {
+	q := url.Values{}
+	q.Set("ip1", ip1)
+	q.Set("ip2", ip2)
+	if intf != "" {
+		q.Set("intf", intf)
+	}
+	return maskAny(c.post(urlPath, q))
+}
+
+// post performs a POST request with given path & query without a body.
+func (c *client) post(urlPath string, query url.Values) error {
+	url := c.createURL(urlPath, query)
+	resp, err := c.client.Post(url, contentTypeJSON, nil)
+	if err != nil {
+		return maskAny(err)
+	}
+	if err := c.handleResponse(resp, "POST", url, nil); err != nil {
+		return maskAny(err)
+	}
+	return nil
+}
+
+// probe returns false if the service does not serve the given path.
+// The request is sent without arguments, so it never changes any rule.
+func (c *client) probe(urlPath string) (bool, error) {
+	url := c.createURL(urlPath, nil)
+	resp, err := c.client.Post(url, contentTypeJSON, nil)
+	if err != nil {
+		return false, maskAny(err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusNotFound {
+		// The endpoint exists (it will most likely complain about the missing arguments)
+		return true, nil
+	}
+	// A handler of the endpoint may also return 404, but then it includes an error message
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return false, maskAny(errors.Wrapf(err, "Failed reading response data from POST request to %s: %v", url, err))
+	}
+	var er ErrorResponse
+	if err := json.Unmarshal(body, &er); err == nil && er.Message != "" {
+		return true, nil
+	}
+	return false, nil
+}
+
 // Rules returns a list of all rules injected by this service.
 func (c *client) Rules() ([]string, error) {
 	url := c.createURL("/api/v1/rules", nil)
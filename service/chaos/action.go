@@ -4,6 +4,10 @@ import (
 	"context"
 	"crypto/sha1"
 	"fmt"
+	"slices"
+	"sync"
+
+	"github.com/arangodb-helper/testagent/service/cluster"
 )
 
 type Action interface {
@@ -13,9 +17,15 @@ type Action interface {
 	Failed() int
 	Skipped() int
 	Enabled() bool
+	Weight() int
+	// Recovery returns how long the cluster needed to recover from the faults of this action.
+	Recovery() RecoveryStats
 
 	Enable()
 	Disable()
+	// SetWeight changes the relative chance of the action being picked.
+	// A weight of 0 means the action is never picked.
+	SetWeight(weight int) error
 }
 
 type chaosAction struct {
@@ -26,6 +36,58 @@ type chaosAction struct {
 	skipped      int
 	disabled     bool
 	minimumLevel int
+	weight       int                  // Relative chance of being picked
+	roles        []cluster.ServerRole // Server roles the action needs to exist in the deployment
+	apiOnly      bool                 // If set, the action only uses the database API (it does not need control over the servers)
+	machineStop  bool                 // If set, the action stops all containers of a machine
+
+	recoveryMutex sync.Mutex
+	recovery      RecoveryStats
+}
+
+// newChaosAction creates a (disabled) action with given name, minimum chaos level and run function.
+func newChaosAction(name string, minimumLevel int, action func(context.Context, *chaosAction) bool) *chaosAction {
+	return &chaosAction{
+		action:       action,
+		name:         name,
+		disabled:     true,
+		minimumLevel: minimumLevel,
+		weight:       1,
+	}
+}
+
+// needs records that the action can only be used on deployments that have servers with all given roles.
+func (a *chaosAction) needs(roles ...cluster.ServerRole) *chaosAction {
+	a.roles = roles
+	return a
+}
+
+// viaAPI records that the action only uses the database API, so it can also be used on clusters
+// of which the servers are not managed by the testagent.
+func (a *chaosAction) viaAPI() *chaosAction {
+	a.apiOnly = true
+	return a
+}
+
+// stopsMachine records that the action stops all containers of a machine, so it cannot be used
+// when the data of a machine does not survive that.
+func (a *chaosAction) stopsMachine() *chaosAction {
+	a.machineStop = true
+	return a
+}
+
+// supports returns true if the action can be used on a deployment with servers of the given roles.
+// On unmanaged deployments, only actions that use the database API are supported.
+func (a *chaosAction) supports(roles []cluster.ServerRole, managed bool) bool {
+	if !managed && !a.apiOnly {
+		return false
+	}
+	for _, r := range a.roles {
+		if !slices.Contains(roles, r) {
+			return false
+		}
+	}
+	return true
 }
 
 func (a *chaosAction) ID() string {
@@ -53,6 +115,17 @@ func (a *chaosAction) Enabled() bool {
 	return !a.disabled
 }
 
+func (a *chaosAction) Weight() int {
+	return a.weight
+}
+
+func (a *chaosAction) Recovery() RecoveryStats {
+	a.recoveryMutex.Lock()
+	defer a.recoveryMutex.Unlock()
+
+	return a.recovery
+}
+
 func (a *chaosAction) Enable() {
 	a.disabled = false
 }
@@ -60,3 +133,11 @@ func (a *chaosAction) Enable() {
 func (a *chaosAction) Disable() {
 	a.disabled = true
 }
+
+func (a *chaosAction) SetWeight(weight int) error {
+	if weight < 0 {
+		return fmt.Errorf("Weight of chaos action '%s' cannot be negative", a.name)
+	}
+	a.weight = weight
+	return nil
+}
@@ -0,0 +1,44 @@
+package chaos
+
+import (
+	"testing"
+
+	"github.com/arangodb-helper/testagent/service/cluster"
+)
+
+func TestActionSupports(t *testing.T) {
+	tests := []struct {
+		roles []cluster.ServerRole
+		mode  cluster.DeploymentMode
+		want  bool
+	}{
+		{nil, cluster.DeploymentModeSingle, true},
+		{[]cluster.ServerRole{cluster.ServerRoleCoordinator}, cluster.DeploymentModeCluster, true},
+		{[]cluster.ServerRole{cluster.ServerRoleCoordinator}, cluster.DeploymentModeSingle, false},
+		{[]cluster.ServerRole{cluster.ServerRoleSingle}, cluster.DeploymentModeCluster, false},
+		{[]cluster.ServerRole{cluster.ServerRoleSingle}, cluster.DeploymentModeSingle, true},
+		{[]cluster.ServerRole{cluster.ServerRoleAgent, cluster.ServerRoleSingle}, cluster.DeploymentModeSingle, false},
+		{[]cluster.ServerRole{cluster.ServerRoleAgent, cluster.ServerRoleSingle}, cluster.DeploymentModeActiveFailover, true},
+	}
+	for _, test := range tests {
+		a := newChaosAction("test", 1, nil).needs(test.roles...)
+		if got := a.supports(deploymentRoles(test.mode), true); got != test.want {
+			t.Errorf("Action needing %v in %s mode: expected %v, got %v", test.roles, test.mode, test.want, got)
+		}
+	}
+}
+
+func TestActionSupportsUnmanaged(t *testing.T) {
+	roles := deploymentRoles(cluster.DeploymentModeCluster)
+	restart := newChaosAction("restart", 1, nil).needs(cluster.ServerRoleDBServer)
+	if restart.supports(roles, false) {
+		t.Errorf("Action that needs control over servers must not be supported on unmanaged clusters")
+	}
+	move := newChaosAction("move", 1, nil).needs(cluster.ServerRoleDBServer).viaAPI()
+	if !move.supports(roles, false) {
+		t.Errorf("Action that only uses the database API must be supported on unmanaged clusters")
+	}
+	if move.supports(deploymentRoles(cluster.DeploymentModeSingle), false) {
+		t.Errorf("Action needing dbservers must not be supported in single mode")
+	}
+}
@@ -16,14 +16,15 @@ func (c *chaosMonkey) addMachine(ctx context.Context, action *chaosAction) bool
 	}
 
 	// Add a machine
-	c.recordEvent(newEvent("Adding %d' machine...", len(machines)+1))
+	event := c.startEvent(action, nil, "", "Adding machine %d", len(machines)+1)
 	if m, err := c.cluster.Add(); err != nil {
 		c.log.Errorf("Failed to add machine: %v", err)
 		action.failures++
-		c.recordEvent(newEvent("Add new machine failed: %v", err))
+		c.finishEvent(event, err)
 	} else {
 		action.succeeded++
-		c.recordEvent(newEvent("Add new machine succeeded (added %s)", m.ID()))
+		c.updateEvent(event, func(e *Event) { e.Machine = m.ID() })
+		c.finishEvent(event, nil)
 	}
 	return true
 }
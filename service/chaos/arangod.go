@@ -0,0 +1,248 @@
+package chaos
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/arangodb-helper/testagent/service/cluster"
+)
+
+const (
+	arangodRequestTimeout = time.Second * 15
+)
+
+var (
+	// arangodClient is used for all requests to servers (the timeout is set per request).
+	arangodClient = cluster.NewHTTPClient(0)
+)
+
+// getJSON performs a GET request on the server at given URL and decodes the JSON response into result.
+func (c *chaosMonkey) getJSON(ctx context.Context, server url.URL, path string, result interface{}) error {
+	return maskAny(c.requestJSON(ctx, "GET", server, path, nil, result, http.StatusOK))
+}
+
+// postJSON performs a POST request with given body (encoded as JSON) on the server at given URL
+// and decodes the JSON response into result.
+func (c *chaosMonkey) postJSON(ctx context.Context, server url.URL, path string, body, result interface{}) error {
+	return maskAny(c.requestJSON(ctx, "POST", server, path, body, result, http.StatusOK, http.StatusCreated, http.StatusAccepted))
+}
+
+// requestJSON performs a request on the server at given URL and decodes the JSON response into result.
+// The path can contain a query. An error is returned when the response status is not one of the expected ones.
+func (c *chaosMonkey) requestJSON(ctx context.Context, method string, server url.URL, path string, body, result interface{}, expectedStatus ...int) error {
+	ctx, cancel := context.WithTimeout(ctx, arangodRequestTimeout)
+	defer cancel()
+	u := server
+	u.Path, u.RawQuery, _ = strings.Cut(path, "?")
+	var reqBody io.Reader
+	if body != nil {
+		encoded, err := json.Marshal(body)
+		if err != nil {
+			return maskAny(err)
+		}
+		reqBody = bytes.NewReader(encoded)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, u.String(), reqBody)
+	if err != nil {
+		return maskAny(err)
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	cluster.Authenticate(c.cluster, req)
+	resp, err := arangodClient.Do(req)
+	if err != nil {
+		return maskAny(err)
+	}
+	defer resp.Body.Close()
+	if !slices.Contains(expectedStatus, resp.StatusCode) {
+		return maskAny(fmt.Errorf("Invalid status from %s %s; expected %v, got %d", method, u.String(), expectedStatus, resp.StatusCode))
+	}
+	if err := json.NewDecoder(resp.Body).Decode(result); err != nil {
+		return maskAny(err)
+	}
+	return nil
+}
+
+// dbserverID fetches the server ID of the dbserver on the given machine.
+func (c *chaosMonkey) dbserverID(ctx context.Context, m cluster.Machine) (string, error) {
+	var resp struct {
+		ID string `json:"id"`
+	}
+	if err := c.getJSON(ctx, m.DBServerURL(), "/_admin/server/id", &resp); err != nil {
+		return "", maskAny(err)
+	}
+	return resp.ID, nil
+}
+
+// shardInfo is the plan of a single shard as returned by the shard distribution API.
+type shardInfo struct {
+	Leader    string   `json:"leader"`
+	Followers []string `json:"followers"`
+}
+
+// collectionShards is the distribution of the shards of a single collection.
+type collectionShards struct {
+	Plan    map[string]shardInfo `json:"Plan"`
+	Current map[string]shardInfo `json:"Current"`
+}
+
+// shardDistribution fetches the planned & current shard distribution of all collections
+// in all databases, through the coordinator on the given machine.
+// The result is indexed by database name and then by collection name.
+func (c *chaosMonkey) shardDistribution(ctx context.Context, coordinator cluster.Machine) (map[string]map[string]collectionShards, error) {
+	var dbs struct {
+		Result []string `json:"result"`
+	}
+	if err := c.getJSON(ctx, coordinator.CoordinatorURL(), "/_api/database", &dbs); err != nil {
+		return nil, maskAny(err)
+	}
+	result := make(map[string]map[string]collectionShards)
+	for _, db := range dbs.Result {
+		var dist struct {
+			Results map[string]collectionShards `json:"results"`
+		}
+		if err := c.getJSON(ctx, coordinator.CoordinatorURL(), "/_db/"+url.PathEscape(db)+"/_admin/cluster/shardDistribution", &dist); err != nil {
+			return nil, maskAny(err)
+		}
+		result[db] = dist.Results
+	}
+	return result, nil
+}
+
+// shardLeaderCounts returns the number of planned shard leaders for each of the given machines (by machine ID).
+// If userOnly is set, shards of system collections are not counted.
+func (c *chaosMonkey) shardLeaderCounts(ctx context.Context, machines MachineList, userOnly bool) (map[string]int, error) {
+	coordinators, _, err := c.checkCoordinatorReadyStatus()
+	if err != nil {
+		return nil, maskAny(err)
+	}
+	if len(coordinators) == 0 {
+		return nil, maskAny(fmt.Errorf("No ready coordinator"))
+	}
+	dist, err := c.shardDistribution(ctx, coordinators[0])
+	if err != nil {
+		return nil, maskAny(err)
+	}
+	leaders := make(map[string]int)
+	for _, collections := range dist {
+		for name, col := range collections {
+			if userOnly && strings.HasPrefix(name, "_") {
+				continue
+			}
+			for _, shard := range col.Plan {
+				leaders[shard.Leader]++
+			}
+		}
+	}
+	result := make(map[string]int)
+	for _, m := range machines {
+		id, err := c.dbserverID(ctx, m)
+		if err != nil {
+			return nil, maskAny(err)
+		}
+		result[m.ID()] = leaders[id]
+	}
+	return result, nil
+}
+
+// agencyConfig is the (relevant part of the) agency configuration as returned by an agent.
+type agencyConfig struct {
+	LeaderID      string `json:"leaderId"`
+	Configuration struct {
+		ID     string   `json:"id"`
+		Active []string `json:"active"`
+	} `json:"configuration"`
+}
+
+// agencyLeader returns the machine (out of the given list) with the agent that is the agency leader.
+func (c *chaosMonkey) agencyLeader(ctx context.Context, agentMachines MachineList) (cluster.Machine, error) {
+	for _, m := range agentMachines {
+		var config agencyConfig
+		if err := c.getJSON(ctx, m.AgentURL(), "/_api/agency/config", &config); err != nil {
+			continue
+		}
+		if config.LeaderID != "" && config.LeaderID == config.Configuration.ID {
+			return m, nil
+		}
+	}
+	return nil, maskAny(fmt.Errorf("No agent reports to be the agency leader"))
+}
+
+// failoverLeader returns the machine (out of the given list) running the single server that
+// currently leads the active failover deployment.
+// The first endpoint reported by `/_api/cluster/endpoints` is the leader.
+func (c *chaosMonkey) failoverLeader(ctx context.Context, singleMachines MachineList) (cluster.Machine, error) {
+	var lastErr error
+	for _, m := range singleMachines {
+		var resp struct {
+			Endpoints []struct {
+				Endpoint string `json:"endpoint"`
+			} `json:"endpoints"`
+		}
+		if err := c.getJSON(ctx, m.SingleURL(), "/_api/cluster/endpoints", &resp); err != nil {
+			lastErr = err
+			continue
+		}
+		if len(resp.Endpoints) == 0 {
+			lastErr = fmt.Errorf("single server on %s reports no endpoints", m.ID())
+			continue
+		}
+		leader := resp.Endpoints[0].Endpoint
+		for _, x := range singleMachines {
+			if strings.HasSuffix(leader, "://"+x.SingleURL().Host) {
+				return x, nil
+			}
+		}
+		return nil, maskAny(fmt.Errorf("Failover leader %s is not a known single server", leader))
+	}
+	if lastErr == nil {
+		lastErr = fmt.Errorf("no single servers")
+	}
+	return nil, maskAny(lastErr)
+}
+
+// checkAgencyHealth checks that one of the agents on the given machines reports a leader
+// and an agency of the given size.
+func (c *chaosMonkey) checkAgencyHealth(ctx context.Context, agentMachines MachineList, size int) error {
+	var lastErr error
+	for _, m := range agentMachines {
+		var config agencyConfig
+		if err := c.getJSON(ctx, m.AgentURL(), "/_api/agency/config", &config); err != nil {
+			lastErr = err
+			continue
+		}
+		if config.LeaderID == "" {
+			lastErr = fmt.Errorf("agent on %s reports no leader", m.ID())
+			continue
+		}
+		if len(config.Configuration.Active) != size {
+			lastErr = fmt.Errorf("agent on %s reports %d active agents, expected %d", m.ID(), len(config.Configuration.Active), size)
+			continue
+		}
+		return nil
+	}
+	if lastErr == nil {
+		lastErr = fmt.Errorf("no agents")
+	}
+	return maskAny(lastErr)
+}
+
+// arangodVersion fetches the version of the dbserver on the given machine.
+func (c *chaosMonkey) arangodVersion(ctx context.Context, m cluster.Machine) (string, error) {
+	var resp struct {
+		Version string `json:"version"`
+	}
+	if err := c.getJSON(ctx, m.DBServerURL(), "/_api/version", &resp); err != nil {
+		return "", maskAny(err)
+	}
+	return resp.Version, nil
+}
@@ -3,11 +3,11 @@ package chaos
 import (
 	"context"
 	"fmt"
-	"math/rand"
 	"sync"
 	"time"
 
 	cluster "github.com/arangodb-helper/testagent/service/cluster"
+	"github.com/arangodb-helper/testagent/service/test"
 	logging "github.com/op/go-logging"
 )
 
@@ -38,52 +38,235 @@ type ChaosMonkey interface {
 
 	// Return all actions possible by the chaos monkey
 	Actions() []Action
+
+	// Seed returns the seed used for all random decisions
+	Seed() int64
+
+	// Faults returns all faults that are currently active
+	Faults() []Fault
+
+	// Pacing returns the current pacing of chaos
+	Pacing() ChaosPacing
+
+	// SetPacing changes the pacing of chaos
+	SetPacing(p ChaosPacing) error
+
+	// InjectFault injects a fault requested by a test script and blocks until it has been applied
+	InjectFault(ctx context.Context, req test.FaultRequest) error
 }
 
 type ChaosMonkeyConfig struct {
-	MaxMachines         int  // Maximum number of machines to allow in a cluster.
-	DisableNetworkChaos bool // If set to true, no network chaos is ever introduced
-	ChaosLevel          int  // Chaos level
+	MaxMachines          int    // Maximum number of machines to allow in a cluster.
+	DisableNetworkChaos  bool   // If set to true, no network chaos is ever introduced
+	ChaosLevel           int    // Chaos level
+	Seed                 int64  // Seed for all random decisions. If 0, a seed is derived from the current time
+	JournalPath          string // Path of the file all decisions are appended to. If empty, no journal is written
+	ReplayJournal        string // Path of a journal to replay. If set, decisions are taken from this journal
+	ScenarioPath         string // Path of a scenario file. If set, this scenario is run instead of random chaos
+	CleanOutBeforeRemove bool   // If set, the dbserver of a machine is cleaned out before the machine is removed
+
+	Budget  FaultBudget    // Limits the faults that are active at the same time
+	Pacing  ChaosPacing    // Pauses between actions & duration of network faults. If empty, DefaultChaosPacing is used
+	Weights map[string]int // Weights of actions (by name, see ParseActionWeights). Actions not listed have weight 1
+
+	RecoverySLA time.Duration // Maximum time the cluster may need to recover from a fault. If 0, recovery is measured but not checked
+
+	DegradeChaosLevel int           // Minimum chaos level at which network degradation (latency, packet loss) is introduced
+	DegradeLatency    time.Duration // Latency added by network degradation actions
+	DegradeJitter     time.Duration // Jitter (+/-) of the latency added by network degradation actions
+	DegradePacketLoss float64       // Percentage of packets lost by network degradation actions
+
+	ResourceChaosLevel int     // Minimum chaos level at which CPU & memory of servers are limited
+	ThrottleCPUs       float64 // Number of CPUs a server can use while its CPU is throttled
+	MemoryLimit        int64   // Number of bytes of memory a server can use while its memory is limited
+
+	DiskChaosLevel  int   // Minimum chaos level at which data volumes are filled
+	DiskFillReserve int64 // Number of bytes left free when filling a data volume
+	DiskFillMax     int64 // Maximum number of bytes written when filling a data volume
+
+	EnableClockSkew bool          // If set, the clocks of servers are skewed (requires a server image that preloads libfaketime)
+	MaxClockSkew    time.Duration // Maximum offset (forward or backward) of a skewed clock
+
+	UpgradeTimeout time.Duration // Maximum time the upgrade of all machines (to the upgrade image of the cluster) may take. If 0, it is not limited
 }
 
-// NewChaosMonkey creates a new chaos monkey for the given cluster
-func NewChaosMonkey(log *logging.Logger, cluster cluster.Cluster, config ChaosMonkeyConfig) ChaosMonkey {
+// NewChaosMonkey creates a new chaos monkey for the given cluster.
+// Violations of the recovery SLA are reported to the given listener (if not nil).
+// Actions that need servers that do not exist in the deployment mode of the cluster are left out.
+func NewChaosMonkey(log *logging.Logger, cl cluster.Cluster, listener test.TestListener, config ChaosMonkeyConfig) (ChaosMonkey, error) {
+	var replay []JournalEntry
+	if config.ReplayJournal != "" {
+		var err error
+		if replay, err = ReadJournal(config.ReplayJournal); err != nil {
+			return nil, maskAny(err)
+		}
+		log.Infof("Replaying %d chaos decisions from %s", len(replay), config.ReplayJournal)
+	}
 	c := &chaosMonkey{
 		ChaosMonkeyConfig: config,
 		log:               log,
-		cluster:           cluster,
+		cluster:           cl,
+		listener:          listener,
+		decisions:         newDecisions(log, config.Seed, replay),
+		faults:            newFaults(config.Budget),
+		pacing:            config.Pacing,
+	}
+	if c.pacing == (ChaosPacing{}) {
+		c.pacing = DefaultChaosPacing()
+	}
+	if err := c.pacing.Validate(); err != nil {
+		return nil, maskAny(err)
+	}
+	if config.JournalPath != "" {
+		j, err := openJournal(config.JournalPath)
+		if err != nil {
+			return nil, maskAny(err)
+		}
+		c.decisions.journal = j
+	}
+	log.Infof("Chaos seed %d", c.decisions.Seed())
+	if config.ScenarioPath != "" {
+		scenario, err := LoadScenario(config.ScenarioPath)
+		if err != nil {
+			return nil, maskAny(err)
+		}
+		for _, step := range scenario.Steps {
+			if step.Action == "reboot-machine" && cl.VolatileData() {
+				return nil, maskAny(fmt.Errorf("Scenario '%s' reboots machines, which would wipe their (tmpfs) data volumes", scenario.Name))
+			}
+		}
+		c.scenario = scenario
+	}
+	if image := cl.UpgradeImage(); image != "" {
+		if !cl.Mode().IsCluster() {
+			return nil, maskAny(fmt.Errorf("Upgrading to %s is only supported in cluster mode", image))
+		}
+		if cl.VolatileData() {
+			return nil, maskAny(fmt.Errorf("Upgrading to %s would wipe the (tmpfs) data volumes of the machines", image))
+		}
+		// Upgrades are done by upgradeLoop, so this action is never picked by chaosLoop either
+		c.upgrade = newChaosAction("Upgrade Machine", chaosLevelMin, nil)
+		c.upgrade.weight = 0
+		c.upgrade.disabled = false
 	}
+	// Faults requested by test scripts are counted by an action that is never picked by chaosLoop
+	c.requested = newChaosAction("Requested Fault", chaosLevelMin, nil)
+	c.requested.weight = 0
+	c.requested.disabled = false
 	c.actions = []*chaosAction{
-		&chaosAction{c.restartAgent, "Restart Agent", 0, 0, 0, true, 1},
-		&chaosAction{c.restartDBServer, "Restart DBServer", 0, 0, 0, true, 1},
-		&chaosAction{c.restartCoordinator, "Restart Coordinator", 0, 0, 0, true, 1},
-		&chaosAction{c.killAgent, "Kill Agent", 0, 0, 0, true, 2},
-		&chaosAction{c.killDBServer, "Kill DBServer", 0, 0, 0, true, 2},
-		&chaosAction{c.killCoordinator, "Kill Coordinator", 0, 0, 0, true, 2},
-		&chaosAction{c.rebootMachine, "Reboot Machine", 0, 0, 0, true, 3},
-		&chaosAction{c.addMachine, "Add New Machine", 0, 0, 0, true, 3},
-		&chaosAction{c.removeMachine, "Remove Machine", 0, 0, 0, true, 3},
-		&chaosAction{c.rejectAgentTraffic, "Reject Agent Traffic", 0, 0, 0, true, 4},
-		&chaosAction{c.rejectDBServerTraffic, "Reject DBServer Traffic", 0, 0, 0, true, 4},
-		&chaosAction{c.rejectCoordinatorTraffic, "Reject Coordinator Traffic", 0, 0, 0, true, 4},
-		&chaosAction{c.dropAgentTraffic, "Drop Agent Traffic", 0, 0, 0, true, 4},
-		&chaosAction{c.dropDBServerTraffic, "Drop DBServer Traffic", 0, 0, 0, true, 4},
-		&chaosAction{c.dropCoordinatorTraffic, "Drop Coordinator Traffic", 0, 0, 0, true, 4},
+		newChaosAction("Restart Agent", 1, c.restartAgent).needs(cluster.ServerRoleAgent),
+		newChaosAction("Restart DBServer", 1, c.restartDBServer).needs(cluster.ServerRoleDBServer),
+		newChaosAction("Restart Coordinator", 1, c.restartCoordinator).needs(cluster.ServerRoleCoordinator),
+		newChaosAction("Kill Agent", 2, c.killAgent).needs(cluster.ServerRoleAgent),
+		newChaosAction("Kill DBServer", 2, c.killDBServer).needs(cluster.ServerRoleDBServer),
+		newChaosAction("Kill Coordinator", 2, c.killCoordinator).needs(cluster.ServerRoleCoordinator),
+		newChaosAction("Freeze Agent", 2, c.freezeAgent).needs(cluster.ServerRoleAgent),
+		newChaosAction("Freeze DBServer", 2, c.freezeDBServer).needs(cluster.ServerRoleDBServer),
+		newChaosAction("Freeze Coordinator", 2, c.freezeCoordinator).needs(cluster.ServerRoleCoordinator),
+		newChaosAction("Reboot Machine", 3, c.rebootMachine).needs(cluster.ServerRoleDBServer, cluster.ServerRoleCoordinator).stopsMachine(),
+		newChaosAction("Add New Machine", 3, c.addMachine).needs(cluster.ServerRoleDBServer, cluster.ServerRoleCoordinator),
+		newChaosAction("Remove Machine", 3, c.removeMachine).needs(cluster.ServerRoleDBServer, cluster.ServerRoleCoordinator),
+		newChaosAction("Replace Agent Machine", 3, c.replaceAgentMachine).needs(cluster.ServerRoleAgent, cluster.ServerRoleDBServer, cluster.ServerRoleCoordinator),
+		newChaosAction("Reject Agent Traffic", 4, c.rejectAgentTraffic).needs(cluster.ServerRoleAgent),
+		newChaosAction("Reject DBServer Traffic", 4, c.rejectDBServerTraffic).needs(cluster.ServerRoleDBServer),
+		newChaosAction("Reject Coordinator Traffic", 4, c.rejectCoordinatorTraffic).needs(cluster.ServerRoleCoordinator),
+		newChaosAction("Drop Agent Traffic", 4, c.dropAgentTraffic).needs(cluster.ServerRoleAgent),
+		newChaosAction("Drop DBServer Traffic", 4, c.dropDBServerTraffic).needs(cluster.ServerRoleDBServer),
+		newChaosAction("Drop Coordinator Traffic", 4, c.dropCoordinatorTraffic).needs(cluster.ServerRoleCoordinator),
+		newChaosAction("Split Brain", 4, c.splitBrain).needs(cluster.ServerRoleDBServer, cluster.ServerRoleCoordinator),
+		newChaosAction("Restart Agency Leader", 1, c.restartAgencyLeader).needs(cluster.ServerRoleAgent),
+		newChaosAction("Restart Shard Leader", 1, c.restartShardLeader).needs(cluster.ServerRoleDBServer),
+		newChaosAction("Kill Agency Leader", 2, c.killAgencyLeader).needs(cluster.ServerRoleAgent),
+		newChaosAction("Kill Shard Leader", 2, c.killShardLeader).needs(cluster.ServerRoleDBServer),
+		newChaosAction("Partition Agency Leader", 4, c.partitionAgencyLeader).needs(cluster.ServerRoleAgent),
+		newChaosAction("Partition Shard Leader", 4, c.partitionShardLeader).needs(cluster.ServerRoleDBServer),
+		newChaosAction("Move Shard", 1, c.moveShard).needs(cluster.ServerRoleDBServer).viaAPI(),
+		newChaosAction("Resign Leadership", 1, c.resignLeadership).needs(cluster.ServerRoleDBServer).viaAPI(),
+		newChaosAction("Clean Out Server", 3, c.cleanOutServer).needs(cluster.ServerRoleDBServer).viaAPI(),
+		newChaosAction("Slow Agent Traffic", config.DegradeChaosLevel, c.slowAgentTraffic).needs(cluster.ServerRoleAgent),
+		newChaosAction("Slow DBServer Traffic", config.DegradeChaosLevel, c.slowDBServerTraffic).needs(cluster.ServerRoleDBServer),
+		newChaosAction("Slow Coordinator Traffic", config.DegradeChaosLevel, c.slowCoordinatorTraffic).needs(cluster.ServerRoleCoordinator),
+		newChaosAction("Lossy Agent Traffic", config.DegradeChaosLevel, c.lossyAgentTraffic).needs(cluster.ServerRoleAgent),
+		newChaosAction("Lossy DBServer Traffic", config.DegradeChaosLevel, c.lossyDBServerTraffic).needs(cluster.ServerRoleDBServer),
+		newChaosAction("Lossy Coordinator Traffic", config.DegradeChaosLevel, c.lossyCoordinatorTraffic).needs(cluster.ServerRoleCoordinator),
+		newChaosAction("Throttle Agent CPU", config.ResourceChaosLevel, c.throttleAgentCPU).needs(cluster.ServerRoleAgent),
+		newChaosAction("Throttle DBServer CPU", config.ResourceChaosLevel, c.throttleDBServerCPU).needs(cluster.ServerRoleDBServer),
+		newChaosAction("Throttle Coordinator CPU", config.ResourceChaosLevel, c.throttleCoordinatorCPU).needs(cluster.ServerRoleCoordinator),
+		newChaosAction("Limit Agent Memory", config.ResourceChaosLevel, c.limitAgentMemory).needs(cluster.ServerRoleAgent),
+		newChaosAction("Limit DBServer Memory", config.ResourceChaosLevel, c.limitDBServerMemory).needs(cluster.ServerRoleDBServer),
+		newChaosAction("Limit Coordinator Memory", config.ResourceChaosLevel, c.limitCoordinatorMemory).needs(cluster.ServerRoleCoordinator),
+		newChaosAction("Fill Agent Disk", config.DiskChaosLevel, c.fillAgentDisk).needs(cluster.ServerRoleAgent),
+		newChaosAction("Fill DBServer Disk", config.DiskChaosLevel, c.fillDBServerDisk).needs(cluster.ServerRoleDBServer),
+		newChaosAction("Skew Agent Clock", 3, c.skewAgentClock).needs(cluster.ServerRoleAgent),
+		newChaosAction("Skew DBServer Clock", 3, c.skewDBServerClock).needs(cluster.ServerRoleDBServer),
+		newChaosAction("Skew Coordinator Clock", 3, c.skewCoordinatorClock).needs(cluster.ServerRoleCoordinator),
+		newChaosAction("Restart Single Server", 1, c.restartSingle).needs(cluster.ServerRoleSingle),
+		newChaosAction("Kill Single Server", 2, c.killSingle).needs(cluster.ServerRoleSingle),
+		newChaosAction("Freeze Single Server", 2, c.freezeSingle).needs(cluster.ServerRoleSingle),
+		newChaosAction("Restart Failover Leader", 1, c.restartFailoverLeader).needs(cluster.ServerRoleAgent, cluster.ServerRoleSingle),
+		newChaosAction("Kill Failover Leader", 2, c.killFailoverLeader).needs(cluster.ServerRoleAgent, cluster.ServerRoleSingle),
+		newChaosAction("Partition Failover Leader", 4, c.partitionFailoverLeader).needs(cluster.ServerRoleAgent, cluster.ServerRoleSingle),
+	}
+	for _, f := range registeredActions() {
+		for _, a := range c.actions {
+			if a.name == f.Name {
+				return nil, maskAny(fmt.Errorf("Chaos action '%s' is already defined", f.Name))
+			}
+		}
+		c.actions = append(c.actions, c.newRegisteredAction(f))
+	}
+	if err := c.applyActionWeights(config.Weights); err != nil {
+		return nil, maskAny(err)
+	}
+	roles := deploymentRoles(cl.Mode())
+	var supported []*chaosAction
+	for _, a := range c.actions {
+		if !a.supports(roles, cl.Managed()) {
+			continue
+		}
+		if a.machineStop && cl.VolatileData() {
+			log.Infof("Leaving out '%s', since stopping a machine would wipe its (tmpfs) data volume", a.name)
+			continue
+		}
+		supported = append(supported, a)
 	}
+	c.actions = supported
 	c.applyChaosLevel()
-	return c
+	return c, nil
 }
 
 type chaosMonkey struct {
 	ChaosMonkeyConfig
-	mutex        sync.Mutex
-	log          *logging.Logger
-	cluster      cluster.Cluster
-	active       bool
-	cancel       context.CancelFunc
-	cancelled    bool
-	recentEvents []Event // Limit list of events (last event first)
-	actions      []*chaosAction
+	mutex           sync.Mutex
+	log             *logging.Logger
+	cluster         cluster.Cluster
+	listener        test.TestListener
+	active          bool
+	cancel          context.CancelFunc
+	cancelled       bool
+	recentEvents    []Event // Limit list of events (last event first)
+	lastEventID     int64
+	actions         []*chaosAction
+	requested       *chaosAction // Counts faults requested by test scripts
+	upgrade         *chaosAction // Counts machine upgrades done by upgradeLoop (nil if no upgrade image is set)
+	upgrading       sync.WaitGroup
+	upgradeDeadline time.Time // Time by which all machines must be upgraded (set when upgradeLoop first starts)
+	decisions       *decisions
+	faults          *faults
+	pacing          ChaosPacing
+	scenario        *Scenario // If set, this scenario is run instead of chaosLoop
+}
+
+// deploymentRoles returns the roles of the servers that exist in a deployment of the given mode.
+func deploymentRoles(mode cluster.DeploymentMode) []cluster.ServerRole {
+	switch mode {
+	case cluster.DeploymentModeSingle:
+		return []cluster.ServerRole{cluster.ServerRoleSingle}
+	case cluster.DeploymentModeActiveFailover:
+		return []cluster.ServerRole{cluster.ServerRoleAgent, cluster.ServerRoleSingle}
+	default:
+		return []cluster.ServerRole{cluster.ServerRoleAgent, cluster.ServerRoleDBServer, cluster.ServerRoleCoordinator}
+	}
 }
 
 const (
@@ -117,7 +300,21 @@ func (c *chaosMonkey) Start() {
 		c.active = true
 		c.cancelled = false
 		c.cancel = cancel
-		go c.chaosLoop(ctx)
+		c.decisions.start()
+		if c.scenario != nil {
+			go c.scenarioLoop(ctx)
+		} else {
+			// Healing continues until chaosLoop is done, such that faults introduced
+			// while stopping are healed too.
+			healCtx, stopHealing := context.WithCancel(context.Background())
+			healerDone := make(chan struct{})
+			go c.healLoop(healCtx, healerDone)
+			if c.upgrade != nil {
+				c.upgrading.Add(1)
+				go c.upgradeLoop(ctx)
+			}
+			go c.chaosLoop(ctx, stopHealing, healerDone)
+		}
 	}
 }
 
@@ -170,34 +367,69 @@ func (c *chaosMonkey) Actions() []Action {
 	for _, a := range c.actions {
 		result = append(result, a)
 	}
+	result = append(result, c.requested)
+	if c.upgrade != nil {
+		result = append(result, c.upgrade)
+	}
 	return result
 }
 
+// Faults returns all faults that are currently active
+func (c *chaosMonkey) Faults() []Fault {
+	return c.faults.Snapshot()
+}
+
+// Seed returns the seed used for all random decisions
+func (c *chaosMonkey) Seed() int64 {
+	return c.decisions.Seed()
+}
+
 // chaosLoop runs the process to actually introduce chaos
-func (c *chaosMonkey) chaosLoop(ctx context.Context) {
+func (c *chaosMonkey) chaosLoop(ctx context.Context, stopHealing context.CancelFunc, healerDone chan struct{}) {
+	defer func() {
+		// Wait for an ongoing machine upgrade, then heal all remaining faults
+		c.upgrading.Wait()
+		stopHealing()
+		<-healerDone
+		c.mutex.Lock()
+		defer c.mutex.Unlock()
+		c.active = false
+		c.cancel = nil
+	}()
 	for {
 		// Pick a random chaos action
-		action := c.actions[rand.Intn(len(c.actions))]
+		action, wait, ok := c.decisions.nextAction(c.actions)
+		if !ok {
+			c.recordEvent(newEvent("Replay of chaos journal finished"))
+			return
+		}
+		if wait > 0 {
+			select {
+			case <-ctx.Done():
+			case <-time.After(wait):
+			}
+		}
 		var delay time.Duration
-		if action.Enabled() {
+		if ctx.Err() != nil {
+			// Stopping, do not introduce any more chaos
+		} else if action != nil && action.Enabled() {
+			c.decisions.recordAction(action)
 			if action.action(ctx, action) {
 				// Chaos was introduced
-				delay = time.Second * 30
+				delay = c.pause()
 			} else {
 				// Chaos was not introduced, wait a bit shorter
-				delay = time.Second * 2
+				delay = c.Pacing().SkipPause
 			}
 		} else {
-			// Action disabled, pick a new one after a very short wait
-			delay = time.Millisecond * 50
+			// No action enabled, try again after a short wait
+			delay = c.Pacing().SkipPause
 		}
+		// Wait until the budget allows another fault
+		c.faults.waitForSlot(ctx)
 		select {
 		case <-ctx.Done():
 			c.log.Debugf("stop signaled, terminating from chaosLoop")
-			c.mutex.Lock()
-			defer c.mutex.Unlock()
-			c.active = false
-			c.cancel = nil
 			return
 		case <-time.After(delay):
 			// Continue looping
@@ -0,0 +1,61 @@
+package chaos
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/arangodb-helper/testagent/service/cluster"
+)
+
+// skewAgentClock randomly picks an agent and shifts the time it sees for a while.
+func (c *chaosMonkey) skewAgentClock(ctx context.Context, action *chaosAction) bool {
+	return c.skewClock(ctx, action, cluster.ServerRoleAgent)
+}
+
+// skewDBServerClock randomly picks a dbserver and shifts the time it sees for a while.
+func (c *chaosMonkey) skewDBServerClock(ctx context.Context, action *chaosAction) bool {
+	return c.skewClock(ctx, action, cluster.ServerRoleDBServer)
+}
+
+// skewCoordinatorClock randomly picks a coordinator and shifts the time it sees for a while.
+func (c *chaosMonkey) skewCoordinatorClock(ctx context.Context, action *chaosAction) bool {
+	return c.skewClock(ctx, action, cluster.ServerRoleCoordinator)
+}
+
+// skewClock randomly picks a server with given role and shifts the time it sees forward or backward for a while.
+// Clock skew is only introduced when enabled, since it requires a server image that preloads libfaketime.
+func (c *chaosMonkey) skewClock(ctx context.Context, action *chaosAction, role cluster.ServerRole) bool {
+	if !c.EnableClockSkew {
+		return false
+	}
+	candidates, err := c.impairCandidates(role)
+	if err != nil {
+		c.log.Infof("%s, so I cannot skew the clock of a %s now", err.Error(), role)
+		action.skipped++
+		return false
+	}
+
+	// Pick a random machine
+	m := c.decisions.pickMachine(action, candidates)
+	f, ok := c.reserveFault(action, false, machineServer{m, role})
+	if !ok {
+		return false
+	}
+	offset := c.decisions.clockSkew(action, c.MaxClockSkew)
+	timeout := c.faultTimeout(action)
+	event := c.startEvent(action, m, role, "Skewing clock of %s on %s by %s for %s", role, m.ID(), offset, timeout)
+	if err := m.SkewClock(role, offset); err != nil {
+		c.log.Errorf("Failed to skew clock of %s: %v", role, err)
+		action.failures++
+		c.finishEvent(event, err)
+		c.faults.remove(f)
+		return false
+	}
+	action.succeeded++
+	c.introducedEvent(event)
+
+	// The heal loop waits a while before removing the skew
+	c.faults.activate(f, event, fmt.Sprintf("Skewing clock of %s on %s by %s", role, m.ID(), offset), timeout,
+		fmt.Sprintf("Restoring clock of %s on %s", role, m.ID()), func() error { return m.RestoreClock(role) })
+	return true
+}
@@ -0,0 +1,273 @@
+package chaos
+
+import (
+	"math/rand"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/arangodb-helper/testagent/service/cluster"
+	logging "github.com/op/go-logging"
+)
+
+// decisions makes all random choices of the chaos monkey.
+// All choices are derived from a single seed and recorded in a journal,
+// such that a run can be replayed from that journal.
+type decisions struct {
+	mutex     sync.Mutex
+	log       *logging.Logger
+	seed      int64
+	rnd       *rand.Rand
+	journal   *journal // Can be nil
+	startedAt time.Time
+	seq       int
+
+	replaying bool
+	replay    []JournalEntry // Remaining entries to replay
+	current   []JournalEntry // Remaining entries of the action being replayed
+}
+
+// newDecisions creates a decision maker for the given seed.
+// If replay is non-empty, decisions are taken from the given journal entries
+// and the seed found in there is used for all remaining random choices.
+func newDecisions(log *logging.Logger, seed int64, replay []JournalEntry) *decisions {
+	for _, e := range replay {
+		if e.Kind == JournalEntrySeed {
+			seed = e.Seed
+			break
+		}
+	}
+	if seed == 0 {
+		seed = time.Now().UnixNano()
+	}
+	return &decisions{
+		log:       log,
+		seed:      seed,
+		rnd:       rand.New(rand.NewSource(seed)),
+		replaying: len(replay) > 0,
+		replay:    replay,
+	}
+}
+
+// Seed returns the seed used for all random choices.
+func (d *decisions) Seed() int64 {
+	return d.seed
+}
+
+// start marks the start of chaos. Only the first call has any effect.
+func (d *decisions) start() {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	if d.startedAt.IsZero() {
+		d.startedAt = time.Now()
+		d.write(JournalEntry{Kind: JournalEntrySeed, Seed: d.seed})
+	}
+}
+
+// nextAction picks the next action to run and returns how long to wait before running it.
+// The returned action is nil when no action is enabled.
+// When a replay is finished, false is returned.
+func (d *decisions) nextAction(actions []*chaosAction) (*chaosAction, time.Duration, bool) {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	if !d.replaying {
+		return d.weightedAction(actions), 0, true
+	}
+	for len(d.replay) > 0 {
+		e := d.replay[0]
+		d.replay = d.replay[1:]
+		if e.Kind != JournalEntryAction {
+			continue
+		}
+		// Collect all decisions belonging to this action
+		d.current = nil
+		for len(d.replay) > 0 && d.replay[0].Kind != JournalEntryAction {
+			if d.replay[0].Seq == e.Seq {
+				d.current = append(d.current, d.replay[0])
+			}
+			d.replay = d.replay[1:]
+		}
+		for _, a := range actions {
+			if a.Name() == e.Action {
+				return a, e.Offset - time.Since(d.startedAt), true
+			}
+		}
+		d.log.Warningf("Action '%s' from journal is unknown, skipping it", e.Action)
+	}
+	return nil, 0, false
+}
+
+// weightedAction picks one of the given enabled actions, with a chance proportional to its weight.
+// Actions with weight 0 are never picked. If no action can be picked, nil is returned.
+// The mutex must be held when calling this function.
+func (d *decisions) weightedAction(actions []*chaosAction) *chaosAction {
+	total := 0
+	for _, a := range actions {
+		if a.Enabled() && a.weight > 0 {
+			total += a.weight
+		}
+	}
+	if total == 0 {
+		return nil
+	}
+	x := d.rnd.Intn(total)
+	for _, a := range actions {
+		if !a.Enabled() || a.weight <= 0 {
+			continue
+		}
+		if x < a.weight {
+			return a
+		}
+		x -= a.weight
+	}
+	return nil
+}
+
+// recordAction records the choice of the given action in the journal.
+func (d *decisions) recordAction(action *chaosAction) {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	d.seq++
+	d.write(JournalEntry{Kind: JournalEntryAction, Action: action.Name()})
+}
+
+// pickMachine picks one of the given candidates as target for the given action.
+func (d *decisions) pickMachine(action *chaosAction, candidates MachineList) cluster.Machine {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	sorted := append(MachineList{}, candidates...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].ID() < sorted[j].ID() })
+
+	index := -1
+	if e, found := d.nextReplayed(JournalEntryMachine); found {
+		for i, m := range sorted {
+			if m.ID() == e.Machine {
+				index = i
+				break
+			}
+		}
+		if index < 0 && e.Index < len(sorted) {
+			d.log.Warningf("Machine %s from journal is not a candidate, using index %d instead", e.Machine, e.Index)
+			index = e.Index
+		}
+	}
+	if index < 0 {
+		index = d.rnd.Intn(len(sorted))
+	}
+	m := sorted[index]
+	d.write(JournalEntry{
+		Kind:       JournalEntryMachine,
+		Action:     action.Name(),
+		Machine:    m.ID(),
+		Index:      index,
+		Candidates: len(sorted),
+	})
+	return m
+}
+
+// clockSkew picks a clock offset (in whole seconds) of at least 1s and at most max, forward or backward.
+func (d *decisions) clockSkew(action *chaosAction, max time.Duration) time.Duration {
+	if max < time.Second {
+		max = time.Second
+	}
+	offset := d.duration(action, time.Second, max)
+	if d.choose(action, 2) == 0 {
+		return -offset
+	}
+	return offset
+}
+
+// duration picks a duration (in whole seconds) between min & max (inclusive) for the given action.
+func (d *decisions) duration(action *chaosAction, min, max time.Duration) time.Duration {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	var result time.Duration
+	if e, found := d.nextReplayed(JournalEntryTimeout); found {
+		result = e.Duration
+	} else {
+		seconds := int((max - min) / time.Second)
+		result = min + time.Duration(d.rnd.Intn(seconds+1))*time.Second
+	}
+	d.write(JournalEntry{
+		Kind:     JournalEntryTimeout,
+		Action:   action.Name(),
+		Duration: result,
+	})
+	return result
+}
+
+// pause picks a pause (in whole seconds) between min & max (inclusive).
+// Pauses are not journaled, since a replay uses the recorded offsets of all actions.
+func (d *decisions) pause(min, max time.Duration) time.Duration {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	seconds := int((max - min) / time.Second)
+	if seconds <= 0 {
+		return min
+	}
+	return min + time.Duration(d.rnd.Intn(seconds+1))*time.Second
+}
+
+// choose picks one of n alternatives (0 <= result < n) for the given action.
+func (d *decisions) choose(action *chaosAction, n int) int {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	index := -1
+	if e, found := d.nextReplayed(JournalEntryChoice); found && e.Index < n {
+		index = e.Index
+	}
+	if index < 0 {
+		index = d.rnd.Intn(n)
+	}
+	d.write(JournalEntry{
+		Kind:       JournalEntryChoice,
+		Action:     action.Name(),
+		Index:      index,
+		Candidates: n,
+	})
+	return index
+}
+
+// shuffle returns the given machines, sorted by ID and then shuffled for the given action.
+func (d *decisions) shuffle(action *chaosAction, machines MachineList) MachineList {
+	result := append(MachineList{}, machines...)
+	sort.Slice(result, func(i, j int) bool { return result[i].ID() < result[j].ID() })
+	for i := len(result) - 1; i > 0; i-- {
+		j := d.choose(action, i+1)
+		result[i], result[j] = result[j], result[i]
+	}
+	return result
+}
+
+// nextReplayed removes the first decision of given kind from the
+// action being replayed and returns it.
+func (d *decisions) nextReplayed(kind JournalEntryKind) (JournalEntry, bool) {
+	for i, e := range d.current {
+		if e.Kind == kind {
+			d.current = append(d.current[:i], d.current[i+1:]...)
+			return e, true
+		}
+	}
+	return JournalEntry{}, false
+}
+
+// write completes the given entry and appends it to the journal (if any).
+// The mutex must be held when calling this function.
+func (d *decisions) write(e JournalEntry) {
+	if d.journal == nil {
+		return
+	}
+	e.Seq = d.seq
+	e.Time = time.Now()
+	e.Offset = e.Time.Sub(d.startedAt)
+	if err := d.journal.Write(e); err != nil {
+		d.log.Errorf("Failed to write chaos journal: %v", err)
+	}
+}
@@ -0,0 +1,132 @@
+package chaos
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/arangodb-helper/testagent/service/cluster"
+	logging "github.com/op/go-logging"
+)
+
+var (
+	log = logging.MustGetLogger("chaos-test")
+)
+
+func fakeMachines(t *testing.T) MachineList {
+	c, err := cluster.NewFakeCluster(5, 5, 5).Create(5, false)
+	if err != nil {
+		t.Fatalf("Failed to create fake cluster: %v", err)
+	}
+	machines, err := c.Machines()
+	if err != nil {
+		t.Fatalf("Failed to get machines: %v", err)
+	}
+	return MachineList(machines)
+}
+
+func TestDecisionsReplayJournal(t *testing.T) {
+	machines := fakeMachines(t)
+	actions := []*chaosAction{
+		&chaosAction{name: "Action A", weight: 1},
+		&chaosAction{name: "Action B", weight: 1},
+		&chaosAction{name: "Action C", weight: 1},
+	}
+	path := filepath.Join(t.TempDir(), "journal.jsonl")
+	j, err := openJournal(path)
+	if err != nil {
+		t.Fatalf("Failed to open journal: %v", err)
+	}
+
+	// Record some decisions
+	d := newDecisions(log, 42, nil)
+	d.journal = j
+	d.start()
+	var names, ids []string
+	var timeouts []time.Duration
+	for i := 0; i < 10; i++ {
+		a, _, ok := d.nextAction(actions)
+		if !ok {
+			t.Fatalf("Expected an action")
+		}
+		d.recordAction(a)
+		names = append(names, a.Name())
+		ids = append(ids, d.pickMachine(a, machines).ID())
+		timeouts = append(timeouts, d.duration(a, time.Second*5, time.Second*64))
+	}
+
+	// Replay them, with machines in a different order
+	entries, err := ReadJournal(path)
+	if err != nil {
+		t.Fatalf("Failed to read journal: %v", err)
+	}
+	r := newDecisions(log, 0, entries)
+	if r.Seed() != 42 {
+		t.Errorf("Expected seed 42 from journal, got %d", r.Seed())
+	}
+	r.start()
+	reversed := MachineList{}
+	for i := len(machines) - 1; i >= 0; i-- {
+		reversed = append(reversed, machines[i])
+	}
+	for i := 0; i < 10; i++ {
+		a, wait, ok := r.nextAction(actions)
+		if !ok {
+			t.Fatalf("Expected action %d to be replayed", i)
+		}
+		if wait > time.Second {
+			t.Errorf("Expected a short wait, got %s", wait)
+		}
+		if a.Name() != names[i] {
+			t.Errorf("Expected action %s, got %s", names[i], a.Name())
+		}
+		if id := r.pickMachine(a, reversed).ID(); id != ids[i] {
+			t.Errorf("Expected machine %s, got %s", ids[i], id)
+		}
+		if timeout := r.duration(a, time.Second*5, time.Second*64); timeout != timeouts[i] {
+			t.Errorf("Expected timeout %s, got %s", timeouts[i], timeout)
+		}
+	}
+	if _, _, ok := r.nextAction(actions); ok {
+		t.Errorf("Expected replay to be finished")
+	}
+}
+
+func TestDecisionsSeed(t *testing.T) {
+	machines := fakeMachines(t)
+	action := &chaosAction{name: "Action"}
+	d1 := newDecisions(log, 7, nil)
+	d2 := newDecisions(log, 7, nil)
+	for i := 0; i < 10; i++ {
+		if m1, m2 := d1.pickMachine(action, machines), d2.pickMachine(action, machines); m1.ID() != m2.ID() {
+			t.Errorf("Expected same machine for same seed, got %s and %s", m1.ID(), m2.ID())
+		}
+	}
+}
+
+func TestDecisionsWeightedAction(t *testing.T) {
+	actions := []*chaosAction{
+		&chaosAction{name: "Often", weight: 9},
+		&chaosAction{name: "Rare", weight: 1},
+		&chaosAction{name: "Never", weight: 0},
+		&chaosAction{name: "Disabled", weight: 5, disabled: true},
+	}
+	d := newDecisions(log, 3, nil)
+	counts := make(map[string]int)
+	for i := 0; i < 1000; i++ {
+		a, _, _ := d.nextAction(actions)
+		counts[a.Name()]++
+	}
+	if counts["Never"] != 0 || counts["Disabled"] != 0 {
+		t.Errorf("Expected actions with weight 0 or disabled never to be picked, got %v", counts)
+	}
+	if counts["Often"] < 4*counts["Rare"] {
+		t.Errorf("Expected action with weight 9 to be picked far more often, got %v", counts)
+	}
+	for _, a := range actions {
+		a.disabled = true
+	}
+	if a, _, _ := d.nextAction(actions); a != nil {
+		t.Errorf("Expected no action when all are disabled, got %s", a.Name())
+	}
+}
@@ -0,0 +1,73 @@
+package chaos
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/arangodb-helper/testagent/service/cluster"
+)
+
+// fillAgentDisk randomly picks a machine with an agent and fills its data volume for a while.
+func (c *chaosMonkey) fillAgentDisk(ctx context.Context, action *chaosAction) bool {
+	return c.fillDisk(ctx, action, cluster.ServerRoleAgent)
+}
+
+// fillDBServerDisk randomly picks a machine with a dbserver and fills its data volume for a while.
+func (c *chaosMonkey) fillDBServerDisk(ctx context.Context, action *chaosAction) bool {
+	return c.fillDisk(ctx, action, cluster.ServerRoleDBServer)
+}
+
+// fillDisk randomly picks a machine with a server of given role and fills its data volume close to
+// its capacity for a while. All servers of the machine share that volume.
+// The volume is only filled if that takes at most DiskFillMax bytes, such that the disk of a
+// docker host is never filled (see --data-volume-size).
+func (c *chaosMonkey) fillDisk(ctx context.Context, action *chaosAction, role cluster.ServerRole) bool {
+	candidates, err := c.impairCandidates(role)
+	if err != nil {
+		c.log.Infof("%s, so I cannot fill the disk of a %s now", err.Error(), role)
+		action.skipped++
+		return false
+	}
+
+	// Pick a random machine
+	m := c.decisions.pickMachine(action, candidates)
+	usage, err := m.DiskUsage()
+	if err != nil {
+		c.log.Infof("Failed to get disk usage of %s (%s), so I cannot fill its disk now", m.ID(), err.Error())
+		action.skipped++
+		return false
+	}
+	size := usage.Free - c.DiskFillReserve
+	if size <= 0 {
+		c.log.Infof("Data volume of %s is already full, so I cannot fill its disk now", m.ID())
+		action.skipped++
+		return false
+	}
+	if size > c.DiskFillMax {
+		c.log.Infof("Filling data volume of %s takes %dMB, more than the maximum of %dMB", m.ID(), size/(1024*1024), c.DiskFillMax/(1024*1024))
+		action.skipped++
+		return false
+	}
+	f, ok := c.reserveFault(action, false, machineServers(m)...)
+	if !ok {
+		return false
+	}
+	timeout := c.faultTimeout(action)
+	event := c.startEvent(action, m, role, "Filling data volume of %s with %dMB (of %dMB) for %s", m.ID(), size/(1024*1024), usage.Total/(1024*1024), timeout)
+	if err := m.FillDisk(size); err != nil {
+		c.log.Errorf("Failed to fill disk: %v", err)
+		action.failures++
+		c.finishEvent(event, err)
+		// Remove what did get written
+		m.FreeDisk()
+		c.faults.remove(f)
+		return false
+	}
+	action.succeeded++
+	c.introducedEvent(event)
+
+	// The heal loop waits a while before freeing the space again
+	c.faults.activate(f, event, fmt.Sprintf("Filling data volume of %s", m.ID()), timeout,
+		fmt.Sprintf("Freeing data volume of %s", m.ID()), m.FreeDisk)
+	return true
+}
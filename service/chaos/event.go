@@ -2,16 +2,109 @@ package chaos
 
 import (
 	"fmt"
+	"strings"
 	"time"
+
+	"github.com/arangodb-helper/testagent/service/cluster"
+)
+
+// EventOutcome is the outcome of a chaos event.
+type EventOutcome string
+
+const (
+	EventInProgress = EventOutcome("")
+	EventSucceeded  = EventOutcome("succeeded")
+	EventFailed     = EventOutcome("failed")
+	EventSkipped    = EventOutcome("skipped")
 )
 
 type Event struct {
-	Time   time.Time // When did the event start
-	Action string    // What happened
+	ID          int64              // Unique ID of the event (per chaos monkey)
+	Time        time.Time          // When did the event start
+	End         time.Time          // When did the event end (zero while the chaos is still active)
+	Description string             // What happened
+	ActionID    string             // ID of the chaos action that caused the event (empty for informational events)
+	ActionName  string             // Name of the chaos action that caused the event
+	Machine     string             // ID of the target machine (empty if none)
+	Role        cluster.ServerRole // Role of the target server (empty if none)
+	Leadership  string             // Leadership held by the target server (e.g. "agency leader", empty if none)
+	Outcome     EventOutcome       // Outcome of the event
+	Error       string             // Error message of a failed event
+
+	ReadyAfter    time.Duration // Time until the impaired servers were ready again (zero if not measured)
+	InSyncAfter   time.Duration // Time until all shards were in sync again (zero if not measured)
+	RecoveryError string        // Why the cluster did not recover (empty if it did or was not measured)
+
+	RecoveryOverlapped bool // Set if other faults were active during the recovery, so its times are not meaningful
 }
 
 func (e Event) String() string {
-	return fmt.Sprintf("[%s] %s", e.Time.Format("2006-01-02 15:04:05"), e.Action)
+	var extra []string
+	if e.ActionName != "" {
+		extra = append(extra, "action="+e.ActionName)
+	}
+	if e.Machine != "" {
+		extra = append(extra, "machine="+e.Machine)
+	}
+	if e.Role != "" {
+		extra = append(extra, "role="+string(e.Role))
+	}
+	if e.Leadership != "" {
+		extra = append(extra, "leadership="+e.Leadership)
+	}
+	if e.ActionID != "" {
+		if e.Outcome == EventInProgress {
+			extra = append(extra, "outcome=in-progress")
+		} else {
+			extra = append(extra, "outcome="+string(e.Outcome))
+		}
+	}
+	if !e.End.IsZero() {
+		extra = append(extra, "end="+e.End.Format("2006-01-02 15:04:05"))
+	}
+	if e.Error != "" {
+		extra = append(extra, "error="+e.Error)
+	}
+	if e.ReadyAfter > 0 {
+		extra = append(extra, "ready-after="+e.ReadyAfter.String())
+	}
+	if e.InSyncAfter > 0 {
+		extra = append(extra, "in-sync-after="+e.InSyncAfter.String())
+	}
+	if e.RecoveryError != "" {
+		extra = append(extra, "recovery-error="+e.RecoveryError)
+	}
+	if e.RecoveryOverlapped {
+		extra = append(extra, "recovery-overlapped")
+	}
+	result := fmt.Sprintf("[%s] %s", e.Time.Format("2006-01-02 15:04:05"), e.Description)
+	if len(extra) > 0 {
+		result += " (" + strings.Join(extra, ", ") + ")"
+	}
+	return result
+}
+
+// ActiveAt returns true if the chaos of this event was active at the given time.
+// Only events of chaos actions that did not fail or get skipped can be active.
+func (e Event) ActiveAt(t time.Time) bool {
+	if e.ActionID == "" || e.Outcome == EventFailed || e.Outcome == EventSkipped {
+		return false
+	}
+	if t.Before(e.Time) {
+		return false
+	}
+	return e.End.IsZero() || !t.After(e.End)
+}
+
+// ActiveEvents returns all events (out of the given list) of which the chaos was active at the given time.
+func ActiveEvents(events []Event, t time.Time) []Event {
+	var result []Event
+	for _, e := range events {
+		if e.ActiveAt(t) {
+			result = append(result, e)
+		}
+	}
+	return result
 }
 
 // Get a list of recent events
@@ -23,23 +116,98 @@ func (c *chaosMonkey) GetRecentEvents(maxEvents int) []Event {
 	if maxEvents < len(source) {
 		source = source[:maxEvents]
 	}
-	result := make([]Event, 0, maxEvents)
+	result := make([]Event, 0, len(source))
 	return append(result, source...)
 }
 
-// newEvent creates a new event with current time and given action.
-func newEvent(action string, args ...interface{}) Event {
+// newEvent creates a new informational event with current time and given description.
+func newEvent(description string, args ...interface{}) Event {
 	return Event{
-		Time:   time.Now(),
-		Action: fmt.Sprintf(action, args...),
+		Time:        time.Now(),
+		Description: fmt.Sprintf(description, args...),
 	}
 }
 
-// recordEvent adds an event to the front of the recentEvents list.
-func (c *chaosMonkey) recordEvent(evt Event) {
+// recordEvent adds an event to the front of the recentEvents list and returns its ID.
+func (c *chaosMonkey) recordEvent(evt Event) int64 {
 	c.mutex.Lock()
 	defer c.mutex.Unlock()
 
+	c.lastEventID++
+	evt.ID = c.lastEventID
 	c.recentEvents = append([]Event{evt}, c.recentEvents...)
 	c.log.Infof("Recorded event %s", evt)
+	return evt.ID
+}
+
+// startEvent records the start of chaos introduced by the given action on the given target
+// and returns the ID of the event.
+// The machine and role are optional.
+func (c *chaosMonkey) startEvent(action *chaosAction, m cluster.Machine, role cluster.ServerRole, description string, args ...interface{}) int64 {
+	evt := newEvent(description, args...)
+	evt.ActionID = action.ID()
+	evt.ActionName = action.Name()
+	if m != nil {
+		evt.Machine = m.ID()
+	}
+	evt.Role = role
+	return c.recordEvent(evt)
+}
+
+// updateEvent calls the given function for the event with given ID (if it is still known).
+func (c *chaosMonkey) updateEvent(id int64, update func(*Event)) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	for i := range c.recentEvents {
+		if c.recentEvents[i].ID == id {
+			update(&c.recentEvents[i])
+			c.log.Infof("Updated event %s", c.recentEvents[i])
+			return
+		}
+	}
+}
+
+// finishEvent records the outcome (succeeded when err is nil, failed otherwise) of the event
+// with given ID and marks it as ended.
+func (c *chaosMonkey) finishEvent(id int64, err error) {
+	c.updateEvent(id, func(e *Event) {
+		e.End = time.Now()
+		if err != nil {
+			e.Outcome = EventFailed
+			e.Error = err.Error()
+		} else {
+			e.Outcome = EventSucceeded
+		}
+	})
+}
+
+// skipEvent records that the event with given ID did not introduce any chaos.
+func (c *chaosMonkey) skipEvent(id int64, err error) {
+	c.updateEvent(id, func(e *Event) {
+		e.End = time.Now()
+		e.Outcome = EventSkipped
+		if err != nil {
+			e.Error = err.Error()
+		}
+	})
+}
+
+// introducedEvent records that the chaos of the event with given ID was introduced successfully
+// and remains active until endEvent is called.
+func (c *chaosMonkey) introducedEvent(id int64) {
+	c.updateEvent(id, func(e *Event) {
+		e.Outcome = EventSucceeded
+	})
+}
+
+// endEvent records that the chaos of the event with given ID is no longer active.
+// A non-nil error means that removing the chaos failed.
+func (c *chaosMonkey) endEvent(id int64, err error) {
+	c.updateEvent(id, func(e *Event) {
+		e.End = time.Now()
+		if err != nil {
+			e.Error = err.Error()
+		}
+	})
 }
@@ -0,0 +1,35 @@
+package chaos
+
+import (
+	"testing"
+	"time"
+)
+
+func TestEventActiveAt(t *testing.T) {
+	start := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	events := []Event{
+		{ID: 1, Description: "healed", ActionID: "a", Time: start, End: start.Add(time.Minute), Outcome: EventSucceeded},
+		{ID: 2, Description: "still active", ActionID: "a", Time: start.Add(time.Second * 30), Outcome: EventSucceeded},
+		{ID: 3, Description: "failed", ActionID: "a", Time: start, End: start.Add(time.Second), Outcome: EventFailed},
+		{ID: 4, Description: "informational", Time: start},
+	}
+	ids := func(list []Event) []int64 {
+		var result []int64
+		for _, e := range list {
+			result = append(result, e.ID)
+		}
+		return result
+	}
+	if got := ids(ActiveEvents(events, start.Add(time.Second*10))); len(got) != 1 || got[0] != 1 {
+		t.Errorf("Expected event 1 to be active, got %v", got)
+	}
+	if got := ids(ActiveEvents(events, start.Add(time.Second*45))); len(got) != 2 {
+		t.Errorf("Expected events 1 & 2 to be active, got %v", got)
+	}
+	if got := ids(ActiveEvents(events, start.Add(time.Hour))); len(got) != 1 || got[0] != 2 {
+		t.Errorf("Expected event 2 to be active, got %v", got)
+	}
+	if got := ids(ActiveEvents(events, start.Add(-time.Second))); len(got) != 0 {
+		t.Errorf("Expected no active events, got %v", got)
+	}
+}
@@ -0,0 +1,403 @@
+package chaos
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/arangodb-helper/testagent/service/cluster"
+)
+
+const (
+	// faultSettleTime is the minimum time a fault without a heal function (e.g. a killed server)
+	// is considered active.
+	faultSettleTime = time.Second * 10
+	// faultReadyTimeout is the maximum time a fault without a heal function is considered active.
+	faultReadyTimeout = time.Minute * 5
+	// maxImpairedSingles is the maximum number of impaired single servers at any time.
+	// An active failover deployment cannot survive the loss of both its leader and its follower.
+	maxImpairedSingles = 1
+)
+
+// FaultBudget limits the faults that are active at the same time.
+type FaultBudget struct {
+	MaxFaults       int // Maximum number of faults active at the same time
+	MaxAgents       int // Maximum number of impaired agents at any time
+	MaxDBServers    int // Maximum number of impaired dbservers at any time (typically replicationFactor-1)
+	MaxCoordinators int // Maximum number of impaired coordinators at any time
+}
+
+// Fault describes an active fault.
+type Fault struct {
+	Action      string    // Name of the action that introduced the fault
+	Description string    // Human readable description of the fault
+	Since       time.Time // When the fault was introduced
+	Until       time.Time // When the fault will be healed (zero when waiting for servers to become ready)
+}
+
+// machineServer is a single server (with given role) on a machine.
+type machineServer struct {
+	machine cluster.Machine
+	role    cluster.ServerRole
+}
+
+// fault is a server impairment introduced by a chaos action.
+type fault struct {
+	action      string
+	source      *chaosAction // Action that introduced the fault
+	description string
+	servers     []machineServer // Servers impaired by the fault
+	exclusive   bool            // If set, no other fault can be active at the same time
+	since       time.Time
+	until       time.Time    // When to heal the fault
+	heal        func() error // Removes the fault (nil when the fault disappears by itself)
+	healing     string       // Description of the heal step used in events
+	active      bool         // Set when the fault has been introduced
+	held        bool         // Set when the fault is removed by the action itself (instead of the heal loop)
+	event       int64        // ID of the event that introduced the fault
+}
+
+// faults tracks all active faults, such that the budget is never exceeded.
+type faults struct {
+	mutex   sync.Mutex
+	budget  FaultBudget
+	list    []*fault
+	removed chan struct{} // Closed (and replaced) when a fault is removed
+}
+
+// newFaults creates a fault tracker with given budget.
+// Limits below 1 are raised to 1.
+func newFaults(budget FaultBudget) *faults {
+	for _, limit := range []*int{&budget.MaxFaults, &budget.MaxAgents, &budget.MaxDBServers, &budget.MaxCoordinators} {
+		if *limit < 1 {
+			*limit = 1
+		}
+	}
+	return &faults{
+		budget:  budget,
+		removed: make(chan struct{}),
+	}
+}
+
+// reserve checks that impairing the given servers fits in the budget and registers a fault for them.
+// Impaired servers that are not covered by a tracked fault (e.g. servers that crashed on their own)
+// count against the budget as well.
+// The returned fault must be activated or removed by the caller.
+func (f *faults) reserve(action *chaosAction, exclusive bool, impaired []machineServer, servers ...machineServer) (*fault, error) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	if len(f.list) >= f.budget.MaxFaults {
+		return nil, maskAny(fmt.Errorf("There are already %d faults active", len(f.list)))
+	}
+	if exclusive && len(f.list) > 0 {
+		return nil, maskAny(fmt.Errorf("There are other faults active"))
+	}
+	counts := make(map[cluster.ServerRole]int)
+	for _, x := range f.list {
+		if x.exclusive {
+			return nil, maskAny(fmt.Errorf("A fault (%s) is active that does not allow other faults", x.action))
+		}
+		for _, s := range x.servers {
+			counts[s.role]++
+		}
+	}
+	if !exclusive {
+		for _, s := range servers {
+			counts[s.role]++
+		}
+		// Servers that are down on their own (or are still down after their fault was dropped)
+		// are impaired just as well
+		for _, s := range impaired {
+			if !f.covers(s) && !containsServer(servers, s) {
+				counts[s.role]++
+			}
+		}
+		if counts[cluster.ServerRoleAgent] > f.budget.MaxAgents {
+			return nil, maskAny(fmt.Errorf("The budget of %d impaired agents would be exceeded", f.budget.MaxAgents))
+		}
+		if counts[cluster.ServerRoleDBServer] > f.budget.MaxDBServers {
+			return nil, maskAny(fmt.Errorf("The budget of %d impaired dbservers would be exceeded", f.budget.MaxDBServers))
+		}
+		if counts[cluster.ServerRoleCoordinator] > f.budget.MaxCoordinators {
+			return nil, maskAny(fmt.Errorf("The budget of %d impaired coordinators would be exceeded", f.budget.MaxCoordinators))
+		}
+		if counts[cluster.ServerRoleSingle] > maxImpairedSingles {
+			return nil, maskAny(fmt.Errorf("Only %d single server may be impaired at any time", maxImpairedSingles))
+		}
+	}
+	x := &fault{
+		action:    action.Name(),
+		source:    action,
+		servers:   servers,
+		exclusive: exclusive,
+		since:     time.Now(),
+	}
+	f.list = append(f.list, x)
+	return x, nil
+}
+
+// activate marks the given fault (introduced by the event with given ID) as introduced.
+// If heal is not nil, it is called after the given duration to remove the fault.
+// Otherwise the fault is removed once all its servers are ready again.
+func (f *faults) activate(x *fault, event int64, description string, duration time.Duration, healing string, heal func() error) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	x.event = event
+	x.description = description
+	x.since = time.Now()
+	if heal != nil {
+		x.until = x.since.Add(duration)
+	} else {
+		x.until = x.since.Add(faultSettleTime)
+	}
+	x.healing = healing
+	x.heal = heal
+	x.active = true
+}
+
+// hold marks the given fault as introduced by an action that removes it itself when done.
+func (f *faults) hold(x *fault, description string) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	x.description = description
+	x.since = time.Now()
+	x.active = true
+	x.held = true
+}
+
+// remove the given fault from the list of faults.
+func (f *faults) remove(x *fault) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	for i, y := range f.list {
+		if y == x {
+			f.list = append(f.list[:i], f.list[i+1:]...)
+			close(f.removed)
+			f.removed = make(chan struct{})
+			return
+		}
+	}
+}
+
+// waitForSlot blocks until the budget allows another fault.
+// It returns false if the context was cancelled.
+func (f *faults) waitForSlot(ctx context.Context) bool {
+	for {
+		f.mutex.Lock()
+		count, removed := len(f.list), f.removed
+		f.mutex.Unlock()
+		if count < f.budget.MaxFaults {
+			return ctx.Err() == nil
+		}
+		select {
+		case <-ctx.Done():
+			return false
+		case <-removed:
+			// Check again
+		}
+	}
+}
+
+// due returns all introduced faults that are due for healing (or checking) at the given time.
+func (f *faults) due(now time.Time) []*fault {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	var result []*fault
+	for _, x := range f.list {
+		if x.active && !x.held && !now.Before(x.until) {
+			result = append(result, x)
+		}
+	}
+	return result
+}
+
+// introduced returns all faults that have been introduced.
+func (f *faults) introduced() []*fault {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	var result []*fault
+	for _, x := range f.list {
+		if x.active {
+			result = append(result, x)
+		}
+	}
+	return result
+}
+
+// Snapshot returns a description of all active faults.
+func (f *faults) Snapshot() []Fault {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	var result []Fault
+	for _, x := range f.list {
+		if !x.active {
+			continue
+		}
+		fault := Fault{
+			Action:      x.action,
+			Description: x.description,
+			Since:       x.since,
+		}
+		if x.heal != nil && !x.held {
+			fault.Until = x.until
+		}
+		result = append(result, fault)
+	}
+	return result
+}
+
+// healLoop heals all faults when they are due, until the given context is cancelled.
+// Then all remaining faults are healed immediately.
+func (c *chaosMonkey) healLoop(ctx context.Context, done chan struct{}) {
+	defer close(done)
+	for {
+		select {
+		case <-ctx.Done():
+			for _, x := range c.faults.introduced() {
+				c.healFault(x)
+			}
+			return
+		case <-time.After(time.Second):
+			for _, x := range c.faults.due(time.Now()) {
+				if x.heal != nil {
+					c.healFault(x)
+				} else if c.serversReady(x.servers) {
+					c.endEvent(x.event, nil)
+					c.faults.remove(x)
+					go c.measureRecovery(x, x.since)
+				} else if time.Since(x.since) > faultReadyTimeout {
+					c.endEvent(x.event, fmt.Errorf("Servers not ready after %s", faultReadyTimeout))
+					c.faults.remove(x)
+					go c.measureRecovery(x, x.since)
+				}
+			}
+		}
+	}
+}
+
+// healFault removes the given fault and records the end of its event.
+// Unless the fault is held by its action, the recovery of the cluster is measured afterwards.
+// Held faults are measured when their action releases them (see releaseFault).
+func (c *chaosMonkey) healFault(x *fault) {
+	since := x.since
+	if x.heal != nil {
+		c.log.Infof("%s...", x.healing)
+		err := x.heal()
+		if err != nil {
+			c.log.Errorf("%s failed: %v", x.healing, err)
+		}
+		c.endEvent(x.event, err)
+		since = time.Now()
+	} else if !x.held {
+		c.endEvent(x.event, nil)
+	}
+	c.faults.remove(x)
+	if !x.held {
+		go c.measureRecovery(x, since)
+	}
+}
+
+// releaseFault removes the given fault, that is held by its action, once the action (with given event) is done.
+// The recovery of the cluster is measured afterwards, waiting for the given servers to become ready
+// (the servers impaired by the fault may be gone, e.g. after removing a machine).
+func (c *chaosMonkey) releaseFault(x *fault, event int64, servers []machineServer) {
+	c.faults.remove(x)
+	x.event = event
+	x.servers = servers
+	go c.measureRecovery(x, time.Now())
+}
+
+// serversReady returns true if all given servers are ready.
+func (c *chaosMonkey) serversReady(servers []machineServer) bool {
+	for _, s := range servers {
+		var err error
+		switch s.role {
+		case cluster.ServerRoleAgent:
+			err = s.machine.TestAgentStatus()
+		case cluster.ServerRoleDBServer:
+			err = s.machine.TestDBServerStatus()
+		case cluster.ServerRoleSingle:
+			err = s.machine.TestSingleStatus()
+		default:
+			err = s.machine.TestCoordinatorStatus()
+		}
+		if err != nil {
+			return false
+		}
+	}
+	return true
+}
+
+// covers returns true if the given server is impaired by a tracked fault.
+// The caller must hold the mutex.
+func (f *faults) covers(s machineServer) bool {
+	for _, x := range f.list {
+		if containsServer(x.servers, s) {
+			return true
+		}
+	}
+	return false
+}
+
+// containsServer returns true if the given server is found (by machine ID & role) in the given list.
+func containsServer(list []machineServer, s machineServer) bool {
+	for _, x := range list {
+		if x.role == s.role && x.machine.ID() == s.machine.ID() {
+			return true
+		}
+	}
+	return false
+}
+
+// machineServers returns all servers on the given machine.
+func machineServers(m cluster.Machine) []machineServer {
+	var result []machineServer
+	for _, role := range machineRoles(m) {
+		result = append(result, machineServer{m, role})
+	}
+	return result
+}
+
+// reserveFault reserves a fault for the given servers.
+// If the budget does not allow this fault, the action is counted as skipped and false is returned.
+func (c *chaosMonkey) reserveFault(action *chaosAction, exclusive bool, servers ...machineServer) (*fault, bool) {
+	f, err := c.reserveFaultFor(action, exclusive, servers...)
+	if err != nil {
+		c.log.Infof("%s, so I cannot run '%s' now", err.Error(), action.Name())
+		action.skipped++
+		return nil, false
+	}
+	return f, true
+}
+
+// reserveFaultFor reserves a fault for the given servers.
+// When a dbserver is about to be impaired, all dbservers that are not ready are counted
+// against the budget, including those that are not covered by any tracked fault.
+func (c *chaosMonkey) reserveFaultFor(action *chaosAction, exclusive bool, servers ...machineServer) (*fault, error) {
+	var impaired []machineServer
+	for _, s := range servers {
+		if s.role == cluster.ServerRoleDBServer {
+			notReady, err := c.notReadyDBServers()
+			if err != nil {
+				return nil, maskAny(fmt.Errorf("Cannot check dbserver status: %v", err))
+			}
+			for _, m := range notReady {
+				impaired = append(impaired, machineServer{m, cluster.ServerRoleDBServer})
+			}
+			break
+		}
+	}
+	f, err := c.faults.reserve(action, exclusive, impaired, servers...)
+	if err != nil {
+		return nil, maskAny(err)
+	}
+	return f, nil
+}
@@ -0,0 +1,80 @@
+package chaos
+
+import (
+	"testing"
+
+	"github.com/arangodb-helper/testagent/service/cluster"
+)
+
+func TestFaultBudget(t *testing.T) {
+	machines := fakeMachines(t)
+	action := &chaosAction{name: "Action"}
+	f := newFaults(FaultBudget{MaxFaults: 3, MaxAgents: 1, MaxDBServers: 2, MaxCoordinators: 1})
+
+	agent, err := f.reserve(action, false, nil, machineServer{machines[0], cluster.ServerRoleAgent})
+	if err != nil {
+		t.Fatalf("Expected agent fault to fit in budget: %v", err)
+	}
+	if _, err := f.reserve(action, false, nil, machineServer{machines[1], cluster.ServerRoleAgent}); err == nil {
+		t.Errorf("Expected second agent fault to exceed budget")
+	}
+	if _, err := f.reserve(action, false, nil, machineServer{machines[1], cluster.ServerRoleDBServer}); err != nil {
+		t.Errorf("Expected dbserver fault to fit in budget: %v", err)
+	}
+	if _, err := f.reserve(action, true, nil, machineServer{machines[2], cluster.ServerRoleCoordinator}); err == nil {
+		t.Errorf("Expected exclusive fault to fail while other faults are active")
+	}
+	if _, err := f.reserve(action, false, nil, machineServer{machines[2], cluster.ServerRoleDBServer}); err != nil {
+		t.Errorf("Expected second dbserver fault to fit in budget: %v", err)
+	}
+	if _, err := f.reserve(action, false, nil, machineServer{machines[3], cluster.ServerRoleCoordinator}); err == nil {
+		t.Errorf("Expected fourth fault to exceed maximum number of faults")
+	}
+
+	f.remove(agent)
+	if _, err := f.reserve(action, false, nil, machineServer{machines[1], cluster.ServerRoleAgent}); err != nil {
+		t.Errorf("Expected agent fault to fit in budget after removal: %v", err)
+	}
+}
+
+func TestFaultBudgetUntracked(t *testing.T) {
+	machines := fakeMachines(t)
+	action := &chaosAction{name: "Action"}
+	f := newFaults(FaultBudget{MaxFaults: 3, MaxDBServers: 1})
+	down := []machineServer{{machines[0], cluster.ServerRoleDBServer}}
+
+	if _, err := f.reserve(action, false, down, machineServer{machines[1], cluster.ServerRoleDBServer}); err == nil {
+		t.Errorf("Expected dbserver fault to exceed budget while another dbserver is down")
+	}
+	if _, err := f.reserve(action, false, down, machineServer{machines[0], cluster.ServerRoleDBServer}); err != nil {
+		t.Errorf("Expected fault on the dbserver that is down to fit in budget: %v", err)
+	}
+	f = newFaults(FaultBudget{MaxFaults: 3, MaxDBServers: 2})
+	if _, err := f.reserve(action, false, nil, machineServer{machines[0], cluster.ServerRoleDBServer}); err != nil {
+		t.Fatalf("Expected dbserver fault to fit in budget: %v", err)
+	}
+	if _, err := f.reserve(action, false, down, machineServer{machines[1], cluster.ServerRoleDBServer}); err != nil {
+		t.Errorf("Expected dbserver that is down because of a tracked fault not to be counted twice: %v", err)
+	}
+}
+
+func TestFaultExclusive(t *testing.T) {
+	machines := fakeMachines(t)
+	action := &chaosAction{name: "Action"}
+	f := newFaults(FaultBudget{MaxFaults: 5})
+
+	x, err := f.reserve(action, true, nil, machineServers(machines[0])...)
+	if err != nil {
+		t.Fatalf("Expected exclusive fault to fit in budget: %v", err)
+	}
+	if _, err := f.reserve(action, false, nil, machineServer{machines[1], cluster.ServerRoleCoordinator}); err == nil {
+		t.Errorf("Expected fault to fail while an exclusive fault is active")
+	}
+	if len(f.Snapshot()) != 0 {
+		t.Errorf("Expected reserved fault not to be listed before it is introduced")
+	}
+	f.activate(x, 0, "partition", 0, "heal", func() error { return nil })
+	if len(f.Snapshot()) != 1 {
+		t.Errorf("Expected introduced fault to be listed")
+	}
+}
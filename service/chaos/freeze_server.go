@@ -0,0 +1,122 @@
+package chaos
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/arangodb-helper/testagent/service/cluster"
+)
+
+// freezeAgent randomly picks an agent and freezes it for a while (as if it hangs).
+// Before doing so, it first checks if freezing an agent is allowed on the current cluster state.
+func (c *chaosMonkey) freezeAgent(ctx context.Context, action *chaosAction) bool {
+	agentMachines, _, err := c.checkAgencyReadyStatus()
+	if err != nil {
+		c.log.Infof("Not all agents are ready (%s), so I cannot freeze one now", err.Error())
+		action.skipped++
+		return false
+	}
+	if len(agentMachines) < 3 {
+		c.log.Infof("There are too few (%d) agents in the cluster, so I cannot freeze one now", len(agentMachines))
+		action.skipped++
+		return false
+	}
+
+	// Pick a random agent machine
+	m := c.decisions.pickMachine(action, agentMachines)
+	f, ok := c.reserveFault(action, false, machineServer{m, cluster.ServerRoleAgent})
+	if !ok {
+		return false
+	}
+	timeout := c.faultTimeout(action)
+	event := c.startEvent(action, m, cluster.ServerRoleAgent, "Freezing agent on %s for %s", m.ID(), timeout)
+	if err := m.PauseAgent(); err != nil {
+		c.log.Errorf("Failed to freeze agent: %v", err)
+		action.failures++
+		c.finishEvent(event, err)
+		c.faults.remove(f)
+		return false
+	}
+	action.succeeded++
+	c.introducedEvent(event)
+
+	// The heal loop waits a while before resuming the agent
+	c.faults.activate(f, event, fmt.Sprintf("Freezing agent on %s", m.ID()), timeout, fmt.Sprintf("Resuming agent on %s", m.ID()), m.ResumeAgent)
+	return true
+}
+
+// freezeDBServer randomly picks a dbserver and freezes it for a while (as if it hangs).
+// Before doing so, it first checks if freezing a dbserver is allowed on the current cluster state.
+func (c *chaosMonkey) freezeDBServer(ctx context.Context, action *chaosAction) bool {
+	readyMachines, _, err := c.checkDBServerReadyStatus()
+	if err != nil {
+		c.log.Infof("Failed to check dbserver ready status (%s), so I cannot freeze one now", err.Error())
+		action.skipped++
+		return false
+	}
+	if len(readyMachines) == 0 {
+		c.log.Infof("There are no ready dbservers in the cluster, so I cannot freeze one now")
+		action.skipped++
+		return false
+	}
+
+	// Pick a random dbserver machine
+	m := c.decisions.pickMachine(action, readyMachines)
+	f, ok := c.reserveFault(action, false, machineServer{m, cluster.ServerRoleDBServer})
+	if !ok {
+		return false
+	}
+	timeout := c.faultTimeout(action)
+	event := c.startEvent(action, m, cluster.ServerRoleDBServer, "Freezing dbserver on %s for %s", m.ID(), timeout)
+	if err := m.PauseDBServer(); err != nil {
+		c.log.Errorf("Failed to freeze dbserver: %v", err)
+		action.failures++
+		c.finishEvent(event, err)
+		c.faults.remove(f)
+		return false
+	}
+	action.succeeded++
+	c.introducedEvent(event)
+
+	// The heal loop waits a while before resuming the dbserver
+	c.faults.activate(f, event, fmt.Sprintf("Freezing dbserver on %s", m.ID()), timeout, fmt.Sprintf("Resuming dbserver on %s", m.ID()), m.ResumeDBServer)
+	return true
+}
+
+// freezeCoordinator randomly picks a coordinator and freezes it for a while (as if it hangs).
+// Before doing so, it first checks if freezing a coordinator is allowed on the current cluster state.
+func (c *chaosMonkey) freezeCoordinator(ctx context.Context, action *chaosAction) bool {
+	readyMachines, _, err := c.checkCoordinatorReadyStatus()
+	if err != nil {
+		c.log.Infof("Failed to check coordinator ready status (%s), so I cannot freeze one now", err.Error())
+		action.skipped++
+		return false
+	}
+	if len(readyMachines) <= 1 {
+		c.log.Infof("There are too few (%d) ready coordinators in the cluster, so I cannot freeze one now", len(readyMachines))
+		action.skipped++
+		return false
+	}
+
+	// Pick a random coordinator machine
+	m := c.decisions.pickMachine(action, readyMachines)
+	f, ok := c.reserveFault(action, false, machineServer{m, cluster.ServerRoleCoordinator})
+	if !ok {
+		return false
+	}
+	timeout := c.faultTimeout(action)
+	event := c.startEvent(action, m, cluster.ServerRoleCoordinator, "Freezing coordinator on %s for %s", m.ID(), timeout)
+	if err := m.PauseCoordinator(); err != nil {
+		c.log.Errorf("Failed to freeze coordinator: %v", err)
+		action.failures++
+		c.finishEvent(event, err)
+		c.faults.remove(f)
+		return false
+	}
+	action.succeeded++
+	c.introducedEvent(event)
+
+	// The heal loop waits a while before resuming the coordinator
+	c.faults.activate(f, event, fmt.Sprintf("Freezing coordinator on %s", m.ID()), timeout, fmt.Sprintf("Resuming coordinator on %s", m.ID()), m.ResumeCoordinator)
+	return true
+}
@@ -0,0 +1,160 @@
+package chaos
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/arangodb-helper/testagent/service/cluster"
+)
+
+const (
+	healthStatusGood = "GOOD"
+)
+
+// serverHealth is the health of a single server as reported by `/_admin/cluster/health`.
+type serverHealth struct {
+	Role      string `json:"Role"`
+	Status    string `json:"Status"`
+	Endpoint  string `json:"Endpoint"`
+	ShortName string `json:"ShortName"`
+}
+
+// clusterHealth fetches the health of all servers, through the first coordinator that answers.
+// The result is indexed by server ID.
+func (c *chaosMonkey) clusterHealth(ctx context.Context) (map[string]serverHealth, error) {
+	machines, err := c.cluster.Machines()
+	if err != nil {
+		return nil, maskAny(err)
+	}
+	var lastErr error
+	for _, m := range machines {
+		var resp struct {
+			Health map[string]serverHealth `json:"Health"`
+		}
+		if err := c.getJSON(ctx, m.CoordinatorURL(), "/_admin/cluster/health", &resp); err != nil {
+			lastErr = err
+			continue
+		}
+		return resp.Health, nil
+	}
+	if lastErr == nil {
+		lastErr = fmt.Errorf("no coordinators")
+	}
+	return nil, maskAny(lastErr)
+}
+
+// unhealthyServers returns the machines (out of the given list) for which the cluster health
+// reports a server with given role that is not GOOD.
+// Role is one of `Agent`, `DBServer` or `Coordinator`.
+func unhealthyServers(health map[string]serverHealth, role string, machines MachineList, serverURL func(cluster.Machine) url.URL) MachineList {
+	var result MachineList
+	for _, m := range machines {
+		u := serverURL(m)
+		for _, h := range health {
+			if h.Role != role || h.Status == healthStatusGood {
+				continue
+			}
+			if strings.HasSuffix(h.Endpoint, "://"+u.Host) {
+				result = append(result, m)
+				break
+			}
+		}
+	}
+	return result
+}
+
+// checkShardsInSync checks that no shard would lose its write concern when another
+// dbserver goes down. A shard that is not fully in sync must have more in-sync replicas
+// (leader + in-sync followers) than its write concern.
+func (c *chaosMonkey) checkShardsInSync(ctx context.Context) error {
+	coordinators, _, err := c.checkCoordinatorReadyStatus()
+	if err != nil {
+		return maskAny(err)
+	}
+	if len(coordinators) == 0 {
+		return maskAny(fmt.Errorf("No ready coordinator"))
+	}
+	coordinator := coordinators[0]
+	dist, err := c.shardDistribution(ctx, coordinator)
+	if err != nil {
+		return maskAny(err)
+	}
+	for db, collections := range dist {
+		for name, col := range collections {
+			writeConcern := 0
+			for shardID, plan := range col.Plan {
+				current := col.Current[shardID]
+				inSync := len(current.Followers)
+				if current.Leader != "" {
+					inSync++
+				}
+				planned := 1 + len(plan.Followers)
+				if inSync >= planned {
+					continue
+				}
+				if writeConcern == 0 {
+					if writeConcern, err = c.collectionWriteConcern(ctx, coordinator, db, name); err != nil {
+						return maskAny(err)
+					}
+				}
+				if inSync <= writeConcern {
+					return maskAny(fmt.Errorf("Shard %s of %s/%s has %d of %d replicas in sync (write concern %d)", shardID, db, name, inSync, planned, writeConcern))
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// shardsOutOfSync returns the number of shards that do not have their planned leader
+// or of which not all planned followers are in sync.
+func (c *chaosMonkey) shardsOutOfSync(ctx context.Context) (int, error) {
+	if !c.cluster.Mode().IsCluster() {
+		// There are no shards outside of cluster mode
+		return 0, nil
+	}
+	coordinators, _, err := c.checkCoordinatorReadyStatus()
+	if err != nil {
+		return 0, maskAny(err)
+	}
+	if len(coordinators) == 0 {
+		return 0, maskAny(fmt.Errorf("No ready coordinator"))
+	}
+	dist, err := c.shardDistribution(ctx, coordinators[0])
+	if err != nil {
+		return 0, maskAny(err)
+	}
+	count := 0
+	for _, collections := range dist {
+		for _, col := range collections {
+			for shardID, plan := range col.Plan {
+				current := col.Current[shardID]
+				if current.Leader != plan.Leader || len(current.Followers) < len(plan.Followers) {
+					count++
+				}
+			}
+		}
+	}
+	return count, nil
+}
+
+// collectionWriteConcern fetches the write concern of the given collection.
+func (c *chaosMonkey) collectionWriteConcern(ctx context.Context, coordinator cluster.Machine, db, collection string) (int, error) {
+	var props struct {
+		WriteConcern         int `json:"writeConcern"`
+		MinReplicationFactor int `json:"minReplicationFactor"`
+	}
+	path := "/_db/" + url.PathEscape(db) + "/_api/collection/" + url.PathEscape(collection) + "/properties"
+	if err := c.getJSON(ctx, coordinator.CoordinatorURL(), path, &props); err != nil {
+		return 0, maskAny(err)
+	}
+	if props.WriteConcern > 0 {
+		return props.WriteConcern, nil
+	}
+	if props.MinReplicationFactor > 0 {
+		return props.MinReplicationFactor, nil
+	}
+	return 1, nil
+}
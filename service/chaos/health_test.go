@@ -0,0 +1,25 @@
+package chaos
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/arangodb-helper/testagent/service/cluster"
+)
+
+func TestUnhealthyServers(t *testing.T) {
+	machines := fakeMachines(t)
+	endpoint := func(m cluster.Machine) string {
+		u := m.DBServerURL()
+		return fmt.Sprintf("tcp://%s", u.Host)
+	}
+	health := map[string]serverHealth{
+		"PRMR-1": {Role: "DBServer", Status: "GOOD", Endpoint: endpoint(machines[0])},
+		"PRMR-2": {Role: "DBServer", Status: "BAD", Endpoint: endpoint(machines[1])},
+		"AGNT-1": {Role: "Agent", Status: "FAILED", Endpoint: endpoint(machines[2])},
+	}
+	unhealthy := unhealthyServers(health, "DBServer", machines, cluster.Machine.DBServerURL)
+	if len(unhealthy) != 1 || unhealthy[0] != machines[1] {
+		t.Errorf("Expected only %s to be unhealthy, got %v", machines[1].ID(), unhealthy)
+	}
+}
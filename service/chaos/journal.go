@@ -0,0 +1,93 @@
+package chaos
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"sync"
+	"time"
+)
+
+type JournalEntryKind string
+
+const (
+	JournalEntrySeed    = JournalEntryKind("seed")    // Seed used for all random decisions
+	JournalEntryAction  = JournalEntryKind("action")  // Choice of a chaos action
+	JournalEntryMachine = JournalEntryKind("machine") // Choice of a target machine
+	JournalEntryTimeout = JournalEntryKind("timeout") // Choice of a network fault or freeze duration
+	JournalEntryChoice  = JournalEntryKind("choice")  // Any other choice among a number of alternatives
+)
+
+// JournalEntry is a single decision made by the chaos monkey.
+type JournalEntry struct {
+	Seq        int              `json:"seq"`                  // Sequence number of the action this decision belongs to
+	Time       time.Time        `json:"time"`                 // When was the decision made
+	Offset     time.Duration    `json:"offset"`               // Time since the chaos monkey was first started
+	Kind       JournalEntryKind `json:"kind"`                 // What kind of decision is this
+	Seed       int64            `json:"seed,omitempty"`       // Random seed (kind=seed)
+	Action     string           `json:"action,omitempty"`     // Name of the chosen action
+	Machine    string           `json:"machine,omitempty"`    // ID of the chosen machine (kind=machine)
+	Index      int              `json:"index,omitempty"`      // Index of the chosen machine in the list of candidates, sorted by ID (kind=machine), or index of the chosen alternative (kind=choice)
+	Candidates int              `json:"candidates,omitempty"` // Number of candidate machines (kind=machine) or alternatives (kind=choice)
+	Duration   time.Duration    `json:"duration,omitempty"`   // Chosen duration (kind=timeout)
+}
+
+// journal is an append-only file of JSON encoded journal entries, one per line.
+type journal struct {
+	mutex sync.Mutex
+	f     *os.File
+	enc   *json.Encoder
+}
+
+// openJournal opens the journal at the given path for appending.
+func openJournal(path string) (*journal, error) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, maskAny(err)
+	}
+	return &journal{
+		f:   f,
+		enc: json.NewEncoder(f),
+	}, nil
+}
+
+// Write appends the given entry to the journal and syncs it to disk.
+func (j *journal) Write(e JournalEntry) error {
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+
+	if err := j.enc.Encode(e); err != nil {
+		return maskAny(err)
+	}
+	if err := j.f.Sync(); err != nil {
+		return maskAny(err)
+	}
+	return nil
+}
+
+// ReadJournal reads all entries from the journal at the given path.
+func ReadJournal(path string) ([]JournalEntry, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, maskAny(err)
+	}
+	defer f.Close()
+
+	var result []JournalEntry
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		var e JournalEntry
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			return nil, maskAny(err)
+		}
+		result = append(result, e)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, maskAny(err)
+	}
+	return result, nil
+}
@@ -2,7 +2,9 @@ package chaos
 
 import (
 	"context"
-	"math/rand"
+	"fmt"
+
+	"github.com/arangodb-helper/testagent/service/cluster"
 )
 
 // killAgent randomly picks an agent and kills it (the hard way).
@@ -21,15 +23,22 @@ func (c *chaosMonkey) killAgent(ctx context.Context, action *chaosAction) bool {
 	}
 
 	// Pick a random agent machine
-	m := agentMachines[rand.Intn(len(agentMachines))]
-	c.recordEvent(newEvent("Killing agent on %s...", m.ID()))
+	m := c.decisions.pickMachine(action, agentMachines)
+	f, ok := c.reserveFault(action, false, machineServer{m, cluster.ServerRoleAgent})
+	if !ok {
+		return false
+	}
+	event := c.startEvent(action, m, cluster.ServerRoleAgent, "Killing agent on %s", m.ID())
 	if err := m.KillAgent(); err != nil {
 		c.log.Errorf("Failed to kill agent: %v", err)
 		action.failures++
-		c.recordEvent(newEvent("Kill agent on %s failed: %v", m.ID(), err))
+		c.finishEvent(event, err)
+		c.faults.remove(f)
 	} else {
 		action.succeeded++
-		c.recordEvent(newEvent("Kill agent on %s succeeded", m.ID()))
+		c.introducedEvent(event)
+		// The fault is active until the agent is ready again
+		c.faults.activate(f, event, fmt.Sprintf("Kill agent on %s", m.ID()), 0, "", nil)
 	}
 	return true
 }
@@ -37,17 +46,12 @@ func (c *chaosMonkey) killAgent(ctx context.Context, action *chaosAction) bool {
 // killDBServer randomly picks a dbserver and kills it.
 // Before doing so, it first checks if killing a dbserver is allowed on the current cluster state.
 func (c *chaosMonkey) killDBServer(ctx context.Context, action *chaosAction) bool {
-	readyMachines, notReadyServers, err := c.checkDBServerReadyStatus()
+	readyMachines, _, err := c.checkDBServerReadyStatus()
 	if err != nil {
 		c.log.Infof("Failed to check dbserver ready status (%s), so I cannot kill one now", err.Error())
 		action.skipped++
 		return false
 	}
-	if notReadyServers > 0 {
-		c.log.Infof("At least 1 dbserver is already down (%d down), so I cannot kill one now", notReadyServers)
-		action.skipped++
-		return false
-	}
 	if len(readyMachines) == 0 {
 		c.log.Infof("There are no ready dbservers in the cluster, so I cannot kill one now")
 		action.skipped++
@@ -55,15 +59,22 @@ func (c *chaosMonkey) killDBServer(ctx context.Context, action *chaosAction) boo
 	}
 
 	// Pick a random dbserver machine
-	m := readyMachines[rand.Intn(len(readyMachines))]
-	c.recordEvent(newEvent("Killing dbserver on %s...", m.ID()))
+	m := c.decisions.pickMachine(action, readyMachines)
+	f, ok := c.reserveFault(action, false, machineServer{m, cluster.ServerRoleDBServer})
+	if !ok {
+		return false
+	}
+	event := c.startEvent(action, m, cluster.ServerRoleDBServer, "Killing dbserver on %s", m.ID())
 	if err := m.KillDBServer(); err != nil {
 		c.log.Errorf("Failed to kill dbserver: %v", err)
 		action.failures++
-		c.recordEvent(newEvent("Kill dbserver on %s failed: %v", m.ID(), err))
+		c.finishEvent(event, err)
+		c.faults.remove(f)
 	} else {
 		action.succeeded++
-		c.recordEvent(newEvent("Kill dbserver on %s succeeded", m.ID()))
+		c.introducedEvent(event)
+		// The fault is active until the dbserver is ready again
+		c.faults.activate(f, event, fmt.Sprintf("Kill dbserver on %s", m.ID()), 0, "", nil)
 	}
 	return true
 }
@@ -84,15 +95,22 @@ func (c *chaosMonkey) killCoordinator(ctx context.Context, action *chaosAction)
 	}
 
 	// Pick a random coordinator machine
-	m := readyMachines[rand.Intn(len(readyMachines))]
-	c.recordEvent(newEvent("Killing coordinator on %s...", m.ID()))
+	m := c.decisions.pickMachine(action, readyMachines)
+	f, ok := c.reserveFault(action, false, machineServer{m, cluster.ServerRoleCoordinator})
+	if !ok {
+		return false
+	}
+	event := c.startEvent(action, m, cluster.ServerRoleCoordinator, "Killing coordinator on %s", m.ID())
 	if err := m.KillCoordinator(); err != nil {
 		c.log.Errorf("Failed to kill coordinator: %v", err)
 		action.failures++
-		c.recordEvent(newEvent("Kill coordinator on %s failed: %v", m.ID(), err))
+		c.finishEvent(event, err)
+		c.faults.remove(f)
 	} else {
 		action.succeeded++
-		c.recordEvent(newEvent("Kill coordinator on %s succeeded", m.ID()))
+		c.introducedEvent(event)
+		// The fault is active until the coordinator is ready again
+		c.faults.activate(f, event, fmt.Sprintf("Kill coordinator on %s", m.ID()), 0, "", nil)
 	}
 	return true
 }
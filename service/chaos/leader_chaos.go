@@ -0,0 +1,242 @@
+package chaos
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/arangodb-helper/testagent/service/cluster"
+)
+
+// serverOperation describes how chaos is introduced on a single server.
+type serverOperation struct {
+	verb    string                      // Used in events, e.g. "Killing"
+	network bool                        // If set, the operation introduces network chaos
+	apply   func(cluster.Machine) error // Introduces the chaos
+	heal    func(cluster.Machine) error // Removes the chaos after a while (nil when the chaos disappears by itself)
+}
+
+var (
+	agentOperations = map[string]serverOperation{
+		"kill":      {"Killing", false, cluster.Machine.KillAgent, nil},
+		"restart":   {"Restarting", false, cluster.Machine.RestartAgent, nil},
+		"freeze":    {"Freezing", false, cluster.Machine.PauseAgent, cluster.Machine.ResumeAgent},
+		"partition": {"Dropping network traffic to", true, cluster.Machine.DropAgentTraffic, cluster.Machine.AcceptAgentTraffic},
+	}
+	dbserverOperations = map[string]serverOperation{
+		"kill":      {"Killing", false, cluster.Machine.KillDBServer, nil},
+		"restart":   {"Restarting", false, cluster.Machine.RestartDBServer, nil},
+		"freeze":    {"Freezing", false, cluster.Machine.PauseDBServer, cluster.Machine.ResumeDBServer},
+		"partition": {"Dropping network traffic to", true, cluster.Machine.DropDBServerTraffic, cluster.Machine.AcceptDBServerTraffic},
+	}
+	coordinatorOperations = map[string]serverOperation{
+		"kill":      {"Killing", false, cluster.Machine.KillCoordinator, nil},
+		"restart":   {"Restarting", false, cluster.Machine.RestartCoordinator, nil},
+		"freeze":    {"Freezing", false, cluster.Machine.PauseCoordinator, cluster.Machine.ResumeCoordinator},
+		"partition": {"Dropping network traffic to", true, cluster.Machine.DropCoordinatorTraffic, cluster.Machine.AcceptCoordinatorTraffic},
+	}
+	singleOperations = map[string]serverOperation{
+		"kill":      {"Killing", false, cluster.Machine.KillSingle, nil},
+		"restart":   {"Restarting", false, cluster.Machine.RestartSingle, nil},
+		"freeze":    {"Freezing", false, cluster.Machine.PauseSingle, cluster.Machine.ResumeSingle},
+		"partition": {"Dropping network traffic to", true, cluster.Machine.DropSingleTraffic, cluster.Machine.AcceptSingleTraffic},
+	}
+)
+
+// killAgencyLeader kills the agent that is the current agency leader.
+func (c *chaosMonkey) killAgencyLeader(ctx context.Context, action *chaosAction) bool {
+	return c.agencyLeaderChaos(ctx, action, agentOperations["kill"])
+}
+
+// restartAgencyLeader restarts the agent that is the current agency leader.
+func (c *chaosMonkey) restartAgencyLeader(ctx context.Context, action *chaosAction) bool {
+	return c.agencyLeaderChaos(ctx, action, agentOperations["restart"])
+}
+
+// partitionAgencyLeader drops all network traffic to the agent that is the current agency leader for a while.
+func (c *chaosMonkey) partitionAgencyLeader(ctx context.Context, action *chaosAction) bool {
+	return c.agencyLeaderChaos(ctx, action, agentOperations["partition"])
+}
+
+// killShardLeader randomly picks a dbserver that leads shards of user collections and kills it.
+func (c *chaosMonkey) killShardLeader(ctx context.Context, action *chaosAction) bool {
+	return c.shardLeaderChaos(ctx, action, dbserverOperations["kill"])
+}
+
+// restartShardLeader randomly picks a dbserver that leads shards of user collections and restarts it.
+func (c *chaosMonkey) restartShardLeader(ctx context.Context, action *chaosAction) bool {
+	return c.shardLeaderChaos(ctx, action, dbserverOperations["restart"])
+}
+
+// partitionShardLeader randomly picks a dbserver that leads shards of user collections and drops
+// all network traffic to it for a while.
+func (c *chaosMonkey) partitionShardLeader(ctx context.Context, action *chaosAction) bool {
+	return c.shardLeaderChaos(ctx, action, dbserverOperations["partition"])
+}
+
+// killFailoverLeader kills the single server that currently leads the active failover deployment.
+func (c *chaosMonkey) killFailoverLeader(ctx context.Context, action *chaosAction) bool {
+	return c.failoverLeaderChaos(ctx, action, singleOperations["kill"])
+}
+
+// restartFailoverLeader restarts the single server that currently leads the active failover deployment.
+func (c *chaosMonkey) restartFailoverLeader(ctx context.Context, action *chaosAction) bool {
+	return c.failoverLeaderChaos(ctx, action, singleOperations["restart"])
+}
+
+// partitionFailoverLeader drops all network traffic to the single server that currently leads
+// the active failover deployment for a while.
+func (c *chaosMonkey) partitionFailoverLeader(ctx context.Context, action *chaosAction) bool {
+	return c.failoverLeaderChaos(ctx, action, singleOperations["partition"])
+}
+
+// agencyLeaderChaos finds the agency leader and applies the given operation to it.
+// Before doing so, it first checks if impairing an agent is allowed on the current cluster state.
+func (c *chaosMonkey) agencyLeaderChaos(ctx context.Context, action *chaosAction, op serverOperation) bool {
+	if op.network && c.DisableNetworkChaos {
+		return false
+	}
+	agentMachines, _, err := c.checkAgencyReadyStatus()
+	if err != nil {
+		c.log.Infof("Not all agents are ready (%s), so I cannot impair the agency leader now", err.Error())
+		action.skipped++
+		return false
+	}
+	if len(agentMachines) < 3 {
+		c.log.Infof("There are too few (%d) agents in the cluster, so I cannot impair the agency leader now", len(agentMachines))
+		action.skipped++
+		return false
+	}
+	leader, err := c.agencyLeader(ctx, agentMachines)
+	if err != nil {
+		c.log.Infof("%s, so I cannot impair the agency leader now", err.Error())
+		action.skipped++
+		return false
+	}
+
+	m := c.decisions.pickMachine(action, MachineList{leader})
+	return c.leaderChaos(action, m, cluster.ServerRoleAgent, "agency leader", op)
+}
+
+// failoverLeaderChaos finds the leader of the active failover deployment and applies the given
+// operation to it.
+// Before doing so, it first checks that all single servers are ready, such that a follower can take over.
+func (c *chaosMonkey) failoverLeaderChaos(ctx context.Context, action *chaosAction, op serverOperation) bool {
+	if op.network && c.DisableNetworkChaos {
+		return false
+	}
+	singleMachines, err := c.impairCandidates(cluster.ServerRoleSingle)
+	if err != nil {
+		c.log.Infof("%s, so I cannot impair the failover leader now", err.Error())
+		action.skipped++
+		return false
+	}
+	if len(singleMachines) < 2 {
+		c.log.Infof("There are too few (%d) single servers in the deployment, so I cannot impair the failover leader now", len(singleMachines))
+		action.skipped++
+		return false
+	}
+	leader, err := c.failoverLeader(ctx, singleMachines)
+	if err != nil {
+		c.log.Infof("%s, so I cannot impair the failover leader now", err.Error())
+		action.skipped++
+		return false
+	}
+
+	m := c.decisions.pickMachine(action, MachineList{leader})
+	return c.leaderChaos(action, m, cluster.ServerRoleSingle, "failover leader", op)
+}
+
+// shardLeaderChaos randomly picks a dbserver that leads shards of user collections and applies
+// the given operation to it.
+// Before doing so, it first checks if impairing a dbserver is allowed on the current cluster state.
+func (c *chaosMonkey) shardLeaderChaos(ctx context.Context, action *chaosAction, op serverOperation) bool {
+	if op.network && c.DisableNetworkChaos {
+		return false
+	}
+	readyMachines, _, err := c.checkDBServerReadyStatus()
+	if err != nil {
+		c.log.Infof("Failed to check dbserver ready status (%s), so I cannot impair a shard leader now", err.Error())
+		action.skipped++
+		return false
+	}
+	counts, err := c.shardLeaderCounts(ctx, readyMachines, true)
+	if err != nil {
+		c.log.Infof("Failed to fetch shard leaders (%s), so I cannot impair a shard leader now", err.Error())
+		action.skipped++
+		return false
+	}
+	var leaders MachineList
+	for _, m := range readyMachines {
+		if counts[m.ID()] > 0 {
+			leaders = append(leaders, m)
+		}
+	}
+	if len(leaders) == 0 {
+		c.log.Infof("There are no dbservers leading shards of user collections, so I cannot impair a shard leader now")
+		action.skipped++
+		return false
+	}
+
+	m := c.decisions.pickMachine(action, leaders)
+	return c.leaderChaos(action, m, cluster.ServerRoleDBServer, fmt.Sprintf("leader of %d shards", counts[m.ID()]), op)
+}
+
+// leaderChaos applies the given operation to the server with given role on the given machine.
+// The leadership held by that server (if any) is recorded in the event.
+func (c *chaosMonkey) leaderChaos(action *chaosAction, m cluster.Machine, role cluster.ServerRole, leadership string, op serverOperation) bool {
+	f, ok := c.reserveFault(action, false, machineServer{m, role})
+	if !ok {
+		return false
+	}
+	target := string(role)
+	if leadership != "" {
+		target = fmt.Sprintf("%s (%s)", role, leadership)
+	}
+	timeout := c.operationTimeout(action, op)
+	event := c.startEvent(action, m, role, "%s %s on %s", op.verb, target, m.ID())
+	c.updateEvent(event, func(e *Event) { e.Leadership = leadership })
+	if err := op.apply(m); err != nil {
+		c.log.Errorf("%s %s failed: %v", op.verb, target, err)
+		action.failures++
+		c.finishEvent(event, err)
+		if op.heal != nil {
+			// Remove the rules that did get applied
+			op.heal(m)
+		}
+		c.faults.remove(f)
+		return false
+	}
+	action.succeeded++
+	c.introducedEvent(event)
+
+	description := fmt.Sprintf("%s %s on %s", op.verb, target, m.ID())
+	if op.heal == nil {
+		// The fault is active until the server is ready again
+		c.faults.activate(f, event, description, 0, "", nil)
+	} else {
+		// The heal loop waits a while before removing the chaos
+		c.faults.activate(f, event, description, timeout, op.healing(role, m), func() error { return op.heal(m) })
+	}
+	return true
+}
+
+// operationTimeout picks how long the chaos of the given operation lasts (0 when it disappears by itself).
+func (c *chaosMonkey) operationTimeout(action *chaosAction, op serverOperation) time.Duration {
+	switch {
+	case op.heal == nil:
+		return 0
+	case op.network:
+		return c.networkTimeout(action)
+	default:
+		return c.faultTimeout(action)
+	}
+}
+
+// healing returns the description of the heal step of the operation on the server with given role on the given machine.
+func (op serverOperation) healing(role cluster.ServerRole, m cluster.Machine) string {
+	if op.network {
+		return fmt.Sprintf("Restoring network traffic to %s on %s", role, m.ID())
+	}
+	return fmt.Sprintf("Resuming %s on %s", role, m.ID())
+}
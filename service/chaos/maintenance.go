@@ -0,0 +1,333 @@
+package chaos
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/arangodb-helper/testagent/service/cluster"
+)
+
+const (
+	// agencyJobTimeout is the maximum time a supervision job may take before it is considered stuck.
+	agencyJobTimeout = time.Minute * 10
+)
+
+// shardRef identifies a single shard of a user collection.
+type shardRef struct {
+	database   string
+	collection string
+	shard      string
+	plan       shardInfo
+}
+
+// moveShard picks a random shard of a user collection and moves its leader to another dbserver.
+func (c *chaosMonkey) moveShard(ctx context.Context, action *chaosAction) bool {
+	coordinator, dbservers, ok := c.maintenanceTargets(ctx, action, "move a shard")
+	if !ok {
+		return false
+	}
+	dist, err := c.shardDistribution(ctx, coordinator)
+	if err != nil {
+		c.log.Infof("Failed to fetch shard distribution (%s), so I cannot move a shard now", err.Error())
+		action.skipped++
+		return false
+	}
+	shards := userShards(dist)
+	if len(shards) == 0 {
+		c.log.Infof("There are no shards of user collections, so I cannot move a shard now")
+		action.skipped++
+		return false
+	}
+	s := shards[c.decisions.choose(action, len(shards))]
+
+	// Find the machine of the leader and the dbservers that do not have this shard yet
+	var from cluster.Machine
+	var targets []string
+	targetMachines := make(map[string]cluster.Machine)
+	for _, id := range sortedServerIDs(dbservers) {
+		m := dbservers[id]
+		if id == s.plan.Leader {
+			from = m
+		} else if !slices.Contains(s.plan.Followers, id) {
+			targets = append(targets, id)
+			targetMachines[id] = m
+		}
+	}
+	if from == nil || len(targets) == 0 {
+		c.log.Infof("There is no dbserver to move shard %s of %s/%s to", s.shard, s.database, s.collection)
+		action.skipped++
+		return false
+	}
+	to := targets[c.decisions.choose(action, len(targets))]
+
+	f, ok := c.reserveFault(action, false, machineServer{from, cluster.ServerRoleDBServer})
+	if !ok {
+		return false
+	}
+	c.faults.hold(f, fmt.Sprintf("Move shard %s of %s/%s", s.shard, s.database, s.collection))
+	event := c.startEvent(action, from, cluster.ServerRoleDBServer, "Moving shard %s of %s/%s from %s to %s", s.shard, s.database, s.collection, from.ID(), targetMachines[to].ID())
+	defer c.releaseFault(f, event, f.servers)
+	err = c.runAgencyJob(ctx, coordinator, "/_admin/cluster/moveShard", map[string]string{
+		"database":   s.database,
+		"collection": s.collection,
+		"shard":      s.shard,
+		"fromServer": s.plan.Leader,
+		"toServer":   to,
+	})
+	c.recordMaintenanceResult(action, event, "Move shard", err)
+	return true
+}
+
+// resignLeadership picks a random dbserver that leads shards of user collections and lets it resign
+// its leadership of all shards.
+func (c *chaosMonkey) resignLeadership(ctx context.Context, action *chaosAction) bool {
+	coordinator, dbservers, ok := c.maintenanceTargets(ctx, action, "resign leadership")
+	if !ok {
+		return false
+	}
+	machines := dbserverMachines(dbservers)
+	counts, err := c.shardLeaderCounts(ctx, machines, true)
+	if err != nil {
+		c.log.Infof("Failed to fetch shard leaders (%s), so I cannot resign leadership now", err.Error())
+		action.skipped++
+		return false
+	}
+	var leaders MachineList
+	for _, m := range machines {
+		if counts[m.ID()] > 0 {
+			leaders = append(leaders, m)
+		}
+	}
+	if len(leaders) == 0 {
+		c.log.Infof("There are no dbservers leading shards of user collections, so I cannot resign leadership now")
+		action.skipped++
+		return false
+	}
+	m := c.decisions.pickMachine(action, leaders)
+	serverID := serverIDOf(dbservers, m)
+
+	f, ok := c.reserveFault(action, false, machineServer{m, cluster.ServerRoleDBServer})
+	if !ok {
+		return false
+	}
+	c.faults.hold(f, fmt.Sprintf("Resign leadership of dbserver on %s", m.ID()))
+	event := c.startEvent(action, m, cluster.ServerRoleDBServer, "Resigning leadership of dbserver %s (leader of %d shards) on %s", serverID, counts[m.ID()], m.ID())
+	defer c.releaseFault(f, event, f.servers)
+	err = c.runAgencyJob(ctx, coordinator, "/_admin/cluster/resignLeadership", map[string]string{"server": serverID})
+	c.recordMaintenanceResult(action, event, "Resign leadership", err)
+	return true
+}
+
+// cleanOutServer picks a random dbserver and moves all its shards to other dbservers.
+// Afterwards the dbserver is made available for new shards again.
+func (c *chaosMonkey) cleanOutServer(ctx context.Context, action *chaosAction) bool {
+	coordinator, dbservers, ok := c.maintenanceTargets(ctx, action, "clean out a dbserver")
+	if !ok {
+		return false
+	}
+	dist, err := c.shardDistribution(ctx, coordinator)
+	if err != nil {
+		c.log.Infof("Failed to fetch shard distribution (%s), so I cannot clean out a dbserver now", err.Error())
+		action.skipped++
+		return false
+	}
+	replicas := 1
+	for _, collections := range dist {
+		for _, col := range collections {
+			for _, plan := range col.Plan {
+				if n := 1 + len(plan.Followers); n > replicas {
+					replicas = n
+				}
+			}
+		}
+	}
+	if len(dbservers) <= replicas {
+		c.log.Infof("There are too few (%d) dbservers for a replication factor of %d, so I cannot clean out one now", len(dbservers), replicas)
+		action.skipped++
+		return false
+	}
+	m := c.decisions.pickMachine(action, dbserverMachines(dbservers))
+	serverID := serverIDOf(dbservers, m)
+
+	f, ok := c.reserveFault(action, false, machineServer{m, cluster.ServerRoleDBServer})
+	if !ok {
+		return false
+	}
+	c.faults.hold(f, fmt.Sprintf("Clean out dbserver on %s", m.ID()))
+	event := c.startEvent(action, m, cluster.ServerRoleDBServer, "Cleaning out dbserver %s on %s", serverID, m.ID())
+	defer c.releaseFault(f, event, f.servers)
+	err = c.cleanOutDBServer(ctx, coordinator, serverID)
+	if err == nil {
+		if restoreErr := c.restoreCleanedServer(ctx, serverID); restoreErr != nil {
+			err = fmt.Errorf("Failed to make cleaned out dbserver available again: %v", restoreErr)
+		}
+	}
+	c.recordMaintenanceResult(action, event, "Clean out server", err)
+	return true
+}
+
+// maintenanceTargets returns a ready coordinator and all dbservers (by server ID) when
+// a maintenance operation is allowed on the current cluster state.
+// Otherwise the action is counted as skipped and false is returned.
+func (c *chaosMonkey) maintenanceTargets(ctx context.Context, action *chaosAction, what string) (cluster.Machine, map[string]cluster.Machine, bool) {
+	readyMachines, _, err := c.checkDBServerReadyStatus()
+	if err != nil {
+		c.log.Infof("Failed to check dbserver ready status (%s), so I cannot %s now", err.Error(), what)
+		action.skipped++
+		return nil, nil, false
+	}
+	coordinators, _, err := c.checkCoordinatorReadyStatus()
+	if err != nil || len(coordinators) == 0 {
+		c.log.Infof("There is no ready coordinator, so I cannot %s now", what)
+		action.skipped++
+		return nil, nil, false
+	}
+	dbservers := make(map[string]cluster.Machine)
+	for _, m := range readyMachines {
+		id, err := c.dbserverID(ctx, m)
+		if err != nil {
+			c.log.Infof("Failed to fetch ID of dbserver on %s (%s), so I cannot %s now", m.ID(), err.Error(), what)
+			action.skipped++
+			return nil, nil, false
+		}
+		dbservers[id] = m
+	}
+	return coordinators[0], dbservers, true
+}
+
+// cleanOutDBServer moves all shards away from the dbserver with given ID and waits until that is done.
+func (c *chaosMonkey) cleanOutDBServer(ctx context.Context, coordinator cluster.Machine, serverID string) error {
+	return maskAny(c.runAgencyJob(ctx, coordinator, "/_admin/cluster/cleanOutServer", map[string]string{"server": serverID}))
+}
+
+// restoreCleanedServer removes the dbserver with given ID from the list of cleaned out servers,
+// such that it is used for new shards again.
+func (c *chaosMonkey) restoreCleanedServer(ctx context.Context, serverID string) error {
+	agentMachines, _, err := c.checkAgencyReadyStatus()
+	if err != nil {
+		return maskAny(err)
+	}
+	leader, err := c.agencyLeader(ctx, agentMachines)
+	if err != nil {
+		return maskAny(err)
+	}
+	transaction := [][]interface{}{{
+		map[string]interface{}{
+			"/arango/Target/CleanedServers": map[string]string{"op": "erase", "val": serverID},
+		},
+	}}
+	var result interface{}
+	if err := c.postJSON(ctx, leader.AgentURL(), "/_api/agency/write", transaction, &result); err != nil {
+		return maskAny(err)
+	}
+	return nil
+}
+
+// runAgencyJob starts a supervision job by posting the given body to the given path on the
+// coordinator and waits until the job has finished.
+// An error is returned when the job failed or did not finish in time.
+func (c *chaosMonkey) runAgencyJob(ctx context.Context, coordinator cluster.Machine, path string, body interface{}) error {
+	var started struct {
+		ID string `json:"id"`
+	}
+	if err := c.postJSON(ctx, coordinator.CoordinatorURL(), path, body, &started); err != nil {
+		return maskAny(err)
+	}
+	if started.ID == "" {
+		return maskAny(fmt.Errorf("No job ID returned by %s", path))
+	}
+	deadline := time.Now().Add(agencyJobTimeout)
+	for {
+		var job struct {
+			Status string `json:"status"`
+		}
+		if err := c.getJSON(ctx, coordinator.CoordinatorURL(), "/_admin/cluster/queryAgencyJob?id="+url.QueryEscape(started.ID), &job); err != nil {
+			c.log.Debugf("Failed to query agency job %s: %v", started.ID, err)
+		} else {
+			switch job.Status {
+			case "Finished":
+				return nil
+			case "Failed":
+				return maskAny(fmt.Errorf("Agency job %s failed", started.ID))
+			}
+		}
+		if time.Now().After(deadline) {
+			return maskAny(fmt.Errorf("Agency job %s did not finish within %s (status '%s')", started.ID, agencyJobTimeout, job.Status))
+		}
+		if !c.sleep(ctx, time.Second*2) {
+			return maskAny(ctx.Err())
+		}
+	}
+}
+
+// recordMaintenanceResult updates the statistics of the given action and finishes its event.
+func (c *chaosMonkey) recordMaintenanceResult(action *chaosAction, event int64, what string, err error) {
+	if err != nil {
+		c.log.Errorf("%s failed: %v", what, err)
+		action.failures++
+	} else {
+		action.succeeded++
+	}
+	c.finishEvent(event, err)
+}
+
+// userShards returns all shards of user collections in the given distribution, sorted by
+// database, collection & shard name.
+func userShards(dist map[string]map[string]collectionShards) []shardRef {
+	var result []shardRef
+	for db, collections := range dist {
+		for name, col := range collections {
+			if strings.HasPrefix(name, "_") {
+				continue
+			}
+			for shard, plan := range col.Plan {
+				result = append(result, shardRef{database: db, collection: name, shard: shard, plan: plan})
+			}
+		}
+	}
+	sort.Slice(result, func(i, j int) bool {
+		a, b := result[i], result[j]
+		if a.database != b.database {
+			return a.database < b.database
+		}
+		if a.collection != b.collection {
+			return a.collection < b.collection
+		}
+		return a.shard < b.shard
+	})
+	return result
+}
+
+// sortedServerIDs returns the server IDs of the given dbservers in sorted order.
+func sortedServerIDs(dbservers map[string]cluster.Machine) []string {
+	result := make([]string, 0, len(dbservers))
+	for id := range dbservers {
+		result = append(result, id)
+	}
+	sort.Strings(result)
+	return result
+}
+
+// dbserverMachines returns the machines of the given dbservers.
+func dbserverMachines(dbservers map[string]cluster.Machine) MachineList {
+	var result MachineList
+	for _, id := range sortedServerIDs(dbservers) {
+		result = append(result, dbservers[id])
+	}
+	return result
+}
+
+// serverIDOf returns the server ID of the dbserver on the given machine.
+func serverIDOf(dbservers map[string]cluster.Machine, m cluster.Machine) string {
+	for id, x := range dbservers {
+		if x == m {
+			return id
+		}
+	}
+	return ""
+}
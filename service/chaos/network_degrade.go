@@ -0,0 +1,137 @@
+package chaos
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/arangodb-helper/testagent/service/cluster"
+)
+
+// slowAgentTraffic randomly picks an agent and adds latency & jitter to all its network traffic.
+func (c *chaosMonkey) slowAgentTraffic(ctx context.Context, action *chaosAction) bool {
+	return c.degradeTraffic(ctx, action, cluster.ServerRoleAgent, c.slowDegradation())
+}
+
+// slowDBServerTraffic randomly picks a dbserver and adds latency & jitter to all its network traffic.
+func (c *chaosMonkey) slowDBServerTraffic(ctx context.Context, action *chaosAction) bool {
+	return c.degradeTraffic(ctx, action, cluster.ServerRoleDBServer, c.slowDegradation())
+}
+
+// slowCoordinatorTraffic randomly picks a coordinator and adds latency & jitter to all its network traffic.
+func (c *chaosMonkey) slowCoordinatorTraffic(ctx context.Context, action *chaosAction) bool {
+	return c.degradeTraffic(ctx, action, cluster.ServerRoleCoordinator, c.slowDegradation())
+}
+
+// lossyAgentTraffic randomly picks an agent and loses a percentage of all its network packets.
+func (c *chaosMonkey) lossyAgentTraffic(ctx context.Context, action *chaosAction) bool {
+	return c.degradeTraffic(ctx, action, cluster.ServerRoleAgent, c.lossyDegradation())
+}
+
+// lossyDBServerTraffic randomly picks a dbserver and loses a percentage of all its network packets.
+func (c *chaosMonkey) lossyDBServerTraffic(ctx context.Context, action *chaosAction) bool {
+	return c.degradeTraffic(ctx, action, cluster.ServerRoleDBServer, c.lossyDegradation())
+}
+
+// lossyCoordinatorTraffic randomly picks a coordinator and loses a percentage of all its network packets.
+func (c *chaosMonkey) lossyCoordinatorTraffic(ctx context.Context, action *chaosAction) bool {
+	return c.degradeTraffic(ctx, action, cluster.ServerRoleCoordinator, c.lossyDegradation())
+}
+
+// slowDegradation returns the configured latency & jitter degradation.
+func (c *chaosMonkey) slowDegradation() cluster.NetworkDegradation {
+	return cluster.NetworkDegradation{
+		Latency: c.DegradeLatency,
+		Jitter:  c.DegradeJitter,
+	}
+}
+
+// lossyDegradation returns the configured packet loss degradation.
+func (c *chaosMonkey) lossyDegradation() cluster.NetworkDegradation {
+	return cluster.NetworkDegradation{
+		PacketLoss: c.DegradePacketLoss,
+	}
+}
+
+// degradeTraffic randomly picks a server with given role and degrades all its network traffic
+// for a while.
+func (c *chaosMonkey) degradeTraffic(ctx context.Context, action *chaosAction, role cluster.ServerRole, d cluster.NetworkDegradation) bool {
+	if c.DisableNetworkChaos {
+		return false
+	}
+	candidates, err := c.impairCandidates(role)
+	if err != nil {
+		c.log.Infof("%s, so I cannot degrade network traffic of a %s now", err.Error(), role)
+		action.skipped++
+		return false
+	}
+	if !c.networkFeatureAvailable(action, candidates, "degrading traffic", func(f cluster.NetworkFeatures) bool { return f.Degradation }) {
+		return false
+	}
+
+	// Pick a random machine
+	m := c.decisions.pickMachine(action, candidates)
+	f, ok := c.reserveFault(action, false, machineServer{m, role})
+	if !ok {
+		return false
+	}
+	timeout := c.networkTimeout(action)
+	event := c.startEvent(action, m, role, "Degrading network traffic of %s on %s with %s for %s", role, m.ID(), d, timeout)
+	if err := m.DegradeTraffic(role, d); err != nil {
+		c.log.Errorf("Failed to degrade network traffic of %s: %v", role, err)
+		action.failures++
+		c.finishEvent(event, err)
+		// Remove the shaping that did get applied
+		m.RestoreTraffic(role)
+		c.faults.remove(f)
+		return false
+	}
+	action.succeeded++
+	c.introducedEvent(event)
+
+	// The heal loop waits a while before restoring network traffic
+	c.faults.activate(f, event, fmt.Sprintf("Degrading network traffic of %s on %s with %s", role, m.ID(), d), timeout,
+		fmt.Sprintf("Restoring network traffic of %s on %s", role, m.ID()), func() error { return m.RestoreTraffic(role) })
+	return true
+}
+
+// impairCandidates returns the machines on which the server with given role can be impaired now.
+func (c *chaosMonkey) impairCandidates(role cluster.ServerRole) (MachineList, error) {
+	switch role {
+	case cluster.ServerRoleAgent:
+		agentMachines, _, err := c.checkAgencyReadyStatus()
+		if err != nil {
+			return nil, maskAny(fmt.Errorf("Not all agents are ready (%s)", err.Error()))
+		}
+		if len(agentMachines) < 3 {
+			return nil, maskAny(fmt.Errorf("There are too few (%d) agents in the cluster", len(agentMachines)))
+		}
+		return agentMachines, nil
+	case cluster.ServerRoleDBServer:
+		readyMachines, _, err := c.checkDBServerReadyStatus()
+		if err != nil {
+			return nil, maskAny(fmt.Errorf("Failed to check dbserver ready status (%s)", err.Error()))
+		}
+		if len(readyMachines) == 0 {
+			return nil, maskAny(fmt.Errorf("There are no ready dbservers in the cluster"))
+		}
+		return readyMachines, nil
+	case cluster.ServerRoleSingle:
+		readyMachines, _, err := c.checkSingleReadyStatus()
+		if err != nil {
+			return nil, maskAny(fmt.Errorf("Failed to check single server ready status (%s)", err.Error()))
+		}
+		if len(readyMachines) == 0 {
+			return nil, maskAny(fmt.Errorf("There are no ready single servers in the deployment"))
+		}
+		return readyMachines, nil
+	default:
+		readyMachines, _, err := c.checkCoordinatorReadyStatus()
+		if err != nil {
+			return nil, maskAny(fmt.Errorf("Failed to check coordinator ready status (%s)", err.Error()))
+		}
+		if len(readyMachines) == 0 {
+			return nil, maskAny(fmt.Errorf("There are no ready coordinators in the cluster"))
+		}
+		return readyMachines, nil
+	}
+}
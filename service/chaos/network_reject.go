@@ -2,8 +2,9 @@ package chaos
 
 import (
 	"context"
-	"math/rand"
-	"time"
+	"fmt"
+
+	"github.com/arangodb-helper/testagent/service/cluster"
 )
 
 // rejectAgentTraffic randomly picks an agent and actively rejects all network traffic to it it.
@@ -24,30 +25,25 @@ func (c *chaosMonkey) rejectAgentTraffic(ctx context.Context, action *chaosActio
 	}
 
 	// Pick a random agent machine
-	m := agentMachines[rand.Intn(len(agentMachines))]
-	timeout := createNetworkTimeout()
-	c.recordEvent(newEvent("Rejecting network traffic to agent on %s for %s", m.ID(), timeout))
+	m := c.decisions.pickMachine(action, agentMachines)
+	f, ok := c.reserveFault(action, false, machineServer{m, cluster.ServerRoleAgent})
+	if !ok {
+		return false
+	}
+	timeout := c.networkTimeout(action)
+	event := c.startEvent(action, m, cluster.ServerRoleAgent, "Rejecting network traffic to agent on %s for %s", m.ID(), timeout)
 	if err := m.RejectAgentTraffic(); err != nil {
 		c.log.Errorf("Failed to reject network traffic to agent: %v", err)
 		action.failures++
-		c.recordEvent(newEvent("Rejecting network traffic to agent on %s failed: %v", m.ID(), err))
+		c.finishEvent(event, err)
+		c.faults.remove(f)
 		return false
 	}
-
-	// Wait a while before restoring network traffic
-	select {
-	case <-ctx.Done():
-	case <-time.After(timeout):
-	}
-
-	// Cleanup
 	action.succeeded++
-	if err := m.AcceptAgentTraffic(); err != nil {
-		c.recordEvent(newEvent("Restoring network traffic to agent on %s failed: %v", m.ID(), err))
-	} else {
-		c.recordEvent(newEvent("Restoring network traffic to agent on %s succeeded", m.ID()))
-	}
+	c.introducedEvent(event)
 
+	// The heal loop waits a while before restoring network traffic
+	c.faults.activate(f, event, fmt.Sprintf("Rejecting network traffic to agent on %s", m.ID()), timeout, fmt.Sprintf("Restoring network traffic to agent on %s", m.ID()), m.AcceptAgentTraffic)
 	return true
 }
 
@@ -56,17 +52,12 @@ func (c *chaosMonkey) rejectDBServerTraffic(ctx context.Context, action *chaosAc
 	if c.DisableNetworkChaos {
 		return false
 	}
-	readyMachines, notReadyServers, err := c.checkDBServerReadyStatus()
+	readyMachines, _, err := c.checkDBServerReadyStatus()
 	if err != nil {
 		c.log.Infof("Failed to check dbserver ready status (%s), so I cannot reject network traffic to one now", err.Error())
 		action.skipped++
 		return false
 	}
-	if notReadyServers > 0 {
-		c.log.Infof("At least 1 dbserver is already down (%d down), so I cannot reject network traffic to one now", notReadyServers)
-		action.skipped++
-		return false
-	}
 	if len(readyMachines) == 0 {
 		c.log.Infof("There are no ready dbservers in the cluster, so I cannot reject network traffic to one now")
 		action.skipped++
@@ -74,30 +65,25 @@ func (c *chaosMonkey) rejectDBServerTraffic(ctx context.Context, action *chaosAc
 	}
 
 	// Pick a random dbserver machine
-	m := readyMachines[rand.Intn(len(readyMachines))]
-	timeout := createNetworkTimeout()
-	c.recordEvent(newEvent("Rejecting network traffic to dbserver on %s for %s", m.ID(), timeout))
+	m := c.decisions.pickMachine(action, readyMachines)
+	f, ok := c.reserveFault(action, false, machineServer{m, cluster.ServerRoleDBServer})
+	if !ok {
+		return false
+	}
+	timeout := c.networkTimeout(action)
+	event := c.startEvent(action, m, cluster.ServerRoleDBServer, "Rejecting network traffic to dbserver on %s for %s", m.ID(), timeout)
 	if err := m.RejectDBServerTraffic(); err != nil {
 		c.log.Errorf("Failed to reject network traffic to dbserver: %v", err)
 		action.failures++
-		c.recordEvent(newEvent("Rejecting network traffic to dbserver on %s failed: %v", m.ID(), err))
+		c.finishEvent(event, err)
+		c.faults.remove(f)
 		return false
 	}
-
-	// Wait a while before restoring network traffic
-	select {
-	case <-ctx.Done():
-	case <-time.After(createNetworkTimeout()):
-	}
-
-	// Cleanup
 	action.succeeded++
-	if err := m.AcceptDBServerTraffic(); err != nil {
-		c.recordEvent(newEvent("Restoring network traffic to dbserver on %s failed: %v", m.ID(), err))
-	} else {
-		c.recordEvent(newEvent("Restoring network traffic to dbserver on %s succeeded", m.ID()))
-	}
+	c.introducedEvent(event)
 
+	// The heal loop waits a while before restoring network traffic
+	c.faults.activate(f, event, fmt.Sprintf("Rejecting network traffic to dbserver on %s", m.ID()), timeout, fmt.Sprintf("Restoring network traffic to dbserver on %s", m.ID()), m.AcceptDBServerTraffic)
 	return true
 }
 
@@ -119,34 +105,24 @@ func (c *chaosMonkey) rejectCoordinatorTraffic(ctx context.Context, action *chao
 	}
 
 	// Pick a random coordinator machine
-	m := readyMachines[rand.Intn(len(readyMachines))]
-	timeout := createNetworkTimeout()
-	c.recordEvent(newEvent("Rejecting network traffic to coordinator on %s for %s", m.ID(), timeout))
+	m := c.decisions.pickMachine(action, readyMachines)
+	f, ok := c.reserveFault(action, false, machineServer{m, cluster.ServerRoleCoordinator})
+	if !ok {
+		return false
+	}
+	timeout := c.networkTimeout(action)
+	event := c.startEvent(action, m, cluster.ServerRoleCoordinator, "Rejecting network traffic to coordinator on %s for %s", m.ID(), timeout)
 	if err := m.RejectCoordinatorTraffic(); err != nil {
 		c.log.Errorf("Failed to reject network traffic to coordinator: %v", err)
 		action.failures++
-		c.recordEvent(newEvent("Rejecting network traffic to coordinator on %s failed: %v", m.ID(), err))
+		c.finishEvent(event, err)
+		c.faults.remove(f)
 		return false
 	}
-
-	// Wait a while before restoring network traffic
-	select {
-	case <-ctx.Done():
-	case <-time.After(timeout):
-	}
-
-	// Cleanup
 	action.succeeded++
-	if err := m.AcceptCoordinatorTraffic(); err != nil {
-		c.recordEvent(newEvent("Restoring network traffic to coordinator on %s failed: %v", m.ID(), err))
-	} else {
-		c.recordEvent(newEvent("Restoring network traffic to coordinator on %s succeeded", m.ID()))
-	}
+	c.introducedEvent(event)
 
+	// The heal loop waits a while before restoring network traffic
+	c.faults.activate(f, event, fmt.Sprintf("Rejecting network traffic to coordinator on %s", m.ID()), timeout, fmt.Sprintf("Restoring network traffic to coordinator on %s", m.ID()), m.AcceptCoordinatorTraffic)
 	return true
 }
-
-func createNetworkTimeout() time.Duration {
-	x := rand.Intn(60) + 5
-	return time.Duration(x) * time.Second
-}
@@ -0,0 +1,132 @@
+package chaos
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ChaosPacing determines how fast chaos is introduced.
+type ChaosPacing struct {
+	MinPause        time.Duration // Minimum pause after chaos was introduced
+	MaxPause        time.Duration // Maximum pause after chaos was introduced
+	SkipPause       time.Duration // Pause after an action could not introduce chaos
+	MinNetworkFault time.Duration // Minimum duration of a network fault
+	MaxNetworkFault time.Duration // Maximum duration of a network fault
+	MinFault        time.Duration // Minimum duration of other temporary faults (freeze, resource limits, disk fill, clock skew)
+	MaxFault        time.Duration // Maximum duration of other temporary faults (freeze, resource limits, disk fill, clock skew)
+}
+
+// DefaultChaosPacing returns the pacing used when nothing is configured.
+func DefaultChaosPacing() ChaosPacing {
+	return ChaosPacing{
+		MinPause:        time.Second * 30,
+		MaxPause:        time.Second * 30,
+		SkipPause:       time.Second * 2,
+		MinNetworkFault: time.Second * 5,
+		MaxNetworkFault: time.Second * 64,
+		MinFault:        time.Second * 5,
+		MaxFault:        time.Second * 64,
+	}
+}
+
+// Validate checks the pacing for consistency.
+func (p ChaosPacing) Validate() error {
+	if p.MinPause < 0 || p.SkipPause < 0 || p.MinNetworkFault < 0 || p.MinFault < 0 {
+		return maskAny(fmt.Errorf("Pauses and durations cannot be negative"))
+	}
+	if p.MaxPause < p.MinPause {
+		return maskAny(fmt.Errorf("Maximum pause (%s) is less than minimum pause (%s)", p.MaxPause, p.MinPause))
+	}
+	if p.MaxNetworkFault < p.MinNetworkFault {
+		return maskAny(fmt.Errorf("Maximum network fault duration (%s) is less than minimum (%s)", p.MaxNetworkFault, p.MinNetworkFault))
+	}
+	if p.MaxFault < p.MinFault {
+		return maskAny(fmt.Errorf("Maximum fault duration (%s) is less than minimum (%s)", p.MaxFault, p.MinFault))
+	}
+	return nil
+}
+
+// ParseActionWeights parses a list of `<action>=<weight>` values.
+// Action names are matched case insensitive, dashes may be used instead of spaces
+// (e.g. `restart-coordinator=10`).
+func ParseActionWeights(values []string) (map[string]int, error) {
+	result := make(map[string]int)
+	for _, v := range values {
+		idx := strings.LastIndex(v, "=")
+		if idx <= 0 {
+			return nil, maskAny(fmt.Errorf("Invalid action weight '%s', expected <action>=<weight>", v))
+		}
+		weight, err := strconv.Atoi(strings.TrimSpace(v[idx+1:]))
+		if err != nil || weight < 0 {
+			return nil, maskAny(fmt.Errorf("Invalid weight in '%s', expected a non-negative number", v))
+		}
+		result[normalizeActionName(v[:idx])] = weight
+	}
+	return result, nil
+}
+
+// normalizeActionName returns the given action name in a form used to match names.
+func normalizeActionName(name string) string {
+	return strings.ToLower(strings.Replace(strings.TrimSpace(name), "-", " ", -1))
+}
+
+// Pacing returns the current pacing of chaos.
+func (c *chaosMonkey) Pacing() ChaosPacing {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	return c.pacing
+}
+
+// SetPacing changes the pacing of chaos.
+func (c *chaosMonkey) SetPacing(p ChaosPacing) error {
+	if err := p.Validate(); err != nil {
+		return maskAny(err)
+	}
+	c.log.Debugf("Setting chaos pacing: %+v", p)
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.pacing = p
+	return nil
+}
+
+// applyActionWeights sets the weights of all actions found in the given map.
+func (c *chaosMonkey) applyActionWeights(weights map[string]int) error {
+	for name, weight := range weights {
+		found := false
+		for _, a := range c.actions {
+			if normalizeActionName(a.name) == normalizeActionName(name) {
+				if err := a.SetWeight(weight); err != nil {
+					return maskAny(err)
+				}
+				found = true
+			}
+		}
+		if !found {
+			return maskAny(fmt.Errorf("Unknown chaos action '%s'", name))
+		}
+	}
+	return nil
+}
+
+// pause returns how long to wait after chaos was introduced.
+func (c *chaosMonkey) pause() time.Duration {
+	p := c.Pacing()
+	return c.decisions.pause(p.MinPause, p.MaxPause)
+}
+
+// networkTimeout picks the duration of a network fault introduced by the given action.
+func (c *chaosMonkey) networkTimeout(action *chaosAction) time.Duration {
+	p := c.Pacing()
+	return c.decisions.duration(action, p.MinNetworkFault, p.MaxNetworkFault)
+}
+
+// faultTimeout picks the duration of a temporary fault (other than a network fault) introduced by the given action.
+func (c *chaosMonkey) faultTimeout(action *chaosAction) time.Duration {
+	p := c.Pacing()
+	return c.decisions.duration(action, p.MinFault, p.MaxFault)
+}
@@ -2,7 +2,7 @@ package chaos
 
 import (
 	"context"
-	"math/rand"
+	"fmt"
 )
 
 // rebootMachine randomly picks a machine and restarts it gracefully.
@@ -38,15 +38,21 @@ func (c *chaosMonkey) rebootMachine(ctx context.Context, action *chaosAction) bo
 	}
 
 	// Pick a random machine
-	m := rebootCandidates[rand.Intn(len(rebootCandidates))]
-	c.recordEvent(newEvent("Rebooting machine %s...", m.ID()))
+	m := c.decisions.pickMachine(action, rebootCandidates)
+	f, ok := c.reserveFault(action, false, machineServers(m)...)
+	if !ok {
+		return false
+	}
+	c.faults.hold(f, fmt.Sprintf("Reboot machine %s", m.ID()))
+	event := c.startEvent(action, m, "", "Rebooting machine %s", m.ID())
+	defer c.releaseFault(f, event, f.servers)
 	if err := m.Reboot(); err != nil {
 		c.log.Errorf("Failed to reboot machine: %v", err)
 		action.failures++
-		c.recordEvent(newEvent("Reboot machine %s failed: %v", m.ID(), err))
+		c.finishEvent(event, err)
 	} else {
 		action.succeeded++
-		c.recordEvent(newEvent("Reboot machine %s succeeded", m.ID()))
+		c.finishEvent(event, nil)
 	}
 	return true
 }
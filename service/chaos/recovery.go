@@ -0,0 +1,167 @@
+package chaos
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/arangodb-helper/testagent/service/test"
+)
+
+const (
+	// recoveryPollInterval is the time between checks while measuring the recovery from a fault.
+	recoveryPollInterval = time.Second * 2
+	// recoveryTimeout is the maximum time the recovery from a fault is measured.
+	recoveryTimeout = time.Minute * 10
+)
+
+// RecoveryStats summarizes how long the cluster needed to recover from the faults of an action.
+// All durations are measured from the moment a fault was healed (or introduced, for faults
+// that disappear by themselves such as a killed server).
+type RecoveryStats struct {
+	Measured      int           // Number of faults the cluster recovered from
+	Unrecovered   int           // Number of faults the cluster did not recover from within the measure timeout
+	TotalReady    time.Duration // Sum of the times until the affected servers were ready
+	MaxReady      time.Duration // Longest time until the affected servers were ready
+	TotalInSync   time.Duration // Sum of the times until all shards were in sync
+	MaxInSync     time.Duration // Longest time until all shards were in sync
+	SLAViolations int           // Number of recoveries that exceeded the recovery SLA
+	Overlapped    int           // Number of recoveries that were not measured because other faults were active
+}
+
+// AvgReady returns the average time until the affected servers were ready.
+func (s RecoveryStats) AvgReady() time.Duration {
+	if s.Measured == 0 {
+		return 0
+	}
+	return (s.TotalReady / time.Duration(s.Measured)).Round(time.Second)
+}
+
+// AvgInSync returns the average time until all shards were in sync.
+func (s RecoveryStats) AvgInSync() time.Duration {
+	if s.Measured == 0 {
+		return 0
+	}
+	return (s.TotalInSync / time.Duration(s.Measured)).Round(time.Second)
+}
+
+func (s RecoveryStats) String() string {
+	if s.Measured == 0 && s.Unrecovered == 0 && s.Overlapped == 0 {
+		return "-"
+	}
+	return fmt.Sprintf("ready avg %s max %s, in sync avg %s max %s, %d unrecovered, %d SLA violations, %d overlapped",
+		s.AvgReady(), s.MaxReady, s.AvgInSync(), s.MaxInSync, s.Unrecovered, s.SLAViolations, s.Overlapped)
+}
+
+// measureRecovery waits until the servers impaired by the given fault are ready again and
+// all shards are in sync, starting at the given time.
+// The results are recorded in the event of the fault and in the statistics of its action.
+// If the recovery takes longer than the recovery SLA, a failure is reported.
+// Shards are checked cluster wide, so while other faults are active the measurement
+// is only recorded as overlapped; it neither counts in the statistics nor against the SLA.
+func (c *chaosMonkey) measureRecovery(x *fault, since time.Time) {
+	ctx, cancel := context.WithTimeout(context.Background(), recoveryTimeout)
+	defer cancel()
+
+	var ready, inSync time.Duration
+	var err error
+	overlapped := false
+	for {
+		overlapped = overlapped || len(c.faults.introduced()) > 0
+		if c.serversReady(x.servers) {
+			ready = time.Since(since)
+			break
+		}
+		if !c.sleep(ctx, recoveryPollInterval) {
+			err = fmt.Errorf("Servers not ready after %s", recoveryTimeout)
+			break
+		}
+	}
+	if err == nil {
+		for {
+			overlapped = overlapped || len(c.faults.introduced()) > 0
+			outOfSync, syncErr := c.shardsOutOfSync(ctx)
+			if syncErr == nil && outOfSync == 0 {
+				inSync = time.Since(since)
+				break
+			}
+			if !c.sleep(ctx, recoveryPollInterval) {
+				if syncErr != nil {
+					err = fmt.Errorf("Shards not in sync after %s: %v", recoveryTimeout, syncErr)
+				} else {
+					err = fmt.Errorf("%d shards not in sync after %s", outOfSync, recoveryTimeout)
+				}
+				break
+			}
+		}
+	}
+	ready, inSync = ready.Round(time.Second), inSync.Round(time.Second)
+
+	if overlapped {
+		c.updateEvent(x.event, func(e *Event) {
+			e.ReadyAfter = ready
+			e.InSyncAfter = inSync
+			e.RecoveryOverlapped = true
+		})
+		if x.source != nil {
+			x.source.addOverlappedRecovery()
+		}
+		c.log.Infof("Recovery from '%s' overlapped with other faults, so it is not measured", x.description)
+		return
+	}
+
+	violated := c.RecoverySLA > 0 && (err != nil || inSync > c.RecoverySLA)
+	c.updateEvent(x.event, func(e *Event) {
+		e.ReadyAfter = ready
+		e.InSyncAfter = inSync
+		if err != nil {
+			e.RecoveryError = err.Error()
+		}
+	})
+	if x.source != nil {
+		x.source.addRecovery(ready, inSync, err == nil, violated)
+	}
+	if err != nil {
+		c.log.Errorf("Recovery from '%s' failed: %v", x.description, err)
+	} else {
+		c.log.Infof("Recovered from '%s': servers ready after %s, shards in sync after %s", x.description, ready, inSync)
+	}
+	if violated && c.listener != nil {
+		if err != nil {
+			c.listener.ReportFailure(test.NewFailure("Chaos", "Recovery from '%s' exceeded the recovery SLA of %s: %v", x.description, c.RecoverySLA, err))
+		} else {
+			c.listener.ReportFailure(test.NewFailure("Chaos", "Recovery from '%s' took %s (servers ready after %s), exceeding the recovery SLA of %s", x.description, inSync, ready, c.RecoverySLA))
+		}
+	}
+}
+
+// addOverlappedRecovery counts a recovery of the action that overlapped with other faults.
+func (a *chaosAction) addOverlappedRecovery() {
+	a.recoveryMutex.Lock()
+	defer a.recoveryMutex.Unlock()
+
+	a.recovery.Overlapped++
+}
+
+// addRecovery adds a measured recovery to the statistics of the action.
+func (a *chaosAction) addRecovery(ready, inSync time.Duration, recovered, slaViolated bool) {
+	a.recoveryMutex.Lock()
+	defer a.recoveryMutex.Unlock()
+
+	if recovered {
+		a.recovery.Measured++
+		a.recovery.TotalReady += ready
+		a.recovery.TotalInSync += inSync
+		if ready > a.recovery.MaxReady {
+			a.recovery.MaxReady = ready
+		}
+		if inSync > a.recovery.MaxInSync {
+			a.recovery.MaxInSync = inSync
+		}
+	} else {
+		a.recovery.Unrecovered++
+	}
+	if slaViolated {
+		a.recovery.SLAViolations++
+	}
+}
@@ -0,0 +1,28 @@
+package chaos
+
+import (
+	"testing"
+	"time"
+)
+
+func TestActionRecovery(t *testing.T) {
+	a := newChaosAction("test", 1, nil)
+	if s := a.Recovery(); s.String() != "-" {
+		t.Errorf("Expected no recoveries, got %s", s)
+	}
+	a.addRecovery(time.Second*10, time.Second*30, true, false)
+	a.addRecovery(time.Second*20, time.Second*90, true, true)
+	a.addRecovery(0, 0, false, true)
+	a.addOverlappedRecovery()
+
+	s := a.Recovery()
+	if s.Measured != 2 || s.Unrecovered != 1 || s.SLAViolations != 2 || s.Overlapped != 1 {
+		t.Errorf("Unexpected counts: %+v", s)
+	}
+	if s.AvgReady() != time.Second*15 || s.MaxReady != time.Second*20 {
+		t.Errorf("Unexpected ready times: avg %s, max %s", s.AvgReady(), s.MaxReady)
+	}
+	if s.AvgInSync() != time.Minute || s.MaxInSync != time.Second*90 {
+		t.Errorf("Unexpected in sync times: avg %s, max %s", s.AvgInSync(), s.MaxInSync)
+	}
+}
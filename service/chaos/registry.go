@@ -0,0 +1,95 @@
+package chaos
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/arangodb-helper/testagent/service/cluster"
+	"github.com/pkg/errors"
+)
+
+var (
+	// ErrSkipped can be returned (wrapped or not) by the run function of a registered chaos action
+	// when it cannot introduce chaos in the current state of the cluster.
+	ErrSkipped = errors.New("chaos action skipped")
+)
+
+// ChaosActionFactory describes a chaos action that is added to all chaos monkeys
+// created after it has been registered using RegisterChaosAction.
+type ChaosActionFactory struct {
+	Name         string // Unique name of the action
+	MinimumLevel int    // Minimum chaos level at which the action is enabled
+	Weight       int    // Relative chance of the action being picked (default 1)
+	// Run introduces the chaos.
+	// It returns nil on success, ErrSkipped when no chaos could be introduced now, or any other error on failure.
+	Run func(ctx context.Context, c cluster.Cluster) error
+}
+
+var (
+	registryMutex sync.Mutex
+	registry      []ChaosActionFactory
+)
+
+// RegisterChaosAction registers a chaos action that is added to all chaos monkeys created afterwards.
+func RegisterChaosAction(f ChaosActionFactory) error {
+	if f.Name == "" {
+		return maskAny(fmt.Errorf("Chaos action name missing"))
+	}
+	if f.Run == nil {
+		return maskAny(fmt.Errorf("Run function of chaos action '%s' missing", f.Name))
+	}
+	if f.MinimumLevel < chaosLevelMin || f.MinimumLevel > chaosLevelMax {
+		return maskAny(fmt.Errorf("Minimum level of chaos action '%s' is out of range [%d, %d]", f.Name, chaosLevelMin, chaosLevelMax))
+	}
+	if f.Weight < 0 {
+		return maskAny(fmt.Errorf("Weight of chaos action '%s' cannot be negative", f.Name))
+	}
+	if f.Weight == 0 {
+		f.Weight = 1
+	}
+
+	registryMutex.Lock()
+	defer registryMutex.Unlock()
+
+	for _, x := range registry {
+		if x.Name == f.Name {
+			return maskAny(fmt.Errorf("Chaos action '%s' is already registered", f.Name))
+		}
+	}
+	registry = append(registry, f)
+	return nil
+}
+
+// registeredActions returns all registered chaos actions.
+func registeredActions() []ChaosActionFactory {
+	registryMutex.Lock()
+	defer registryMutex.Unlock()
+
+	return append([]ChaosActionFactory{}, registry...)
+}
+
+// newRegisteredAction creates an action for the given registered chaos action.
+func (c *chaosMonkey) newRegisteredAction(f ChaosActionFactory) *chaosAction {
+	a := newChaosAction(f.Name, f.MinimumLevel, func(ctx context.Context, action *chaosAction) bool {
+		event := c.startEvent(action, nil, "", "Running %s", f.Name)
+		err := f.Run(ctx, c.cluster)
+		switch {
+		case errors.Is(err, ErrSkipped):
+			c.log.Infof("%s skipped: %v", f.Name, err)
+			action.skipped++
+			c.skipEvent(event, err)
+			return false
+		case err != nil:
+			c.log.Errorf("%s failed: %v", f.Name, err)
+			action.failures++
+			c.finishEvent(event, err)
+		default:
+			action.succeeded++
+			c.finishEvent(event, nil)
+		}
+		return true
+	})
+	a.weight = f.Weight
+	return a
+}
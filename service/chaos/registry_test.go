@@ -0,0 +1,65 @@
+package chaos
+
+import (
+	"context"
+	"testing"
+
+	"github.com/arangodb-helper/testagent/service/cluster"
+)
+
+// restoreRegistry restores the registry of chaos actions when the test is done,
+// such that actions registered by the test do not leak into other tests.
+func restoreRegistry(t *testing.T) {
+	registryMutex.Lock()
+	saved := append([]ChaosActionFactory{}, registry...)
+	registryMutex.Unlock()
+	t.Cleanup(func() {
+		registryMutex.Lock()
+		defer registryMutex.Unlock()
+		registry = saved
+	})
+}
+
+func TestRegisterChaosAction(t *testing.T) {
+	restoreRegistry(t)
+	run := func(ctx context.Context, c cluster.Cluster) error { return nil }
+	if err := RegisterChaosAction(ChaosActionFactory{Name: "Site Specific", MinimumLevel: 3, Run: run}); err != nil {
+		t.Fatalf("Failed to register action: %v", err)
+	}
+	if err := RegisterChaosAction(ChaosActionFactory{Name: "Site Specific", MinimumLevel: 3, Run: run}); err == nil {
+		t.Errorf("Expected duplicate registration to fail")
+	}
+	if err := RegisterChaosAction(ChaosActionFactory{Name: "Without Run"}); err == nil {
+		t.Errorf("Expected registration without run function to fail")
+	}
+	if err := RegisterChaosAction(ChaosActionFactory{Name: "Too Chaotic", MinimumLevel: chaosLevelMax + 1, Run: run}); err == nil {
+		t.Errorf("Expected registration with invalid level to fail")
+	}
+
+	c, err := cluster.NewFakeCluster(3, 3, 3).Create(3, false)
+	if err != nil {
+		t.Fatalf("Failed to create fake cluster: %v", err)
+	}
+	cm, err := NewChaosMonkey(log, c, nil, ChaosMonkeyConfig{ChaosLevel: 2})
+	if err != nil {
+		t.Fatalf("Failed to create chaos monkey: %v", err)
+	}
+	var found Action
+	for _, a := range cm.Actions() {
+		if a.Name() == "Site Specific" {
+			found = a
+		}
+	}
+	if found == nil {
+		t.Fatalf("Registered action not found")
+	}
+	if found.Enabled() || found.Weight() != 1 {
+		t.Errorf("Expected registered action to be disabled at level 2 with weight 1")
+	}
+	if err := cm.SetChaosLevel(3); err != nil {
+		t.Fatalf("Failed to set chaos level: %v", err)
+	}
+	if !found.Enabled() {
+		t.Errorf("Expected registered action to be enabled at level 3")
+	}
+}
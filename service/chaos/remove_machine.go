@@ -2,7 +2,9 @@ package chaos
 
 import (
 	"context"
-	"math/rand"
+	"fmt"
+
+	"github.com/arangodb-helper/testagent/service/cluster"
 )
 
 // removeMachine randomly picks a machine and removes it gracefully.
@@ -31,15 +33,41 @@ func (c *chaosMonkey) removeMachine(ctx context.Context, action *chaosAction) bo
 	}
 
 	// Pick a random machine
-	m := removeCandidates[rand.Intn(len(removeCandidates))]
-	c.recordEvent(newEvent("Removing machine %s...", m.ID()))
+	m := c.decisions.pickMachine(action, removeCandidates)
+	f, ok := c.reserveFault(action, false, machineServers(m)...)
+	if !ok {
+		return false
+	}
+	c.faults.hold(f, fmt.Sprintf("Remove machine %s", m.ID()))
+	event := c.startEvent(action, m, "", "Removing machine %s", m.ID())
+	// The servers of the machine are gone afterwards, so recovery only waits for the shards
+	defer c.releaseFault(f, event, nil)
+	if c.CleanOutBeforeRemove {
+		// Move all shards away from the dbserver first
+		c.log.Infof("Cleaning out dbserver on %s before removing it", m.ID())
+		if err := c.cleanOutMachine(ctx, m, readyCoordinatorMachines[0]); err != nil {
+			c.log.Errorf("Failed to clean out dbserver before removing machine: %v", err)
+			action.failures++
+			c.finishEvent(event, fmt.Errorf("Clean out dbserver failed: %v", err))
+			return true
+		}
+	}
 	if err := m.Destroy(); err != nil {
 		c.log.Errorf("Failed to remove machine: %v", err)
 		action.failures++
-		c.recordEvent(newEvent("Remove machine %s failed: %v", m.ID(), err))
+		c.finishEvent(event, err)
 	} else {
 		action.succeeded++
-		c.recordEvent(newEvent("Remove machine %s succeeded", m.ID()))
+		c.finishEvent(event, nil)
 	}
 	return true
 }
+
+// cleanOutMachine moves all shards away from the dbserver on the given machine, using the given coordinator.
+func (c *chaosMonkey) cleanOutMachine(ctx context.Context, m, coordinator cluster.Machine) error {
+	serverID, err := c.dbserverID(ctx, m)
+	if err != nil {
+		return maskAny(err)
+	}
+	return maskAny(c.cleanOutDBServer(ctx, coordinator, serverID))
+}
@@ -0,0 +1,99 @@
+package chaos
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+const (
+	agencyRecoveryTimeout = time.Minute * 5
+)
+
+// replaceAgentMachine randomly picks a machine with an agent, destroys it permanently and
+// brings up a replacement that takes over its agent.
+// Afterwards, it verifies that the agency regains its full size and a leader.
+func (c *chaosMonkey) replaceAgentMachine(ctx context.Context, action *chaosAction) bool {
+	agentMachines, _, err := c.checkAgencyReadyStatus()
+	if err != nil {
+		c.log.Infof("Not all agents are ready (%s), so I cannot replace an agent machine now", err.Error())
+		action.skipped++
+		return false
+	}
+	if len(agentMachines) < 3 {
+		c.log.Infof("There are too few (%d) agents in the cluster, so I cannot replace an agent machine now", len(agentMachines))
+		action.skipped++
+		return false
+	}
+	readyDBServerMachines, notReadyDBServers, err := c.checkDBServerReadyStatus()
+	if err != nil || notReadyDBServers > 0 {
+		c.log.Infof("At least 1 dbserver is already down (%d down), so I cannot replace an agent machine now", notReadyDBServers)
+		action.skipped++
+		return false
+	}
+	readyCoordinatorMachines, _, _ := c.checkCoordinatorReadyStatus()
+	if len(readyCoordinatorMachines) <= 1 {
+		c.log.Infof("Only %d coordinator is ready, so I cannot replace an agent machine now", len(readyCoordinatorMachines))
+		action.skipped++
+		return false
+	}
+	var candidates MachineList
+	for _, m := range agentMachines.Intersection(readyDBServerMachines) {
+		if m.ReplaceAllowed() {
+			candidates = append(candidates, m)
+		}
+	}
+	if len(candidates) == 0 {
+		c.log.Infof("There are 0 agent machines that can be replaced")
+		action.skipped++
+		return false
+	}
+
+	// Pick a random agent machine
+	m := c.decisions.pickMachine(action, candidates)
+	f, ok := c.reserveFault(action, false, machineServers(m)...)
+	if !ok {
+		return false
+	}
+	c.faults.hold(f, fmt.Sprintf("Replace agent machine %s", m.ID()))
+	agencySize := len(agentMachines)
+	event := c.startEvent(action, m, "", "Replacing agent machine %s", m.ID())
+	// The servers of the machine are gone afterwards, so recovery waits for those of the replacement
+	var replacementServers []machineServer
+	defer func() { c.releaseFault(f, event, replacementServers) }()
+	replacement, err := c.cluster.Replace(m)
+	if err != nil {
+		c.log.Errorf("Failed to replace agent machine: %v", err)
+		action.failures++
+		c.finishEvent(event, err)
+		return true
+	}
+	replacementServers = machineServers(replacement)
+	c.updateEvent(event, func(e *Event) {
+		e.Description = fmt.Sprintf("Replacing agent machine %s by %s", m.ID(), replacement.ID())
+	})
+
+	// Wait for the agency to regain its full size and a leader
+	deadline := time.Now().Add(agencyRecoveryTimeout)
+	for {
+		agentMachines, _, err := c.checkAgencyReadyStatus()
+		if err == nil {
+			err = c.checkAgencyHealth(ctx, agentMachines, agencySize)
+		}
+		if err == nil {
+			break
+		}
+		if time.Now().After(deadline) {
+			action.failures++
+			c.finishEvent(event, fmt.Errorf("Agency did not recover: %v", err))
+			return true
+		}
+		if !c.sleep(ctx, time.Second*5) {
+			c.finishEvent(event, ctx.Err())
+			return true
+		}
+	}
+	action.succeeded++
+	c.finishEvent(event, nil)
+	return true
+}
@@ -0,0 +1,117 @@
+package chaos
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+
+	"github.com/arangodb-helper/testagent/service/cluster"
+	"github.com/arangodb-helper/testagent/service/test"
+)
+
+var (
+	// requestedOperationNames maps the kinds of faults test scripts can request to server operations.
+	requestedOperationNames = map[test.FaultKind]string{
+		test.FaultRestart:     "restart",
+		test.FaultKill:        "kill",
+		test.FaultFreeze:      "freeze",
+		test.FaultDropTraffic: "partition",
+	}
+)
+
+// InjectFault injects a fault requested by a test script and blocks until it has been applied.
+// Requested faults are counted by the "Requested Fault" action (which can be disabled), obey the
+// fault budget and are healed by the heal loop, just like the faults of other actions.
+func (c *chaosMonkey) InjectFault(ctx context.Context, req test.FaultRequest) error {
+	c.mutex.Lock()
+	active := c.active && !c.cancelled
+	c.mutex.Unlock()
+	if !active || c.scenario != nil {
+		return maskAny(fmt.Errorf("%w: chaos is not active", ErrSkipped))
+	}
+	action := c.requested
+	if !action.Enabled() {
+		return maskAny(fmt.Errorf("%w: requested faults are disabled", ErrSkipped))
+	}
+	if !c.cluster.Managed() {
+		action.skipped++
+		return maskAny(fmt.Errorf("%w: the servers of the cluster are not managed by the testagent", ErrSkipped))
+	}
+	var ops map[string]serverOperation
+	var serverURL func(cluster.Machine) url.URL
+	switch req.Role {
+	case cluster.ServerRoleAgent:
+		ops, serverURL = agentOperations, cluster.Machine.AgentURL
+	case cluster.ServerRoleDBServer:
+		ops, serverURL = dbserverOperations, cluster.Machine.DBServerURL
+	case cluster.ServerRoleCoordinator:
+		ops, serverURL = coordinatorOperations, cluster.Machine.CoordinatorURL
+	case cluster.ServerRoleSingle:
+		ops, serverURL = singleOperations, cluster.Machine.SingleURL
+	default:
+		return maskAny(fmt.Errorf("Unknown server role '%s'", req.Role))
+	}
+	op, found := ops[requestedOperationNames[req.Kind]]
+	if !found {
+		return maskAny(fmt.Errorf("Unknown fault kind '%s'", req.Kind))
+	}
+	if op.network && c.DisableNetworkChaos {
+		action.skipped++
+		return maskAny(fmt.Errorf("%w: network chaos is disabled", ErrSkipped))
+	}
+	m, err := c.machineByEndpoint(req.Endpoint, serverURL)
+	if err != nil {
+		action.skipped++
+		return maskAny(fmt.Errorf("%w: %v", ErrSkipped, err))
+	}
+	f, err := c.reserveFaultFor(action, false, machineServer{m, req.Role})
+	if err != nil {
+		c.log.Infof("%s, so I cannot inject the fault requested by %s now", err.Error(), req.Test)
+		action.skipped++
+		return maskAny(fmt.Errorf("%w: %v", ErrSkipped, err))
+	}
+	timeout := c.operationTimeout(action, op)
+	description := fmt.Sprintf("%s %s on %s (requested by %s: %s)", op.verb, req.Role, m.ID(), req.Test, req.Reason)
+	event := c.startEvent(action, m, req.Role, "%s", description)
+	if err := op.apply(m); err != nil {
+		c.log.Errorf("%s %s requested by %s failed: %v", op.verb, req.Role, req.Test, err)
+		action.failures++
+		c.finishEvent(event, err)
+		if op.heal != nil {
+			// Remove what did get applied
+			op.heal(m)
+		}
+		c.faults.remove(f)
+		return maskAny(err)
+	}
+	action.succeeded++
+	c.introducedEvent(event)
+
+	if op.heal == nil {
+		// The fault is active until the server is ready again
+		c.faults.activate(f, event, description, 0, "", nil)
+	} else {
+		// The heal loop waits a while before removing the chaos
+		c.faults.activate(f, event, description, timeout, op.healing(req.Role, m), func() error { return op.heal(m) })
+	}
+	return nil
+}
+
+// machineByEndpoint returns the machine for which the given function returns a URL with the same host
+// as the given endpoint.
+func (c *chaosMonkey) machineByEndpoint(endpoint string, serverURL func(cluster.Machine) url.URL) (cluster.Machine, error) {
+	ep, err := url.Parse(endpoint)
+	if err != nil {
+		return nil, maskAny(err)
+	}
+	machines, err := c.cluster.Machines()
+	if err != nil {
+		return nil, maskAny(err)
+	}
+	for _, m := range machines {
+		if u := serverURL(m); u.Host == ep.Host {
+			return m, nil
+		}
+	}
+	return nil, maskAny(fmt.Errorf("No machine found for endpoint '%s'", endpoint))
+}
@@ -0,0 +1,59 @@
+package chaos
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/arangodb-helper/testagent/service/cluster"
+	"github.com/arangodb-helper/testagent/service/test"
+)
+
+func TestInjectFault(t *testing.T) {
+	c, err := cluster.NewFakeCluster(3, 3, 3).Create(3, false)
+	if err != nil {
+		t.Fatalf("Failed to create fake cluster: %v", err)
+	}
+	cm, err := NewChaosMonkey(log, c, nil, ChaosMonkeyConfig{ChaosLevel: 2})
+	if err != nil {
+		t.Fatalf("Failed to create chaos monkey: %v", err)
+	}
+	machines, err := c.Machines()
+	if err != nil {
+		t.Fatalf("Failed to get machines: %v", err)
+	}
+	u := machines[1].CoordinatorURL()
+	req := test.FaultRequest{
+		Test:     "unit",
+		Kind:     test.FaultRestart,
+		Role:     cluster.ServerRoleCoordinator,
+		Endpoint: u.String(),
+		Reason:   "testing",
+	}
+	ctx := context.Background()
+	if err := cm.InjectFault(ctx, req); !errors.Is(err, ErrSkipped) {
+		t.Errorf("Expected ErrSkipped while chaos is not active, got %v", err)
+	}
+
+	// Pretend chaos is active, without running the chaos loop
+	cm.(*chaosMonkey).active = true
+	if err := cm.InjectFault(ctx, req); err != nil {
+		t.Fatalf("Failed to inject fault: %v", err)
+	}
+	events := cm.GetRecentEvents(1)
+	if len(events) != 1 || events[0].ActionName != "Requested Fault" || events[0].Machine != machines[1].ID() || events[0].Outcome != EventSucceeded {
+		t.Errorf("Unexpected event %v", events)
+	}
+	if faults := cm.Faults(); len(faults) != 1 {
+		t.Errorf("Expected 1 active fault, got %d", len(faults))
+	}
+
+	req.Endpoint = "http://127.0.0.1:1"
+	if err := cm.InjectFault(ctx, req); !errors.Is(err, ErrSkipped) {
+		t.Errorf("Expected ErrSkipped for unknown endpoint, got %v", err)
+	}
+	req.Kind = test.FaultKind("explode")
+	if err := cm.InjectFault(ctx, req); err == nil || errors.Is(err, ErrSkipped) {
+		t.Errorf("Expected error for unknown fault kind, got %v", err)
+	}
+}
@@ -0,0 +1,74 @@
+package chaos
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/arangodb-helper/testagent/service/cluster"
+)
+
+// throttleAgentCPU randomly picks an agent and limits the CPU it can use for a while.
+func (c *chaosMonkey) throttleAgentCPU(ctx context.Context, action *chaosAction) bool {
+	return c.limitResources(ctx, action, cluster.ServerRoleAgent, cluster.ResourceLimits{CPUs: c.ThrottleCPUs})
+}
+
+// throttleDBServerCPU randomly picks a dbserver and limits the CPU it can use for a while.
+func (c *chaosMonkey) throttleDBServerCPU(ctx context.Context, action *chaosAction) bool {
+	return c.limitResources(ctx, action, cluster.ServerRoleDBServer, cluster.ResourceLimits{CPUs: c.ThrottleCPUs})
+}
+
+// throttleCoordinatorCPU randomly picks a coordinator and limits the CPU it can use for a while.
+func (c *chaosMonkey) throttleCoordinatorCPU(ctx context.Context, action *chaosAction) bool {
+	return c.limitResources(ctx, action, cluster.ServerRoleCoordinator, cluster.ResourceLimits{CPUs: c.ThrottleCPUs})
+}
+
+// limitAgentMemory randomly picks an agent and limits the memory it can use for a while.
+func (c *chaosMonkey) limitAgentMemory(ctx context.Context, action *chaosAction) bool {
+	return c.limitResources(ctx, action, cluster.ServerRoleAgent, cluster.ResourceLimits{Memory: c.MemoryLimit})
+}
+
+// limitDBServerMemory randomly picks a dbserver and limits the memory it can use for a while.
+func (c *chaosMonkey) limitDBServerMemory(ctx context.Context, action *chaosAction) bool {
+	return c.limitResources(ctx, action, cluster.ServerRoleDBServer, cluster.ResourceLimits{Memory: c.MemoryLimit})
+}
+
+// limitCoordinatorMemory randomly picks a coordinator and limits the memory it can use for a while.
+func (c *chaosMonkey) limitCoordinatorMemory(ctx context.Context, action *chaosAction) bool {
+	return c.limitResources(ctx, action, cluster.ServerRoleCoordinator, cluster.ResourceLimits{Memory: c.MemoryLimit})
+}
+
+// limitResources randomly picks a server with given role and limits its CPU and/or memory for a while.
+// The original limits are restored by the heal loop, also when chaos is stopped.
+func (c *chaosMonkey) limitResources(ctx context.Context, action *chaosAction, role cluster.ServerRole, l cluster.ResourceLimits) bool {
+	candidates, err := c.impairCandidates(role)
+	if err != nil {
+		c.log.Infof("%s, so I cannot limit the resources of a %s now", err.Error(), role)
+		action.skipped++
+		return false
+	}
+
+	// Pick a random machine
+	m := c.decisions.pickMachine(action, candidates)
+	f, ok := c.reserveFault(action, false, machineServer{m, role})
+	if !ok {
+		return false
+	}
+	timeout := c.faultTimeout(action)
+	event := c.startEvent(action, m, role, "Limiting resources of %s on %s to %s for %s", role, m.ID(), l, timeout)
+	if err := m.LimitResources(role, l); err != nil {
+		c.log.Errorf("Failed to limit resources of %s: %v", role, err)
+		action.failures++
+		c.finishEvent(event, err)
+		// Restore the limits that did get applied
+		m.RestoreResources(role)
+		c.faults.remove(f)
+		return false
+	}
+	action.succeeded++
+	c.introducedEvent(event)
+
+	// The heal loop waits a while before restoring the original limits
+	c.faults.activate(f, event, fmt.Sprintf("Limiting resources of %s on %s to %s", role, m.ID(), l), timeout,
+		fmt.Sprintf("Restoring resources of %s on %s", role, m.ID()), func() error { return m.RestoreResources(role) })
+	return true
+}
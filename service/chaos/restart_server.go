@@ -2,7 +2,9 @@ package chaos
 
 import (
 	"context"
-	"math/rand"
+	"fmt"
+
+	"github.com/arangodb-helper/testagent/service/cluster"
 )
 
 // restartAgent randomly picks an agent and restarts it.
@@ -21,15 +23,22 @@ func (c *chaosMonkey) restartAgent(ctx context.Context, action *chaosAction) boo
 	}
 
 	// Pick a random agent machine
-	m := agentMachines[rand.Intn(len(agentMachines))]
-	c.recordEvent(newEvent("Restarting agent on %s...", m.ID()))
+	m := c.decisions.pickMachine(action, agentMachines)
+	f, ok := c.reserveFault(action, false, machineServer{m, cluster.ServerRoleAgent})
+	if !ok {
+		return false
+	}
+	event := c.startEvent(action, m, cluster.ServerRoleAgent, "Restarting agent on %s", m.ID())
 	if err := m.RestartAgent(); err != nil {
 		c.log.Errorf("Failed to restart agent: %v", err)
 		action.failures++
-		c.recordEvent(newEvent("Restart agent on %s failed: %v", m.ID(), err))
+		c.finishEvent(event, err)
+		c.faults.remove(f)
 	} else {
 		action.succeeded++
-		c.recordEvent(newEvent("Restart agent on %s succeeded", m.ID()))
+		c.introducedEvent(event)
+		// The fault is active until the agent is ready again
+		c.faults.activate(f, event, fmt.Sprintf("Restart agent on %s", m.ID()), 0, "", nil)
 	}
 	return true
 }
@@ -37,17 +46,12 @@ func (c *chaosMonkey) restartAgent(ctx context.Context, action *chaosAction) boo
 // restartDBServer randomly picks a dbserver and restarts it.
 // Before doing so, it first checks if restarting a dbserver is allowed on the current cluster state.
 func (c *chaosMonkey) restartDBServer(ctx context.Context, action *chaosAction) bool {
-	readyMachines, notReadyServers, err := c.checkDBServerReadyStatus()
+	readyMachines, _, err := c.checkDBServerReadyStatus()
 	if err != nil {
 		c.log.Infof("Failed to check dbserver ready status (%s), so I cannot restart one now", err.Error())
 		action.skipped++
 		return false
 	}
-	if notReadyServers > 0 {
-		c.log.Infof("At least 1 dbserver is already down (%d down), so I cannot restart one now", notReadyServers)
-		action.skipped++
-		return false
-	}
 	if len(readyMachines) == 0 {
 		c.log.Infof("There are no ready dbservers in the cluster, so I cannot restart one now")
 		action.skipped++
@@ -55,15 +59,22 @@ func (c *chaosMonkey) restartDBServer(ctx context.Context, action *chaosAction)
 	}
 
 	// Pick a random dbserver machine
-	m := readyMachines[rand.Intn(len(readyMachines))]
-	c.recordEvent(newEvent("Restarting dbserver on %s...", m.ID()))
+	m := c.decisions.pickMachine(action, readyMachines)
+	f, ok := c.reserveFault(action, false, machineServer{m, cluster.ServerRoleDBServer})
+	if !ok {
+		return false
+	}
+	event := c.startEvent(action, m, cluster.ServerRoleDBServer, "Restarting dbserver on %s", m.ID())
 	if err := m.RestartDBServer(); err != nil {
 		c.log.Errorf("Failed to restart dbserver: %v", err)
 		action.failures++
-		c.recordEvent(newEvent("Restart dbserver on %s failed: %v", m.ID(), err))
+		c.finishEvent(event, err)
+		c.faults.remove(f)
 	} else {
 		action.succeeded++
-		c.recordEvent(newEvent("Restart dbserver on %s succeeded", m.ID()))
+		c.introducedEvent(event)
+		// The fault is active until the dbserver is ready again
+		c.faults.activate(f, event, fmt.Sprintf("Restart dbserver on %s", m.ID()), 0, "", nil)
 	}
 	return true
 }
@@ -84,15 +95,22 @@ func (c *chaosMonkey) restartCoordinator(ctx context.Context, action *chaosActio
 	}
 
 	// Pick a random coordinator machine
-	m := readyMachines[rand.Intn(len(readyMachines))]
-	c.recordEvent(newEvent("Restarting coordinator on %s...", m.ID()))
+	m := c.decisions.pickMachine(action, readyMachines)
+	f, ok := c.reserveFault(action, false, machineServer{m, cluster.ServerRoleCoordinator})
+	if !ok {
+		return false
+	}
+	event := c.startEvent(action, m, cluster.ServerRoleCoordinator, "Restarting coordinator on %s", m.ID())
 	if err := m.RestartCoordinator(); err != nil {
 		c.log.Errorf("Failed to restart coordinator: %v", err)
 		action.failures++
-		c.recordEvent(newEvent("Restart coordinator on %s failed: %v", m.ID(), err))
+		c.finishEvent(event, err)
+		c.faults.remove(f)
 	} else {
 		action.succeeded++
-		c.recordEvent(newEvent("Restart coordinator on %s succeeded", m.ID()))
+		c.introducedEvent(event)
+		// The fault is active until the coordinator is ready again
+		c.faults.activate(f, event, fmt.Sprintf("Restart coordinator on %s", m.ID()), 0, "", nil)
 	}
 	return true
 }
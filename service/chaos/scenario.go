@@ -0,0 +1,147 @@
+package chaos
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Scenario is a declarative list of chaos steps that is run instead of random chaos.
+type Scenario struct {
+	Name    string         `json:"name"`              // Name of the scenario
+	Repeat  int            `json:"repeat,omitempty"`  // Number of times all steps are run (default 1)
+	Forever bool           `json:"forever,omitempty"` // If set, all steps are run until chaos is stopped
+	Steps   []ScenarioStep `json:"steps"`             // Steps to run, in order
+}
+
+// ScenarioStep is a single step of a scenario.
+type ScenarioStep struct {
+	Name                string   `json:"name,omitempty"`                 // Optional description of the step
+	Action              string   `json:"action"`                         // Operation to perform (see ScenarioActions)
+	Target              string   `json:"target,omitempty"`               // How to select the target machine (see ScenarioTargets)
+	Duration            Duration `json:"duration,omitempty"`             // How long a network fault or freeze lasts or how long to wait (action=wait)
+	Wait                Duration `json:"wait,omitempty"`                 // How long to wait after the step
+	Repeat              int      `json:"repeat,omitempty"`               // Number of times the step is run (default 1)
+	Preconditions       []string `json:"preconditions,omitempty"`        // Conditions that must hold before the step is run (see ScenarioPreconditions)
+	PreconditionTimeout Duration `json:"precondition-timeout,omitempty"` // How long to wait for the preconditions (default 5m)
+}
+
+const (
+	defaultPreconditionTimeout = time.Minute * 5
+)
+
+var (
+	// ScenarioActions lists all supported step actions.
+	ScenarioActions = []string{
+		"wait",
+		"restart-agent", "restart-dbserver", "restart-coordinator",
+		"kill-agent", "kill-dbserver", "kill-coordinator",
+		"reject-agent-traffic", "reject-dbserver-traffic", "reject-coordinator-traffic",
+		"drop-agent-traffic", "drop-dbserver-traffic", "drop-coordinator-traffic",
+		"reboot-machine", "add-machine", "remove-machine",
+	}
+	// ScenarioTargets lists all supported target selectors.
+	// Next to these, `index:<n>` selects the machine with index n.
+	ScenarioTargets = []string{
+		"random", "most-shard-leaders", "fewest-shard-leaders",
+	}
+	// ScenarioPreconditions lists all supported preconditions.
+	ScenarioPreconditions = []string{
+		"agency-ready", "dbservers-ready", "coordinators-ready", "all-ready",
+	}
+)
+
+// Duration is a time.Duration that is encoded in JSON as a string such as "45s".
+type Duration time.Duration
+
+// MarshalJSON encodes the duration as a string.
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
+}
+
+// UnmarshalJSON decodes the duration from a string such as "2m".
+func (d *Duration) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return maskAny(err)
+	}
+	x, err := time.ParseDuration(s)
+	if err != nil {
+		return maskAny(err)
+	}
+	*d = Duration(x)
+	return nil
+}
+
+// LoadScenario reads and validates the scenario in the (JSON) file with given path.
+func LoadScenario(path string) (*Scenario, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, maskAny(err)
+	}
+	var s Scenario
+	if err := json.Unmarshal(data, &s); err != nil {
+		return nil, maskAny(fmt.Errorf("Failed to parse scenario %s: %v", path, err))
+	}
+	if err := s.Validate(); err != nil {
+		return nil, maskAny(err)
+	}
+	return &s, nil
+}
+
+// Validate checks the scenario for unknown actions, targets & preconditions.
+func (s Scenario) Validate() error {
+	if len(s.Steps) == 0 {
+		return maskAny(fmt.Errorf("Scenario '%s' has no steps", s.Name))
+	}
+	for i, step := range s.Steps {
+		if !slices.Contains(ScenarioActions, step.Action) {
+			return maskAny(fmt.Errorf("Step %d of scenario '%s' has unknown action '%s'", i+1, s.Name, step.Action))
+		}
+		if strings.HasPrefix(step.Target, "index:") {
+			if _, err := parseIndexTarget(step.Target); err != nil {
+				return maskAny(fmt.Errorf("Step %d of scenario '%s' has invalid target '%s': %v", i+1, s.Name, step.Target, err))
+			}
+		} else if step.Target != "" && !slices.Contains(ScenarioTargets, step.Target) {
+			return maskAny(fmt.Errorf("Step %d of scenario '%s' has unknown target '%s'", i+1, s.Name, step.Target))
+		}
+		for _, p := range step.Preconditions {
+			if !slices.Contains(ScenarioPreconditions, p) {
+				return maskAny(fmt.Errorf("Step %d of scenario '%s' has unknown precondition '%s'", i+1, s.Name, p))
+			}
+		}
+	}
+	return nil
+}
+
+// parseIndexTarget returns the machine index of an `index:<n>` target.
+func parseIndexTarget(target string) (int, error) {
+	raw := strings.TrimPrefix(target, "index:")
+	index, err := strconv.Atoi(raw)
+	if err != nil {
+		return 0, maskAny(fmt.Errorf("index '%s' is not a number", raw))
+	}
+	if index < 0 || strconv.Itoa(index) != raw {
+		return 0, maskAny(fmt.Errorf("index '%s' must be a non-negative integer", raw))
+	}
+	return index, nil
+}
+
+// Description returns a human readable description of the step.
+func (s ScenarioStep) Description() string {
+	if s.Name != "" {
+		return s.Name
+	}
+	result := s.Action
+	if s.Target != "" {
+		result += " on " + s.Target
+	}
+	if s.Duration > 0 {
+		result += " for " + time.Duration(s.Duration).String()
+	}
+	return result
+}
@@ -0,0 +1,238 @@
+package chaos
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/arangodb-helper/testagent/service/cluster"
+)
+
+// scenarioOperation describes how a scenario action is applied to a machine.
+type scenarioOperation struct {
+	agentOnly bool                        // If set, only machines with an agent are candidates
+	apply     func(cluster.Machine) error // Introduces the chaos
+	undo      func(cluster.Machine) error // Removes the chaos after the step duration (can be nil)
+}
+
+var scenarioOperations = map[string]scenarioOperation{
+	"restart-agent":              {true, cluster.Machine.RestartAgent, nil},
+	"restart-dbserver":           {false, cluster.Machine.RestartDBServer, nil},
+	"restart-coordinator":        {false, cluster.Machine.RestartCoordinator, nil},
+	"kill-agent":                 {true, cluster.Machine.KillAgent, nil},
+	"kill-dbserver":              {false, cluster.Machine.KillDBServer, nil},
+	"kill-coordinator":           {false, cluster.Machine.KillCoordinator, nil},
+	"reject-agent-traffic":       {true, cluster.Machine.RejectAgentTraffic, cluster.Machine.AcceptAgentTraffic},
+	"reject-dbserver-traffic":    {false, cluster.Machine.RejectDBServerTraffic, cluster.Machine.AcceptDBServerTraffic},
+	"reject-coordinator-traffic": {false, cluster.Machine.RejectCoordinatorTraffic, cluster.Machine.AcceptCoordinatorTraffic},
+	"drop-agent-traffic":         {true, cluster.Machine.DropAgentTraffic, cluster.Machine.AcceptAgentTraffic},
+	"drop-dbserver-traffic":      {false, cluster.Machine.DropDBServerTraffic, cluster.Machine.AcceptDBServerTraffic},
+	"drop-coordinator-traffic":   {false, cluster.Machine.DropCoordinatorTraffic, cluster.Machine.AcceptCoordinatorTraffic},
+	"reboot-machine":             {false, cluster.Machine.Reboot, nil},
+	"remove-machine":             {false, cluster.Machine.Destroy, nil},
+}
+
+// scenarioLoop runs the configured scenario instead of random chaos.
+func (c *chaosMonkey) scenarioLoop(ctx context.Context) {
+	s := c.scenario
+	c.recordEvent(newEvent("Starting scenario '%s'", s.Name))
+	repeat := s.Repeat
+	if repeat <= 0 {
+		repeat = 1
+	}
+	for i := 0; (s.Forever || i < repeat) && ctx.Err() == nil; i++ {
+		for _, step := range s.Steps {
+			stepRepeat := step.Repeat
+			if stepRepeat <= 0 {
+				stepRepeat = 1
+			}
+			for j := 0; j < stepRepeat && ctx.Err() == nil; j++ {
+				c.runScenarioStep(ctx, step)
+				if !c.sleep(ctx, time.Duration(step.Wait)) {
+					break
+				}
+			}
+		}
+	}
+	if ctx.Err() == nil {
+		c.recordEvent(newEvent("Scenario '%s' finished", s.Name))
+	}
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.active = false
+	c.cancel = nil
+}
+
+// runScenarioStep runs a single step of the scenario.
+func (c *chaosMonkey) runScenarioStep(ctx context.Context, step ScenarioStep) {
+	desc := step.Description()
+	if step.Action == "wait" {
+		c.sleep(ctx, time.Duration(step.Duration))
+		return
+	}
+	action := &chaosAction{name: step.Action}
+
+	// Wait for preconditions
+	if err := c.waitForPreconditions(ctx, step); err != nil {
+		c.skipEvent(c.startEvent(action, nil, "", "Step '%s'", desc), err)
+		return
+	}
+
+	// Adding a machine does not need a target
+	if step.Action == "add-machine" {
+		event := c.startEvent(action, nil, "", "Adding machine (step '%s')", desc)
+		if m, err := c.cluster.Add(); err != nil {
+			c.finishEvent(event, err)
+		} else {
+			c.updateEvent(event, func(e *Event) { e.Machine = m.ID() })
+			c.finishEvent(event, nil)
+		}
+		return
+	}
+
+	// Select target
+	op := scenarioOperations[step.Action]
+	m, err := c.selectScenarioTarget(ctx, step, op)
+	if err != nil {
+		c.skipEvent(c.startEvent(action, nil, "", "Step '%s'", desc), err)
+		return
+	}
+
+	// Apply operation
+	event := c.startEvent(action, m, "", "Running step '%s' on %s", desc, m.ID())
+	if err := op.apply(m); err != nil {
+		c.log.Errorf("Step '%s' failed: %v", desc, err)
+		c.finishEvent(event, err)
+		return
+	}
+	if op.undo == nil {
+		c.finishEvent(event, nil)
+		return
+	}
+	c.introducedEvent(event)
+
+	// Wait a while before removing the chaos
+	timeout := time.Duration(step.Duration)
+	if timeout == 0 {
+		timeout = c.networkTimeout(action)
+	}
+	c.sleep(ctx, timeout)
+	err = op.undo(m)
+	if err != nil {
+		c.log.Errorf("Restoring after step '%s' on %s failed: %v", desc, m.ID(), err)
+	}
+	c.endEvent(event, err)
+}
+
+// waitForPreconditions blocks until all preconditions of the given step hold.
+func (c *chaosMonkey) waitForPreconditions(ctx context.Context, step ScenarioStep) error {
+	if len(step.Preconditions) == 0 {
+		return nil
+	}
+	timeout := time.Duration(step.PreconditionTimeout)
+	if timeout == 0 {
+		timeout = defaultPreconditionTimeout
+	}
+	deadline := time.Now().Add(timeout)
+	for {
+		err := c.checkPreconditions(step.Preconditions)
+		if err == nil {
+			return nil
+		}
+		if time.Now().After(deadline) {
+			return maskAny(fmt.Errorf("preconditions not met after %s: %v", timeout, err))
+		}
+		if !c.sleep(ctx, time.Second*5) {
+			return maskAny(ctx.Err())
+		}
+	}
+}
+
+// checkPreconditions returns an error if one of the given preconditions does not hold.
+func (c *chaosMonkey) checkPreconditions(preconditions []string) error {
+	for _, p := range preconditions {
+		if p == "agency-ready" || p == "all-ready" {
+			if _, _, err := c.checkAgencyReadyStatus(); err != nil {
+				return maskAny(fmt.Errorf("not all agents are ready"))
+			}
+		}
+		if p == "dbservers-ready" || p == "all-ready" {
+			if _, notReady, err := c.checkDBServerReadyStatus(); err != nil || notReady > 0 {
+				return maskAny(fmt.Errorf("not all dbservers are ready"))
+			}
+		}
+		if p == "coordinators-ready" || p == "all-ready" {
+			if _, notReady, err := c.checkCoordinatorReadyStatus(); err != nil || notReady > 0 {
+				return maskAny(fmt.Errorf("not all coordinators are ready"))
+			}
+		}
+	}
+	return nil
+}
+
+// selectScenarioTarget selects the machine the given step is applied to.
+func (c *chaosMonkey) selectScenarioTarget(ctx context.Context, step ScenarioStep, op scenarioOperation) (cluster.Machine, error) {
+	machines, err := c.cluster.Machines()
+	if err != nil {
+		return nil, maskAny(err)
+	}
+	var candidates MachineList
+	for _, m := range machines {
+		if step.Action == "remove-machine" && (!m.DestroyAllowed() || m.HasAgent()) {
+			continue
+		}
+		if !op.agentOnly || m.HasAgent() {
+			candidates = append(candidates, m)
+		}
+	}
+	if len(candidates) == 0 {
+		return nil, maskAny(fmt.Errorf("no candidate machines"))
+	}
+
+	switch {
+	case step.Target == "" || step.Target == "random":
+		return c.decisions.pickMachine(&chaosAction{name: step.Action}, candidates), nil
+	case strings.HasPrefix(step.Target, "index:"):
+		index, err := parseIndexTarget(step.Target)
+		if err != nil {
+			return nil, maskAny(fmt.Errorf("invalid target '%s': %v", step.Target, err))
+		}
+		prefix := fmt.Sprintf("m%d-", index)
+		for _, m := range candidates {
+			if strings.HasPrefix(m.ID(), prefix) {
+				return m, nil
+			}
+		}
+		return nil, maskAny(fmt.Errorf("no candidate machine for target '%s'", step.Target))
+	case step.Target == "most-shard-leaders" || step.Target == "fewest-shard-leaders":
+		counts, err := c.shardLeaderCounts(ctx, candidates, false)
+		if err != nil {
+			return nil, maskAny(err)
+		}
+		sort.SliceStable(candidates, func(i, j int) bool {
+			return counts[candidates[i].ID()] > counts[candidates[j].ID()]
+		})
+		if step.Target == "most-shard-leaders" {
+			return candidates[0], nil
+		}
+		return candidates[len(candidates)-1], nil
+	default:
+		return nil, maskAny(fmt.Errorf("unknown target '%s'", step.Target))
+	}
+}
+
+// sleep waits for the given duration, or until the given context is cancelled.
+// It returns false if the context was cancelled.
+func (c *chaosMonkey) sleep(ctx context.Context, d time.Duration) bool {
+	if d <= 0 {
+		return ctx.Err() == nil
+	}
+	select {
+	case <-ctx.Done():
+		return false
+	case <-time.After(d):
+		return true
+	}
+}
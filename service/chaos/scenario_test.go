@@ -0,0 +1,51 @@
+package chaos
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestScenarioParse(t *testing.T) {
+	input := `{
+		"name": "test",
+		"repeat": 2,
+		"steps": [
+			{ "action": "kill-dbserver", "target": "most-shard-leaders", "preconditions": ["all-ready"] },
+			{ "action": "wait", "duration": "45s" },
+			{ "action": "drop-agent-traffic", "duration": "2m", "target": "index:1" }
+		]
+	}`
+	var s Scenario
+	if err := json.Unmarshal([]byte(input), &s); err != nil {
+		t.Fatalf("Failed to parse scenario: %v", err)
+	}
+	if err := s.Validate(); err != nil {
+		t.Errorf("Expected valid scenario, got %v", err)
+	}
+	if len(s.Steps) != 3 {
+		t.Fatalf("Expected 3 steps, got %d", len(s.Steps))
+	}
+	if d := time.Duration(s.Steps[1].Duration); d != 45*time.Second {
+		t.Errorf("Expected duration 45s, got %s", d)
+	}
+	if desc := s.Steps[2].Description(); desc != "drop-agent-traffic on index:1 for 2m0s" {
+		t.Errorf("Unexpected description '%s'", desc)
+	}
+}
+
+func TestScenarioValidate(t *testing.T) {
+	invalid := []Scenario{
+		{Name: "no steps"},
+		{Name: "action", Steps: []ScenarioStep{{Action: "explode"}}},
+		{Name: "target", Steps: []ScenarioStep{{Action: "kill-agent", Target: "biggest"}}},
+		{Name: "index", Steps: []ScenarioStep{{Action: "kill-agent", Target: "index:two"}}},
+		{Name: "negative index", Steps: []ScenarioStep{{Action: "kill-agent", Target: "index:-1"}}},
+		{Name: "precondition", Steps: []ScenarioStep{{Action: "kill-agent", Preconditions: []string{"sunny"}}}},
+	}
+	for _, s := range invalid {
+		if err := s.Validate(); err == nil {
+			t.Errorf("Expected scenario '%s' to be invalid", s.Name)
+		}
+	}
+}
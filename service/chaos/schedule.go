@@ -0,0 +1,187 @@
+package chaos
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	logging "github.com/op/go-logging"
+)
+
+// Schedule is a list of phases that change the chaos level over time.
+// After the last phase, the schedule continues with phase RepeatFrom (1-based).
+// If RepeatFrom is 0, the last phase lasts forever (its duration is ignored).
+type Schedule struct {
+	Name       string          `json:"name,omitempty"`        // Name of the schedule
+	Phases     []SchedulePhase `json:"phases"`                // Phases, in order
+	RepeatFrom int             `json:"repeat-from,omitempty"` // Phase (1-based) to continue with after the last phase (0 means no repeat)
+}
+
+// SchedulePhase is a single phase of a schedule.
+type SchedulePhase struct {
+	Name     string   `json:"name,omitempty"`  // Optional description of the phase
+	Duration Duration `json:"duration"`        // How long the phase lasts (not needed for the last phase of a schedule without repeat)
+	Level    int      `json:"level,omitempty"` // Chaos level during the phase
+	Quiet    bool     `json:"quiet,omitempty"` // If set, no chaos is introduced during the phase
+}
+
+// ScheduleStatus describes the active phase of a schedule.
+type ScheduleStatus struct {
+	Schedule string    // Name of the schedule
+	Index    int       // Index (1-based) of the active phase
+	Phase    string    // Description of the active phase
+	Level    int       // Chaos level of the active phase
+	Quiet    bool      // Set if no chaos is introduced during the active phase
+	Since    time.Time // When the active phase started
+	Until    time.Time // When the active phase ends (zero if it lasts forever)
+	Cycle    int       // Number of times the schedule has been repeated
+}
+
+// LoadSchedule reads and validates the schedule in the (JSON) file with given path.
+func LoadSchedule(path string) (*Schedule, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, maskAny(err)
+	}
+	var s Schedule
+	if err := json.Unmarshal(data, &s); err != nil {
+		return nil, maskAny(fmt.Errorf("Failed to parse schedule %s: %v", path, err))
+	}
+	if err := s.Validate(); err != nil {
+		return nil, maskAny(err)
+	}
+	return &s, nil
+}
+
+// Validate checks the schedule for invalid levels & durations.
+func (s Schedule) Validate() error {
+	if len(s.Phases) == 0 {
+		return maskAny(fmt.Errorf("Schedule '%s' has no phases", s.Name))
+	}
+	if s.RepeatFrom < 0 || s.RepeatFrom > len(s.Phases) {
+		return maskAny(fmt.Errorf("Schedule '%s' repeats from unknown phase %d", s.Name, s.RepeatFrom))
+	}
+	for i, p := range s.Phases {
+		if !p.Quiet && (p.Level < chaosLevelMin || p.Level > chaosLevelMax) {
+			return maskAny(fmt.Errorf("Phase %d of schedule '%s' has level %d, out of range [%d, %d]", i+1, s.Name, p.Level, chaosLevelMin, chaosLevelMax))
+		}
+		if p.Duration < 0 {
+			return maskAny(fmt.Errorf("Phase %d of schedule '%s' has a negative duration", i+1, s.Name))
+		}
+		if p.Duration == 0 && (i+1 < len(s.Phases) || s.RepeatFrom > 0) {
+			return maskAny(fmt.Errorf("Phase %d of schedule '%s' has no duration", i+1, s.Name))
+		}
+	}
+	return nil
+}
+
+// Description returns a human readable description of the phase.
+func (p SchedulePhase) Description() string {
+	if p.Name != "" {
+		return p.Name
+	}
+	if p.Quiet {
+		return "quiet"
+	}
+	return fmt.Sprintf("level %d", p.Level)
+}
+
+// next returns the index (0-based) of the phase that follows the phase with given index
+// and true if the schedule starts a new cycle with it.
+// It returns -1 when the given phase is the last one.
+func (s Schedule) next(index int) (int, bool) {
+	if index+1 < len(s.Phases) {
+		return index + 1, false
+	}
+	if s.RepeatFrom > 0 {
+		return s.RepeatFrom - 1, true
+	}
+	return -1, false
+}
+
+// Scheduler drives the chaos level of a chaos monkey, and starts & stops it, according to a schedule.
+type Scheduler struct {
+	mutex    sync.Mutex
+	log      *logging.Logger
+	cm       ChaosMonkey
+	schedule Schedule
+	status   ScheduleStatus
+}
+
+// NewScheduler creates a scheduler that drives the given chaos monkey according to the given schedule.
+func NewScheduler(log *logging.Logger, cm ChaosMonkey, schedule Schedule) *Scheduler {
+	return &Scheduler{
+		log:      log,
+		cm:       cm,
+		schedule: schedule,
+	}
+}
+
+// Status returns the active phase of the schedule.
+func (s *Scheduler) Status() ScheduleStatus {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	return s.status
+}
+
+// Run applies all phases of the schedule, until the given context is cancelled.
+// Manual changes of the chaos level or pausing/resuming chaos remain in effect until the next phase starts.
+func (s *Scheduler) Run(ctx context.Context) {
+	index, cycle := 0, 0
+	for ctx.Err() == nil {
+		phase := s.schedule.Phases[index]
+		now := time.Now()
+		status := ScheduleStatus{
+			Schedule: s.schedule.Name,
+			Index:    index + 1,
+			Phase:    phase.Description(),
+			Level:    phase.Level,
+			Quiet:    phase.Quiet,
+			Since:    now,
+			Cycle:    cycle,
+		}
+		next, repeat := s.schedule.next(index)
+		if next >= 0 {
+			status.Until = now.Add(time.Duration(phase.Duration))
+		}
+		s.mutex.Lock()
+		s.status = status
+		s.mutex.Unlock()
+		s.apply(phase)
+
+		if next < 0 {
+			// Last phase lasts forever
+			return
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(time.Duration(phase.Duration)):
+		}
+		index = next
+		if repeat {
+			cycle++
+		}
+	}
+}
+
+// apply sets the chaos level of the given phase and starts or stops the chaos monkey.
+func (s *Scheduler) apply(phase SchedulePhase) {
+	s.log.Infof("Starting chaos schedule phase '%s'", phase.Description())
+	if phase.Quiet {
+		s.cm.Stop()
+		return
+	}
+	if err := s.cm.SetChaosLevel(phase.Level); err != nil {
+		s.log.Errorf("Failed to set chaos level %d: %v", phase.Level, err)
+	}
+	if s.cm.State() == "stopping" {
+		// Start has no effect until the previous chaos has stopped
+		s.cm.WaitUntilInactive()
+	}
+	s.cm.Start()
+}
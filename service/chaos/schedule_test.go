@@ -0,0 +1,50 @@
+package chaos
+
+import (
+	"testing"
+	"time"
+)
+
+func TestScheduleValidate(t *testing.T) {
+	hour := Duration(time.Hour)
+	valid := []Schedule{
+		{Phases: []SchedulePhase{{Level: 1, Duration: hour}, {Level: 4}}},
+		{Phases: []SchedulePhase{{Level: 1, Duration: hour}, {Level: 4, Duration: hour}, {Quiet: true, Duration: hour}}, RepeatFrom: 2},
+	}
+	for i, s := range valid {
+		if err := s.Validate(); err != nil {
+			t.Errorf("Expected schedule %d to be valid, got %v", i, err)
+		}
+	}
+	invalid := []Schedule{
+		{},
+		{Phases: []SchedulePhase{{Level: 5}}},
+		{Phases: []SchedulePhase{{Level: 1}, {Level: 2}}},
+		{Phases: []SchedulePhase{{Level: 1}}, RepeatFrom: 1},
+		{Phases: []SchedulePhase{{Level: 1, Duration: hour}}, RepeatFrom: 2},
+		{Phases: []SchedulePhase{{Level: 1, Duration: -hour}}},
+	}
+	for i, s := range invalid {
+		if err := s.Validate(); err == nil {
+			t.Errorf("Expected schedule %d to be invalid", i)
+		}
+	}
+}
+
+func TestScheduleNext(t *testing.T) {
+	hour := Duration(time.Hour)
+	s := Schedule{Phases: []SchedulePhase{{Level: 1, Duration: hour}, {Level: 4, Duration: hour}, {Quiet: true, Duration: hour}}, RepeatFrom: 2}
+	expected := []struct {
+		index  int
+		repeat bool
+	}{{1, false}, {2, false}, {1, true}}
+	for i, e := range expected {
+		if index, repeat := s.next(i); index != e.index || repeat != e.repeat {
+			t.Errorf("Expected phase %d to be followed by %d (repeat %v), got %d (repeat %v)", i, e.index, e.repeat, index, repeat)
+		}
+	}
+	s.RepeatFrom = 0
+	if index, _ := s.next(2); index != -1 {
+		t.Errorf("Expected last phase to have no successor, got %d", index)
+	}
+}
@@ -0,0 +1,38 @@
+package chaos
+
+import (
+	"context"
+
+	"github.com/arangodb-helper/testagent/service/cluster"
+)
+
+// restartSingle randomly picks a single server and restarts it.
+func (c *chaosMonkey) restartSingle(ctx context.Context, action *chaosAction) bool {
+	return c.singleServerChaos(ctx, action, singleOperations["restart"])
+}
+
+// killSingle randomly picks a single server and kills it (the hard way).
+func (c *chaosMonkey) killSingle(ctx context.Context, action *chaosAction) bool {
+	return c.singleServerChaos(ctx, action, singleOperations["kill"])
+}
+
+// freezeSingle randomly picks a single server and freezes it for a while (as if it hangs).
+func (c *chaosMonkey) freezeSingle(ctx context.Context, action *chaosAction) bool {
+	return c.singleServerChaos(ctx, action, singleOperations["freeze"])
+}
+
+// singleServerChaos randomly picks a single server and applies the given operation to it.
+// Before doing so, it first checks that all single servers are ready.
+// In single mode that is the only server of the deployment, so clients see it go down.
+func (c *chaosMonkey) singleServerChaos(ctx context.Context, action *chaosAction, op serverOperation) bool {
+	candidates, err := c.impairCandidates(cluster.ServerRoleSingle)
+	if err != nil {
+		c.log.Infof("%s, so I cannot impair a single server now", err.Error())
+		action.skipped++
+		return false
+	}
+
+	// Pick a random single server machine
+	m := c.decisions.pickMachine(action, candidates)
+	return c.leaderChaos(action, m, cluster.ServerRoleSingle, "", op)
+}
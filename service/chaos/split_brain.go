@@ -0,0 +1,225 @@
+package chaos
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/arangodb-helper/testagent/service/cluster"
+)
+
+// partition is a split of servers into 2 groups that cannot reach each other.
+type partition struct {
+	layout string
+	sideA  []machineServer
+	sideB  []machineServer
+}
+
+const (
+	partitionAgencyMajority      = "agency majority vs minority"
+	partitionIsolatedCoordinator = "coordinator vs all dbservers"
+	partitionRandomHalves        = "random halves"
+)
+
+// String returns a human readable description of the partition.
+func (p partition) String() string {
+	side := func(servers []machineServer) string {
+		var names []string
+		for _, s := range servers {
+			names = append(names, fmt.Sprintf("%s/%s", s.machine.ID(), s.role))
+		}
+		return "[" + strings.Join(names, ", ") + "]"
+	}
+	return fmt.Sprintf("%s: %s | %s", p.layout, side(p.sideA), side(p.sideB))
+}
+
+// splitBrain splits the cluster into 2 groups and silently drops all network traffic between them.
+func (c *chaosMonkey) splitBrain(ctx context.Context, action *chaosAction) bool {
+	if c.DisableNetworkChaos {
+		return false
+	}
+	machines, err := c.cluster.Machines()
+	if err != nil {
+		c.log.Errorf("Failed to get machines: %v", err)
+		return false
+	}
+	if !c.networkFeatureAvailable(action, machines, "network partitions", func(f cluster.NetworkFeatures) bool { return f.Partition }) {
+		return false
+	}
+	agentMachines, _, err := c.checkAgencyReadyStatus()
+	if err != nil {
+		c.log.Infof("Not all agents are ready (%s), so I cannot split the network now", err.Error())
+		action.skipped++
+		return false
+	}
+	if len(agentMachines) < 3 {
+		c.log.Infof("There are too few (%d) agents in the cluster, so I cannot split the network now", len(agentMachines))
+		action.skipped++
+		return false
+	}
+	readyDBServerMachines, notReadyDBServers, err := c.checkDBServerReadyStatus()
+	if err != nil || notReadyDBServers > 0 {
+		c.log.Infof("At least 1 dbserver is already down (%d down), so I cannot split the network now", notReadyDBServers)
+		action.skipped++
+		return false
+	}
+	readyCoordinatorMachines, _, err := c.checkCoordinatorReadyStatus()
+	if err != nil || len(readyCoordinatorMachines) <= 1 {
+		c.log.Infof("Only %d coordinator is ready, so I cannot split the network now", len(readyCoordinatorMachines))
+		action.skipped++
+		return false
+	}
+
+	// Pick a partition layout
+	var p partition
+	switch c.decisions.choose(action, 3) {
+	case 0:
+		// Majority of agents (and some other machines) vs the rest
+		p.layout = partitionAgencyMajority
+		agents := c.decisions.shuffle(action, agentMachines)
+		majority := agents[:len(agents)/2+1]
+		for _, m := range c.decisions.shuffle(action, MachineList(machines)) {
+			inA := majority.Contains(m)
+			if !agentMachines.Contains(m) {
+				inA = c.decisions.choose(action, 2) == 0
+			}
+			for _, role := range machineRoles(m) {
+				if inA {
+					p.sideA = append(p.sideA, machineServer{m, role})
+				} else {
+					p.sideB = append(p.sideB, machineServer{m, role})
+				}
+			}
+		}
+	case 1:
+		// A single coordinator vs all dbservers
+		p.layout = partitionIsolatedCoordinator
+		m := c.decisions.pickMachine(action, readyCoordinatorMachines)
+		p.sideA = []machineServer{{m, cluster.ServerRoleCoordinator}}
+		for _, x := range readyDBServerMachines {
+			p.sideB = append(p.sideB, machineServer{x, cluster.ServerRoleDBServer})
+		}
+	default:
+		// Random split of machines
+		p.layout = partitionRandomHalves
+		shuffled := c.decisions.shuffle(action, MachineList(machines))
+		split := c.decisions.choose(action, len(shuffled)-1) + 1
+		for i, m := range shuffled {
+			for _, role := range machineRoles(m) {
+				if i < split {
+					p.sideA = append(p.sideA, machineServer{m, role})
+				} else {
+					p.sideB = append(p.sideB, machineServer{m, role})
+				}
+			}
+		}
+	}
+
+	var servers []machineServer
+	servers = append(servers, p.sideA...)
+	servers = append(servers, p.sideB...)
+	f, ok := c.reserveFault(action, true, servers...)
+	if !ok {
+		return false
+	}
+	timeout := c.networkTimeout(action)
+	event := c.startEvent(action, nil, "", "Splitting network for %s (%s)", timeout, p)
+	if err := c.applyPartition(p, true); err != nil {
+		c.log.Errorf("Failed to split network: %v", err)
+		action.failures++
+		c.finishEvent(event, err)
+		// Remove the rules that did get applied
+		c.applyPartition(p, false)
+		c.faults.remove(f)
+		return false
+	}
+	action.succeeded++
+	c.introducedEvent(event)
+
+	// The heal loop waits a while before healing the partition
+	c.faults.activate(f, event, fmt.Sprintf("Splitting network (%s)", p), timeout,
+		fmt.Sprintf("Healing network partition (%s)", p.layout), func() error { return c.applyPartition(p, false) })
+	return true
+}
+
+// applyPartition drops (block=true) or accepts (block=false) all traffic between the
+// two sides of the given partition.
+// Rules are installed on the machines of both sides, such that traffic is blocked on
+// every docker host it passes.
+func (c *chaosMonkey) applyPartition(p partition, block bool) error {
+	ips := func(servers []machineServer) ([]string, error) {
+		var result []string
+		for _, s := range servers {
+			ip, err := s.machine.ContainerIP(s.role)
+			if err != nil {
+				return nil, maskAny(err)
+			}
+			result = append(result, ip)
+		}
+		return result, nil
+	}
+	ipsA, err := ips(p.sideA)
+	if err != nil {
+		return maskAny(err)
+	}
+	ipsB, err := ips(p.sideB)
+	if err != nil {
+		return maskAny(err)
+	}
+	var lastErr error
+	apply := func(servers []machineServer, others []string) {
+		for _, s := range servers {
+			var err error
+			if block {
+				err = s.machine.DropTrafficBetween(s.role, others)
+			} else {
+				err = s.machine.AcceptTrafficBetween(s.role, others)
+			}
+			if err != nil {
+				c.log.Errorf("Failed to change traffic between %s/%s and %v: %v", s.machine.ID(), s.role, others, err)
+				lastErr = err
+			}
+		}
+	}
+	apply(p.sideA, ipsB)
+	apply(p.sideB, ipsA)
+	return maskAny(lastErr)
+}
+
+// networkFeatureAvailable returns true if the network(-blocker) of all given machines supports the
+// network operation (described by what) needed by the given action.
+// When it is not supported, the action is disabled, since it would fail every time.
+func (c *chaosMonkey) networkFeatureAvailable(action *chaosAction, machines []cluster.Machine, what string, supported func(cluster.NetworkFeatures) bool) bool {
+	for _, m := range machines {
+		features, err := m.NetworkFeatures()
+		if err != nil {
+			c.log.Infof("Cannot check network features of %s (%s), so I cannot introduce %s now", m.ID(), err.Error(), what)
+			action.skipped++
+			return false
+		}
+		if !supported(features) {
+			c.log.Warningf("The network-blocker of %s does not support %s (see --network-blocker-image), disabling '%s'", m.ID(), what, action.name)
+			action.Disable()
+			return false
+		}
+	}
+	return true
+}
+
+// machineRoles returns the roles of all servers on the given machine.
+func machineRoles(m cluster.Machine) []cluster.ServerRole {
+	var result []cluster.ServerRole
+	if m.HasAgent() {
+		result = append(result, cluster.ServerRoleAgent)
+	}
+	if m.HasDBServer() {
+		result = append(result, cluster.ServerRoleDBServer)
+	}
+	if m.HasCoordinator() {
+		result = append(result, cluster.ServerRoleCoordinator)
+	}
+	if m.HasSingle() {
+		result = append(result, cluster.ServerRoleSingle)
+	}
+	return result
+}
@@ -1,12 +1,16 @@
 package chaos
 
 import (
+	"context"
+	"fmt"
 	"sync"
 
+	"github.com/arangodb-helper/testagent/service/cluster"
 	"golang.org/x/sync/errgroup"
 )
 
 // checkAgencyReadyStatus checks that all agents in the cluster are ready.
+// An agent is only ready when it answers pings and the cluster health reports it as GOOD.
 // It returns: readyAgentMachines, #notReadyAgents, error
 func (c *chaosMonkey) checkAgencyReadyStatus() (MachineList, int, error) {
 	machines, err := c.cluster.Machines()
@@ -31,16 +35,71 @@ func (c *chaosMonkey) checkAgencyReadyStatus() (MachineList, int, error) {
 		return nil, 0, maskAny(err)
 	}
 
+	if !c.cluster.Mode().IsCluster() {
+		// Without coordinators there is no cluster health to check
+		return agentMachines, len(machines) - len(agentMachines), nil
+	}
+
+	// Agents can answer pings while the cluster considers them unhealthy
+	health, err := c.clusterHealth(context.Background())
+	if err != nil {
+		return nil, 0, maskAny(err)
+	}
+	if unhealthy := unhealthyServers(health, "Agent", agentMachines, cluster.Machine.AgentURL); len(unhealthy) > 0 {
+		return nil, 0, maskAny(fmt.Errorf("Agent on %s is not healthy", unhealthy[0].ID()))
+	}
+
 	return agentMachines, len(machines) - len(agentMachines), nil
 }
 
 // checkDBServerReadyStatus checks that all DBServers in the cluster are ready.
+// A dbserver is only ready when it answers pings and the cluster health reports it as GOOD.
+// When there are ready dbservers, it also checks that no shard would lose its write concern
+// when another dbserver goes down (how many dbservers may be impaired is limited by the fault budget,
+// which also counts dbservers that are down without a tracked fault).
 // It returns: readyDBServerMachines, #notReadyDBServerMachines error
 func (c *chaosMonkey) checkDBServerReadyStatus() (MachineList, int, error) {
 	machines, err := c.cluster.Machines()
 	if err != nil {
 		return nil, 0, maskAny(err)
 	}
+	ctx := context.Background()
+	readyMachines, err := c.readyDBServers(ctx, machines)
+	if err != nil {
+		return nil, 0, maskAny(err)
+	}
+	notReady := len(machines) - len(readyMachines)
+	if len(readyMachines) > 0 {
+		if err := c.checkShardsInSync(ctx); err != nil {
+			return nil, 0, maskAny(err)
+		}
+	}
+
+	return readyMachines, notReady, nil
+}
+
+// notReadyDBServers returns all machines with a dbserver that is not ready.
+func (c *chaosMonkey) notReadyDBServers() (MachineList, error) {
+	machines, err := c.cluster.Machines()
+	if err != nil {
+		return nil, maskAny(err)
+	}
+	var dbserverMachines MachineList
+	for _, m := range machines {
+		if m.HasDBServer() {
+			dbserverMachines = append(dbserverMachines, m)
+		}
+	}
+	readyMachines, err := c.readyDBServers(context.Background(), dbserverMachines)
+	if err != nil {
+		return nil, maskAny(err)
+	}
+	return dbserverMachines.Except(readyMachines), nil
+}
+
+// readyDBServers returns those of the given machines that have a dbserver which
+// answers pings and which the cluster health reports as GOOD.
+func (c *chaosMonkey) readyDBServers(ctx context.Context, machines MachineList) (MachineList, error) {
 	var mutex sync.Mutex
 	var readyMachines MachineList
 	g := errgroup.Group{}
@@ -56,10 +115,15 @@ func (c *chaosMonkey) checkDBServerReadyStatus() (MachineList, int, error) {
 		})
 	}
 	if err := g.Wait(); err != nil {
-		return nil, 0, maskAny(err)
+		return nil, maskAny(err)
 	}
 
-	return readyMachines, len(machines) - len(readyMachines), nil
+	// DBServers can answer pings while the cluster considers them unhealthy
+	health, err := c.clusterHealth(ctx)
+	if err != nil {
+		return nil, maskAny(err)
+	}
+	return readyMachines.Except(unhealthyServers(health, "DBServer", readyMachines, cluster.Machine.DBServerURL)), nil
 }
 
 // checkCoordinatorReadyStatus checks that all Coordinators in the cluster are ready.
@@ -89,3 +153,36 @@ func (c *chaosMonkey) checkCoordinatorReadyStatus() (MachineList, int, error) {
 
 	return readyMachines, len(machines) - len(readyMachines), nil
 }
+
+// checkSingleReadyStatus checks that all single servers in the deployment are ready.
+// It returns: readySingleMachines, #notReadySingleServers error
+func (c *chaosMonkey) checkSingleReadyStatus() (MachineList, int, error) {
+	machines, err := c.cluster.Machines()
+	if err != nil {
+		return nil, 0, maskAny(err)
+	}
+	var mutex sync.Mutex
+	var readyMachines MachineList
+	singles := 0
+	g := errgroup.Group{}
+	for _, m := range machines {
+		m := m // Used in nested func
+		if !m.HasSingle() {
+			continue
+		}
+		singles++
+		g.Go(func() error {
+			if err := m.TestSingleStatus(); err == nil {
+				mutex.Lock()
+				defer mutex.Unlock()
+				readyMachines = append(readyMachines, m)
+			}
+			return nil
+		})
+	}
+	if err := g.Wait(); err != nil {
+		return nil, 0, maskAny(err)
+	}
+
+	return readyMachines, singles - len(readyMachines), nil
+}
@@ -0,0 +1,138 @@
+package chaos
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/arangodb-helper/testagent/service/cluster"
+	"github.com/arangodb-helper/testagent/service/test"
+)
+
+// upgradeLoop upgrades all machines of the cluster to its upgrade image, one machine at a time,
+// while the tests keep running.
+// Machines with an agent are upgraded first, since the agency must be upgraded before
+// the dbservers & coordinators.
+// When all machines run the upgrade image, an event with the new version is recorded.
+// If that does not happen within the upgrade timeout, a failure is reported.
+func (c *chaosMonkey) upgradeLoop(ctx context.Context) {
+	defer c.upgrading.Done()
+
+	image := c.cluster.UpgradeImage()
+	c.mutex.Lock()
+	if c.upgradeDeadline.IsZero() && c.UpgradeTimeout > 0 {
+		c.upgradeDeadline = time.Now().Add(c.UpgradeTimeout)
+	}
+	deadline := c.upgradeDeadline
+	c.mutex.Unlock()
+
+	for {
+		m, left, err := c.nextMachineToUpgrade(image)
+		if err != nil {
+			c.log.Errorf("Failed to get machines: %v", err)
+		} else if m == nil {
+			machines, _ := c.cluster.Machines()
+			version := "unknown"
+			if len(machines) > 0 {
+				if v, err := c.arangodVersion(ctx, machines[0]); err == nil {
+					version = v
+				}
+			}
+			c.recordEvent(newEvent("Cluster upgraded to version %s (%s)", version, image))
+			c.log.Infof("All machines are upgraded to %s", image)
+			return
+		} else if !deadline.IsZero() && time.Now().After(deadline) {
+			c.recordEvent(newEvent("Upgrade of cluster to %s did not finish within %s (%d machines left)", image, c.UpgradeTimeout, left))
+			if c.listener != nil {
+				c.listener.ReportFailure(test.NewFailure("Chaos", "Upgrade of cluster to %s did not finish within %s (%d machines left)", image, c.UpgradeTimeout, left))
+			}
+			return
+		}
+		delay := c.Pacing().SkipPause
+		if m != nil && c.upgradeMachine(ctx, c.upgrade, m, left) {
+			delay = c.Pacing().MinPause
+		}
+		if !c.sleep(ctx, delay) {
+			return
+		}
+	}
+}
+
+// nextMachineToUpgrade returns the machine to upgrade next (nil if all machines run the given image)
+// and the number of machines that still have to be upgraded.
+// Machines with an agent come first, then all others (ordered by ID).
+func (c *chaosMonkey) nextMachineToUpgrade(image string) (cluster.Machine, int, error) {
+	machines, err := c.cluster.Machines()
+	if err != nil {
+		return nil, 0, maskAny(err)
+	}
+	var candidates MachineList
+	for _, m := range machines {
+		if m.ArangoImage() != image {
+			candidates = append(candidates, m)
+		}
+	}
+	if len(candidates) == 0 {
+		return nil, 0, nil
+	}
+	sort.Slice(candidates, func(i, j int) bool {
+		if a, b := candidates[i].HasAgent(), candidates[j].HasAgent(); a != b {
+			return a
+		}
+		return candidates[i].ID() < candidates[j].ID()
+	})
+	return candidates[0], len(candidates), nil
+}
+
+// upgradeMachine upgrades the given machine to the upgrade image of the cluster.
+// Before doing so, it first checks if restarting a machine is allowed on the current cluster state.
+// It returns true if the upgrade was attempted.
+func (c *chaosMonkey) upgradeMachine(ctx context.Context, action *chaosAction, m cluster.Machine, left int) bool {
+	image := c.cluster.UpgradeImage()
+	if _, _, err := c.checkAgencyReadyStatus(); err != nil {
+		c.log.Infof("Not all agents are ready (%s), so I cannot upgrade a machine now", err.Error())
+		action.skipped++
+		return false
+	}
+	_, notReadyDBServers, err := c.checkDBServerReadyStatus()
+	if err != nil || notReadyDBServers > 0 {
+		c.log.Infof("At least 1 dbserver is already down (%d down), so I cannot upgrade a machine now", notReadyDBServers)
+		action.skipped++
+		return false
+	}
+	readyCoordinatorMachines, _, _ := c.checkCoordinatorReadyStatus()
+	if len(readyCoordinatorMachines) <= 1 {
+		c.log.Infof("Only %d coordinator is ready, so I cannot upgrade a machine now", len(readyCoordinatorMachines))
+		action.skipped++
+		return false
+	}
+
+	f, ok := c.reserveFault(action, false, machineServers(m)...)
+	if !ok {
+		return false
+	}
+	c.faults.hold(f, fmt.Sprintf("Upgrade machine %s to %s", m.ID(), image))
+	oldVersion, err := c.arangodVersion(ctx, m)
+	if err != nil {
+		oldVersion = "unknown"
+	}
+	event := c.startEvent(action, m, "", "Upgrading machine %s (version %s) to %s", m.ID(), oldVersion, image)
+	defer c.releaseFault(f, event, f.servers)
+	if err := m.Upgrade(image); err != nil {
+		c.log.Errorf("Failed to upgrade machine: %v", err)
+		action.failures++
+		c.finishEvent(event, err)
+		return true
+	}
+	newVersion, err := c.arangodVersion(ctx, m)
+	if err != nil {
+		newVersion = "unknown"
+	}
+	action.succeeded++
+	c.updateEvent(event, func(e *Event) {
+		e.Description = fmt.Sprintf("Upgrading machine %s (version %s -> %s, %d machines left)", m.ID(), oldVersion, newVersion, left-1)
+	})
+	c.finishEvent(event, nil)
+	return true
+}
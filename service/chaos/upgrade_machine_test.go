@@ -0,0 +1,29 @@
+package chaos
+
+import (
+	"testing"
+
+	"github.com/arangodb-helper/testagent/service/cluster"
+)
+
+func TestNextMachineToUpgrade(t *testing.T) {
+	c, err := cluster.NewFakeCluster(5, 5, 3).Create(3, false)
+	if err != nil {
+		t.Fatalf("Failed to create fake cluster: %v", err)
+	}
+	cm, err := NewChaosMonkey(log, c, nil, ChaosMonkeyConfig{ChaosLevel: 2})
+	if err != nil {
+		t.Fatalf("Failed to create chaos monkey: %v", err)
+	}
+	m, left, err := cm.(*chaosMonkey).nextMachineToUpgrade("new")
+	if err != nil {
+		t.Fatalf("Failed to get next machine: %v", err)
+	}
+	if m == nil || !m.HasAgent() || m.ID() != "m0" || left != 5 {
+		t.Errorf("Expected agent machine m0 with 5 machines left, got %v, %d", m, left)
+	}
+	// The fake machines already run image "none"
+	if m, left, err := cm.(*chaosMonkey).nextMachineToUpgrade("none"); err != nil || m != nil || left != 0 {
+		t.Errorf("Expected no machine left, got %v, %d, %v", m, left, err)
+	}
+}
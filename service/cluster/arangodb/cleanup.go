@@ -0,0 +1,225 @@
+package arangodb
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"syscall"
+
+	"github.com/arangodb-helper/testagent/pkg/docker"
+	dc "github.com/fsouza/go-dockerclient"
+	logging "github.com/op/go-logging"
+)
+
+// LeftoverKind is the kind of a docker resource left behind by a cluster.
+type LeftoverKind string
+
+const (
+	LeftoverContainer = LeftoverKind("container") // Arangodb (starter) or network-blocker container
+	LeftoverServer    = LeftoverKind("server")    // Server container (created by a starter)
+	LeftoverVolume    = LeftoverKind("volume")    // Data volume of a machine
+)
+
+// Leftover is a docker resource that was created for a cluster.
+type Leftover struct {
+	Host      *docker.DockerHost
+	Kind      LeftoverKind
+	ID        string
+	Name      string
+	ClusterID string
+	RunID     string
+	Agent     string // Identity of the testagent process that created the resource (empty for older resources)
+	State     string // State of a container (e.g. running, exited), empty for volumes
+}
+
+// FindLeftovers lists all docker resources created for clusters on the given docker hosts.
+// Server containers are created by the starters without labels, so they are recognized
+// by their mount of a labelled data volume.
+// If clusterIDs is not empty, only resources of these clusters are listed.
+// If runIDs is not empty, only resources created by these runs are listed.
+func FindLeftovers(hosts []*docker.DockerHost, clusterIDs, runIDs []string) ([]Leftover, error) {
+	selected := func(labels map[string]string) bool {
+		id, found := labels[labelCluster]
+		return found && (len(clusterIDs) == 0 || slices.Contains(clusterIDs, id)) &&
+			(len(runIDs) == 0 || slices.Contains(runIDs, labels[labelRun]))
+	}
+	var result []Leftover
+	for _, h := range hosts {
+		volumes, err := h.Client.ListVolumes(dc.ListVolumesOptions{
+			Filters: map[string][]string{"label": {labelCluster}},
+		})
+		if err != nil {
+			return nil, maskAny(err)
+		}
+		var volumeList []Leftover
+		volumeByName := make(map[string]Leftover)
+		for _, v := range volumes {
+			if !selected(v.Labels) {
+				continue
+			}
+			l := Leftover{
+				Host:      h,
+				Kind:      LeftoverVolume,
+				ID:        v.Name,
+				Name:      v.Name,
+				ClusterID: v.Labels[labelCluster],
+				RunID:     v.Labels[labelRun],
+				Agent:     v.Labels[labelAgent],
+			}
+			volumeList = append(volumeList, l)
+			volumeByName[v.Name] = l
+		}
+
+		containers, err := h.Client.ListContainers(dc.ListContainersOptions{All: true})
+		if err != nil {
+			return nil, maskAny(err)
+		}
+		var serverList []Leftover
+		for _, c := range containers {
+			l := Leftover{
+				Host:  h,
+				ID:    c.ID,
+				Name:  containerName(c),
+				State: c.State,
+			}
+			if selected(c.Labels) {
+				l.Kind = LeftoverContainer
+				l.ClusterID = c.Labels[labelCluster]
+				l.RunID = c.Labels[labelRun]
+				l.Agent = c.Labels[labelAgent]
+				result = append(result, l)
+				continue
+			}
+			for _, mount := range c.Mounts {
+				if v, found := volumeByName[mount.Name]; found {
+					l.Kind = LeftoverServer
+					l.ClusterID = v.ClusterID
+					l.RunID = v.RunID
+					l.Agent = v.Agent
+					serverList = append(serverList, l)
+					break
+				}
+			}
+		}
+		// Servers after their starters (such that they are not restarted) & volumes last (once they are no longer used)
+		result = append(result, serverList...)
+		result = append(result, volumeList...)
+	}
+	return result, nil
+}
+
+// ClustersInUse returns the clusters (on the given docker hosts) that may still be used by a
+// running testagent, mapped to the reason why.
+// A cluster is in use when one of its resources was created by a testagent process that has
+// not (provably) exited. This includes clusters created by a testagent that did not record
+// its identity, since nothing can be told about those.
+func ClustersInUse(hosts []*docker.DockerHost) (map[string]string, error) {
+	all, err := FindLeftovers(hosts, nil, nil)
+	if err != nil {
+		return nil, maskAny(err)
+	}
+	checked := make(map[string]string)
+	result := make(map[string]string)
+	for _, l := range all {
+		if _, found := result[l.ClusterID]; found {
+			continue
+		}
+		reason, found := checked[l.Agent]
+		if !found {
+			var running bool
+			running, reason = agentRunning(hosts, l.Agent)
+			if !running {
+				reason = ""
+			}
+			checked[l.Agent] = reason
+		}
+		if reason != "" {
+			result[l.ClusterID] = reason
+		}
+	}
+	return result, nil
+}
+
+// containerIDPattern matches the hostname docker gives to a container (its short ID).
+var containerIDPattern = regexp.MustCompile(`^[0-9a-f]{12}$`)
+
+// agentRunning returns true if the testagent process with given identity (see agentIdentity)
+// may still be running, together with a description of why.
+// A process on the same host is looked up by its PID. A process in a docker container is
+// running as long as its container is running on one of the given docker hosts.
+func agentRunning(hosts []*docker.DockerHost, agent string) (bool, string) {
+	hostname, pidText, found := strings.Cut(agent, "/")
+	pid, err := strconv.Atoi(pidText)
+	if !found || err != nil {
+		return true, "created by a testagent that did not record its identity"
+	}
+	if own, _ := os.Hostname(); own == hostname {
+		if p, err := os.FindProcess(pid); err == nil {
+			if err := p.Signal(syscall.Signal(0)); err == nil || errors.Is(err, syscall.EPERM) {
+				return true, fmt.Sprintf("testagent process %d is running", pid)
+			}
+		}
+		return false, fmt.Sprintf("testagent process %d has exited", pid)
+	}
+	if !containerIDPattern.MatchString(hostname) {
+		return true, fmt.Sprintf("cannot check testagent %s on another host", agent)
+	}
+	for _, h := range hosts {
+		cont, err := h.Client.InspectContainerWithOptions(dc.InspectContainerOptions{ID: hostname})
+		if err != nil {
+			var noSuchContainer *dc.NoSuchContainer
+			if errors.As(err, &noSuchContainer) {
+				continue
+			}
+			return true, fmt.Sprintf("cannot inspect testagent container %s on %s: %v", hostname, h.Endpoint, err)
+		}
+		if cont.Config == nil || cont.Config.Hostname != hostname {
+			continue
+		}
+		if cont.State.Running {
+			return true, fmt.Sprintf("testagent container %s is running on %s", hostname, h.Endpoint)
+		}
+		return false, fmt.Sprintf("testagent container %s has exited", hostname)
+	}
+	// Containers of a testagent are typically removed once it exits
+	return false, fmt.Sprintf("testagent container %s no longer exists", hostname)
+}
+
+// RemoveLeftovers removes the given docker resources (in the order returned by FindLeftovers).
+// Failures are logged, such that as many resources as possible are removed.
+// The number of resources that could not be removed is returned.
+func RemoveLeftovers(log *logging.Logger, leftovers []Leftover) int {
+	failed := 0
+	for _, l := range leftovers {
+		var err error
+		switch l.Kind {
+		case LeftoverVolume:
+			err = l.Host.Client.RemoveVolume(l.ID)
+		default:
+			err = l.Host.Client.RemoveContainer(dc.RemoveContainerOptions{
+				ID:            l.ID,
+				Force:         true,
+				RemoveVolumes: true,
+			})
+		}
+		if err != nil {
+			log.Errorf("Failed to remove %s %s on %s: %v", l.Kind, l.Name, l.Host.Endpoint, err)
+			failed++
+		} else {
+			log.Infof("Removed %s %s on %s", l.Kind, l.Name, l.Host.Endpoint)
+		}
+	}
+	return failed
+}
+
+// containerName returns the name of the given container (without leading slash).
+func containerName(c dc.APIContainers) string {
+	if len(c.Names) == 0 {
+		return c.ID
+	}
+	return strings.TrimPrefix(c.Names[0], "/")
+}
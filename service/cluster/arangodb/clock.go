@@ -0,0 +1,50 @@
+package arangodb
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/arangodb-helper/testagent/service/cluster"
+)
+
+const (
+	// faketimePath is the file libfaketime reads the clock offset from in server containers
+	// (FAKETIME_TIMESTAMP_FILE, see Dockerfile.faketime).
+	faketimePath = "/etc/testagent-faketime"
+)
+
+// SkewClock shifts the time seen by the server with given role by the given offset (forward or backward).
+// This requires a server image that preloads libfaketime (see Dockerfile.faketime).
+func (m *arangodb) SkewClock(role cluster.ServerRole, offset time.Duration) error {
+	id, err := m.serverContainerID(role)
+	if err != nil {
+		return maskAny(err)
+	}
+	m.log.Infof("Skewing clock of %s container %s by %s", role, id, offset)
+	if err := m.writeFaketime(id, fmt.Sprintf("%+d", int64(offset/time.Second))); err != nil {
+		return maskAny(err)
+	}
+	return nil
+}
+
+// RestoreClock removes any clock skew from the server with given role.
+func (m *arangodb) RestoreClock(role cluster.ServerRole) error {
+	id, err := m.serverContainerID(role)
+	if err != nil {
+		return maskAny(err)
+	}
+	m.log.Infof("Restoring clock of %s container %s", role, id)
+	if err := m.writeFaketime(id, "+0"); err != nil {
+		return maskAny(err)
+	}
+	return nil
+}
+
+// writeFaketime writes the given libfaketime offset into the faketime file of the container with given ID.
+func (m *arangodb) writeFaketime(containerID, offset string) error {
+	script := fmt.Sprintf("test -w %s || { echo 'server image does not support clock skew (see Dockerfile.faketime)'; exit 1; }; echo '%s' > %s", faketimePath, offset, faketimePath)
+	if _, err := m.exec(containerID, "sh", "-c", script); err != nil {
+		return maskAny(err)
+	}
+	return nil
+}
@@ -4,9 +4,6 @@ import (
 	"crypto/rand"
 	"encoding/hex"
 	"fmt"
-	"net"
-	"net/url"
-	"strconv"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -24,19 +21,32 @@ const (
 )
 
 type ArangodbConfig struct {
-	MasterPort            int      // MasterPort for arangodb
-	ArangodbImage         string   // Docker image containing arangodb
-	ArangoImage           string   // Docker image containing arangod (can be empty)
-	NetworkBlockerImage   string   // Docker image container network-blocker
-	DockerHostIP          string   // IP of docker host
-	DockerEndpoints       []string // Endpoint used to reach the docker daemon(s)
-	DockerNetHost         bool     // If set, run containers with `--net=host`
-	DockerInterface       string   // Network Interface used to connect docker container to
-	Verbose               bool     // Turn on debug logging
-	Privileged            bool     // Start containers with `--privileged`
-	ReplicationVersion2   bool     // Use replication version 2
-	FailedWriteConcern403 bool     // Do not set option `--cluster.failed-write-concern-status-code` to `503` for all DB servers
-	ChaosLevel            int      // Level of chaos to use. An integer from 0 to 4. 0 - no chaos. 4 - maximum chaos.
+	MasterPort            int                    // MasterPort for arangodb
+	ArangodbImage         string                 // Docker image containing arangodb
+	ArangoImage           string                 // Docker image containing arangod (can be empty)
+	UpgradeImage          string                 // Docker image containing arangod that all machines are gradually upgraded to (can be empty)
+	NetworkBlockerImage   string                 // Docker image container network-blocker
+	DockerHostIP          string                 // IP of docker host
+	DockerEndpoints       []string               // Endpoint used to reach the docker daemon(s)
+	DockerNetHost         bool                   // If set, run containers with `--net=host`
+	DockerInterface       string                 // Network Interface used to connect docker container to
+	Verbose               bool                   // Turn on debug logging
+	Privileged            bool                   // Start containers with `--privileged`
+	ReplicationVersion2   bool                   // Use replication version 2
+	FailedWriteConcern403 bool                   // Do not set option `--cluster.failed-write-concern-status-code` to `503` for all DB servers
+	ChaosLevel            int                    // Level of chaos to use. An integer from 0 to 4. 0 - no chaos. 4 - maximum chaos.
+	DataVolumeSize        int64                  // If set, the data volume of each machine is a tmpfs of this size (in bytes)
+	Mode                  cluster.DeploymentMode // Deployment mode passed to the starters (defaults to cluster)
+	SSLKeyFile            string                 // If set, servers use TLS with this keyfile (path must be the same on the docker hosts & in the testagent)
+	SSLAutoKey            bool                   // If set, servers use TLS with a self-signed certificate generated by the starter
+	JWTSecretFile         string                 // If set, servers require authentication with the JWT secret in this file (path must be the same on the docker hosts & in the testagent)
+	StateDir              string                 // If set, the topology of the cluster is stored in this directory, such that the cluster can be resumed
+	RunID                 string                 // ID of this testagent run, used to label all docker resources (generated when empty)
+}
+
+// secure returns true if the starters & servers use TLS.
+func (c ArangodbConfig) secure() bool {
+	return c.SSLKeyFile != "" || c.SSLAutoKey
 }
 
 // arangodbClusterBuilder implements a ClusterBuilder using arangodb.
@@ -44,6 +54,7 @@ type arangodbClusterBuilder struct {
 	log            *logging.Logger
 	collectMetrics bool
 	metricsDir     string
+	authHeader     string // Authorization header derived from the JWT secret (empty without authentication)
 	ArangodbConfig
 }
 
@@ -51,6 +62,7 @@ type arangodbCluster struct {
 	ArangodbConfig
 
 	mutex            sync.Mutex
+	stateMutex       sync.Mutex // Serializes writes of the state file
 	log              *logging.Logger
 	collectMetrics   bool
 	metricsDir       string
@@ -58,9 +70,11 @@ type arangodbCluster struct {
 	id               string
 	agencySize       int
 	forceOneShard    bool
+	authHeader       string
 	machines         []*arangodb
 	lastMachineIndex int32
 	ports            portSpace
+	agentVolume      string // Volume marking a resumed cluster as in use by this testagent (see createAgentVolume)
 }
 
 // NewArangodbClusterBuilder creates a new ClusterBuilder using arangodb.
@@ -77,16 +91,47 @@ func NewArangodbClusterBuilder(log *logging.Logger, metricsDir string, collectMe
 	if len(config.DockerEndpoints) == 0 {
 		return nil, maskAny(fmt.Errorf("DockerEndpoints missing"))
 	}
+	if config.Mode == "" {
+		config.Mode = cluster.DeploymentModeCluster
+	}
+	if err := config.Mode.Validate(); err != nil {
+		return nil, maskAny(err)
+	}
+	if config.SSLKeyFile != "" && config.SSLAutoKey {
+		return nil, maskAny(fmt.Errorf("SSLKeyFile and SSLAutoKey cannot be used together"))
+	}
+	if config.RunID == "" {
+		b := make([]byte, 4)
+		if _, err := rand.Read(b); err != nil {
+			return nil, maskAny(err)
+		}
+		config.RunID = hex.EncodeToString(b)
+	}
+	var authHeader string
+	if config.JWTSecretFile != "" {
+		secret, err := cluster.ReadJWTSecret(config.JWTSecretFile)
+		if err != nil {
+			return nil, maskAny(fmt.Errorf("Cannot read JWT secret: %v", err))
+		}
+		if secret == "" {
+			return nil, maskAny(fmt.Errorf("JWT secret file '%s' is empty", config.JWTSecretFile))
+		}
+		if authHeader, err = cluster.JWTAuthorizationHeader(secret); err != nil {
+			return nil, maskAny(err)
+		}
+	}
 	return &arangodbClusterBuilder{
 		log:            log,
 		collectMetrics: collectMetrics,
 		metricsDir:     metricsDir,
+		authHeader:     authHeader,
 		ArangodbConfig: config,
 	}, nil
 }
 
 // Create creates and starts a new cluster.
-// The number of "machines" created equals the given agency size.
+// The number of "machines" created equals the given agency size, except in single mode
+// where only 1 machine is created.
 // This function returns when the cluster is operational (or an error occurs)
 func (cb *arangodbClusterBuilder) Create(agencySize int, forceOneShard bool) (cluster.Cluster, error) {
 	// Create docker hosts
@@ -101,6 +146,7 @@ func (cb *arangodbClusterBuilder) Create(agencySize int, forceOneShard bool) (cl
 		return nil, maskAny(err)
 	}
 	id := hex.EncodeToString(b)
+	cb.log.Infof("Creating cluster %s (run %s)", id, cb.RunID)
 
 	// Instantiate
 	c := &arangodbCluster{
@@ -110,6 +156,8 @@ func (cb *arangodbClusterBuilder) Create(agencySize int, forceOneShard bool) (cl
 		ArangodbConfig:   cb.ArangodbConfig,
 		dockerHosts:      dockerHosts,
 		agencySize:       agencySize,
+		forceOneShard:    forceOneShard,
+		authHeader:       cb.authHeader,
 		id:               id,
 		lastMachineIndex: 0,
 	}
@@ -120,7 +168,7 @@ func (cb *arangodbClusterBuilder) Create(agencySize int, forceOneShard bool) (cl
 		return nil, maskAny(err)
 	}
 	// Start arangodb slave several times
-	if agencySize > 1 {
+	if agencySize > 1 && cb.Mode != cluster.DeploymentModeSingle {
 		g := errgroup.Group{}
 		for i := 1; i < agencySize; i++ {
 			g.Go(func() error {
@@ -135,6 +183,7 @@ func (cb *arangodbClusterBuilder) Create(agencySize int, forceOneShard bool) (cl
 			return nil, maskAny(err)
 		}
 	}
+	c.saveState()
 
 	return c, nil
 }
@@ -167,11 +216,38 @@ func (c *arangodbCluster) ID() string {
 	return c.id
 }
 
+// Mode returns the deployment mode of this cluster
+func (c *arangodbCluster) Mode() cluster.DeploymentMode {
+	return c.ArangodbConfig.Mode
+}
+
+// Managed returns true, since all machines & servers are created by this cluster.
+func (c *arangodbCluster) Managed() bool {
+	return true
+}
+
+// VolatileData returns true if the data volumes of the machines are a tmpfs, which loses its
+// content when no container uses it anymore.
+func (c *arangodbCluster) VolatileData() bool {
+	return c.DataVolumeSize > 0
+}
+
+// AuthorizationHeader returns the value of the Authorization header needed to talk to the servers
+// of this cluster, or empty if the servers do not require authentication
+func (c *arangodbCluster) AuthorizationHeader() string {
+	return c.authHeader
+}
+
 // ArangoImage returns the arango (database) docker image used on this cluster
 func (c *arangodbCluster) ArangoImage() string {
 	return c.ArangodbConfig.ArangoImage
 }
 
+// UpgradeImage returns the arango (database) docker image that machines are upgraded to, or empty if no upgrade is configured
+func (c *arangodbCluster) UpgradeImage() string {
+	return c.ArangodbConfig.UpgradeImage
+}
+
 // Machines returns all machines in the cluster
 func (c *arangodbCluster) Machines() ([]cluster.Machine, error) {
 	c.mutex.Lock()
@@ -186,11 +262,16 @@ func (c *arangodbCluster) Machines() ([]cluster.Machine, error) {
 
 // Add adds a single machine to the cluster
 func (c *arangodbCluster) Add() (cluster.Machine, error) {
+	if c.ArangodbConfig.Mode == cluster.DeploymentModeSingle {
+		return nil, maskAny(fmt.Errorf("Cannot add machines to a single server deployment"))
+	}
+
 	// Create & start machine
 	m, err := c.add()
 	if err != nil {
 		return nil, maskAny(err)
 	}
+	c.saveState()
 
 	// Wait until all servers are reachable
 	ma := m.(*arangodb)
@@ -232,6 +313,8 @@ func (c *arangodbCluster) Destroy() error {
 	if err := g.Wait(); err != nil {
 		return maskAny(err)
 	}
+	c.removeAgentVolume()
+	c.removeState()
 	return nil
 }
 
@@ -240,11 +323,69 @@ func (c *arangodbCluster) add() (cluster.Machine, error) {
 	index := int(atomic.AddInt32(&c.lastMachineIndex, 1) - 1)
 
 	// Create machine
-	m, err := c.createMachine(index)
+	m, err := c.createMachine(index, nil)
+	if err != nil {
+		return nil, maskAny(err)
+	}
+
+	// Launch machine
+	if err := c.launch(m); err != nil {
+		return nil, maskAny(err)
+	}
+
+	return m, nil
+}
+
+// Replace permanently destroys the given machine (as if its hardware is lost) and brings up
+// a new machine that takes over its place in the cluster, including its agent (if any).
+// The new machine uses the starter's recovery procedure, which requires the new starter
+// to run on the same address as the lost one.
+func (c *arangodbCluster) Replace(m cluster.Machine) (cluster.Machine, error) {
+	old, ok := m.(*arangodb)
+	if !ok {
+		return nil, maskAny(fmt.Errorf("Machine %s is not part of this cluster", m.ID()))
+	}
+	if !old.ReplaceAllowed() {
+		return nil, maskAny(fmt.Errorf("Machine %s cannot be replaced", m.ID()))
+	}
+
+	// Create new index
+	index := int(atomic.AddInt32(&c.lastMachineIndex, 1) - 1)
+
+	// Create replacement machine (takes over the port of the old machine)
+	nm, err := c.createMachine(index, old)
 	if err != nil {
 		return nil, maskAny(err)
 	}
 
+	// Lose the old machine
+	if err := old.lose(); err != nil {
+		return nil, maskAny(err)
+	}
+
+	// Launch replacement machine
+	if err := c.launch(nm); err != nil {
+		return nil, maskAny(err)
+	}
+	c.saveState()
+
+	// Wait until all servers are reachable
+	if err := nm.waitUntilServersReady(c.log, serverReadyTimeout); err != nil {
+		return nil, maskAny(err)
+	}
+
+	// Start metrics collection
+	if c.collectMetrics {
+		if err := nm.startMetricsCollectionFromAllContainers(); err != nil {
+			return nil, maskAny(err)
+		}
+	}
+
+	return nm, nil
+}
+
+// launch registers & starts the given (newly created) machine.
+func (c *arangodbCluster) launch(m *arangodb) error {
 	// Register machine
 	c.mutex.Lock()
 	c.machines = append(c.machines, m)
@@ -252,28 +393,28 @@ func (c *arangodbCluster) add() (cluster.Machine, error) {
 
 	// Pull arangodb image
 	if err := m.pullImageIfNeeded(c.ArangodbConfig.ArangodbImage); err != nil {
-		return nil, maskAny(err)
+		return maskAny(err)
 	}
 
 	// Pull network-block image
 	if err := m.pullImageIfNeeded(c.ArangodbConfig.NetworkBlockerImage); err != nil {
-		return nil, maskAny(err)
+		return maskAny(err)
 	}
 
 	// Start network blocker
 	if err := m.startNetworkBlocker(c.ArangodbConfig.NetworkBlockerImage); err != nil {
-		return nil, maskAny(err)
+		return maskAny(err)
 	}
 
 	// Start machine
 	if err := m.start(); err != nil {
-		return nil, maskAny(err)
+		return maskAny(err)
 	}
 
 	// Start watchdog
 	m.watchdog()
 
-	return m, nil
+	return nil
 }
 
 // masterArangodbClient creates a client for the master arangodb starter.
@@ -285,14 +426,7 @@ func (c *arangodbCluster) masterArangodbClient() (arangostarter.API, error) {
 		return nil, maskAny(fmt.Errorf("No master found"))
 	}
 	master := c.machines[0]
-	masterIP := master.dockerHost.IP
-	masterPort := master.arangodbPort
-
-	ep := url.URL{
-		Scheme: "http",
-		Host:   net.JoinHostPort(masterIP, strconv.Itoa(masterPort)),
-	}
-	client, err := arangostarter.NewArangoStarterClient(ep)
+	client, err := arangostarter.NewArangoStarterClient(master.StarterEndpoint())
 	if err != nil {
 		return nil, maskAny(err)
 	}
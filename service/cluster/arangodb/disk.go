@@ -0,0 +1,92 @@
+package arangodb
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/arangodb-helper/testagent/service/cluster"
+	dc "github.com/fsouza/go-dockerclient"
+)
+
+const (
+	// fillDiskPath is the file written on the data volume by FillDisk.
+	fillDiskPath = "/data/testagent-fill"
+)
+
+// DiskUsage returns the size & free space of the data volume of this machine.
+func (m *arangodb) DiskUsage() (cluster.DiskUsage, error) {
+	output, err := m.exec(m.containerID, "df", "-Pk", "/data")
+	if err != nil {
+		return cluster.DiskUsage{}, maskAny(err)
+	}
+	// Filesystem 1024-blocks Used Available Capacity Mounted on
+	lines := strings.Split(strings.TrimSpace(output), "\n")
+	if len(lines) < 2 {
+		return cluster.DiskUsage{}, maskAny(fmt.Errorf("unexpected output of df: %s", output))
+	}
+	fields := strings.Fields(lines[len(lines)-1])
+	if len(fields) < 4 {
+		return cluster.DiskUsage{}, maskAny(fmt.Errorf("unexpected output of df: %s", output))
+	}
+	total, err := strconv.ParseInt(fields[1], 10, 64)
+	if err != nil {
+		return cluster.DiskUsage{}, maskAny(err)
+	}
+	free, err := strconv.ParseInt(fields[3], 10, 64)
+	if err != nil {
+		return cluster.DiskUsage{}, maskAny(err)
+	}
+	return cluster.DiskUsage{Total: total * 1024, Free: free * 1024}, nil
+}
+
+// FillDisk writes a file of given size (in bytes) on the data volume of this machine.
+func (m *arangodb) FillDisk(size int64) error {
+	mb := size / (1024 * 1024)
+	m.log.Infof("Filling data volume of %s with %dMB", m.ID(), mb)
+	script := fmt.Sprintf("fallocate -l %d %s 2>/dev/null || dd if=/dev/zero of=%s bs=1048576 count=%d 2>/dev/null", size, fillDiskPath, fillDiskPath, mb)
+	if _, err := m.exec(m.containerID, "sh", "-c", script); err != nil {
+		return maskAny(err)
+	}
+	return nil
+}
+
+// FreeDisk removes the file written by FillDisk.
+func (m *arangodb) FreeDisk() error {
+	m.log.Infof("Freeing data volume of %s", m.ID())
+	if _, err := m.exec(m.containerID, "rm", "-f", fillDiskPath); err != nil {
+		return maskAny(err)
+	}
+	return nil
+}
+
+// exec runs the given command in the container with given ID (e.g. the arangodb (starter) container
+// of this machine) and returns its output.
+func (m *arangodb) exec(containerID string, cmd ...string) (string, error) {
+	client := m.dockerHost.Client
+	e, err := client.CreateExec(dc.CreateExecOptions{
+		Container:    containerID,
+		Cmd:          cmd,
+		AttachStdout: true,
+		AttachStderr: true,
+	})
+	if err != nil {
+		return "", maskAny(err)
+	}
+	var output bytes.Buffer
+	if err := client.StartExec(e.ID, dc.StartExecOptions{
+		OutputStream: &output,
+		ErrorStream:  &output,
+	}); err != nil {
+		return "", maskAny(err)
+	}
+	info, err := client.InspectExec(e.ID)
+	if err != nil {
+		return "", maskAny(err)
+	}
+	if info.ExitCode != 0 {
+		return "", maskAny(fmt.Errorf("'%s' failed with exit code %d: %s", strings.Join(cmd, " "), info.ExitCode, output.String()))
+	}
+	return output.String(), nil
+}
@@ -0,0 +1,68 @@
+package arangodb
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"os"
+
+	dc "github.com/fsouza/go-dockerclient"
+)
+
+const (
+	// Labels of all docker containers & volumes created for a cluster (see FindLeftovers)
+	labelCluster = "com.arangodb.testagent.cluster" // ID of the cluster
+	labelRun     = "com.arangodb.testagent.run"     // ID of the testagent run that created the resource
+	labelMachine = "com.arangodb.testagent.machine" // ID of the machine
+	labelAgent   = "com.arangodb.testagent.agent"   // Hostname & PID of the testagent process that created the resource
+)
+
+// agentIdentity identifies this testagent process as <hostname>/<pid>.
+// Inside a docker container the hostname is the (short) ID of the container.
+var agentIdentity = func() string {
+	hostname, _ := os.Hostname()
+	return fmt.Sprintf("%s/%d", hostname, os.Getpid())
+}()
+
+// machineLabels returns the labels of all docker resources of the machine with given ID.
+func (c *arangodbCluster) machineLabels(machineID string) map[string]string {
+	return map[string]string{
+		labelCluster: c.id,
+		labelRun:     c.RunID,
+		labelMachine: machineID,
+		labelAgent:   agentIdentity,
+	}
+}
+
+// createAgentVolume creates an empty volume labelled with the cluster & this testagent process.
+// The containers of a resumed cluster carry the identity of the testagent that created them,
+// so this volume tells the cleanup command that the cluster is in use again.
+func (c *arangodbCluster) createAgentVolume() error {
+	b := make([]byte, 4)
+	if _, err := rand.Read(b); err != nil {
+		return maskAny(err)
+	}
+	name := fmt.Sprintf("arangodb-%s-agent-%s", c.id, hex.EncodeToString(b))
+	if _, err := c.dockerHosts[0].Client.CreateVolume(dc.CreateVolumeOptions{
+		Name: name,
+		Labels: map[string]string{
+			labelCluster: c.id,
+			labelRun:     c.RunID,
+			labelAgent:   agentIdentity,
+		},
+	}); err != nil {
+		return maskAny(err)
+	}
+	c.agentVolume = name
+	return nil
+}
+
+// removeAgentVolume removes the volume created by createAgentVolume (if any).
+func (c *arangodbCluster) removeAgentVolume() {
+	if c.agentVolume == "" {
+		return
+	}
+	if err := c.dockerHosts[0].Client.RemoveVolume(c.agentVolume); err != nil {
+		c.log.Warningf("Failed to remove volume %s: %v", c.agentVolume, err)
+	}
+}
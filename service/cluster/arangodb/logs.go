@@ -64,6 +64,19 @@ func (m *arangodb) CollectCoordinatorLogs(w io.Writer) error {
 	return nil
 }
 
+// CollectSingleLogs collects recent logs from the single server and writes them to the given writer.
+func (m *arangodb) CollectSingleLogs(w io.Writer) error {
+	if err := m.updateServerInfo(); err != nil {
+		return maskAny(err)
+	}
+	if m.HasSingle() {
+		if err := m.collectServerLogs(w, "single"); err != nil && errors.Cause(err) != io.EOF {
+			return maskAny(err)
+		}
+	}
+	return nil
+}
+
 // collectContainerLogs collects recent logs from the container with given ID and writes them to the given writer.
 func (m *arangodb) collectContainerLogs(w io.Writer, containerID string) error {
 	since := time.Now().Add(-time.Minute * 10)
@@ -86,10 +99,12 @@ func (m *arangodb) collectContainerLogs(w io.Writer, containerID string) error {
 
 // collectServerLogs collects recent logs from the container with given ID and writes them to the given writer.
 func (m *arangodb) collectServerLogs(w io.Writer, server string) error {
-	addr := fmt.Sprintf("http://%s:%d/logs/%s", m.dockerHost.IP, m.arangodbPort, server)
+	ep := m.StarterEndpoint()
+	ep.Path = "/logs/" + server
+	addr := ep.String()
 	m.log.Debugf("fetching logs from %s", addr)
 
-	resp, err := http.Get(addr)
+	resp, err := m.get(&http.Client{Transport: serverTransport}, addr)
 	if err != nil {
 		m.log.Debugf("failed to fetching logs from %s: %v", addr, err)
 		return maskAny(err)
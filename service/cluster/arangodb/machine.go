@@ -1,6 +1,8 @@
 package arangodb
 
 import (
+	"archive/tar"
+	"bytes"
 	"context"
 	"crypto/rand"
 	"encoding/hex"
@@ -11,6 +13,7 @@ import (
 	"os"
 	"strconv"
 	"strings"
+	"sync"
 	"sync/atomic"
 	"time"
 
@@ -32,6 +35,11 @@ const (
 	testStatusTimeout    = time.Second * 20
 )
 
+var (
+	// serverTransport is shared by all requests to servers & starters, such that connections are reused.
+	serverTransport = cluster.NewHTTPTransport()
+)
+
 type arangodb struct {
 	machineID                  string
 	dockerHost                 *docker.DockerHost
@@ -40,8 +48,11 @@ type arangodb struct {
 	log                        *logging.Logger
 	createOptions              dc.CreateContainerOptions
 	index                      int
+	mode                       cluster.DeploymentMode
 	arangodbPort               int
 	nwBlockerPort              int
+	secure                     bool   // If set, the starter & servers use TLS
+	authHeader                 string // Authorization header used to talk to the servers (empty without authentication)
 	createdAt                  time.Time
 	startedAt                  time.Time
 	state                      cluster.MachineState
@@ -49,24 +60,46 @@ type arangodb struct {
 	containerID                string // ID of arangodb container
 	nwBlockerContainerID       string // ID of network-blocker container
 	nwBlocker                  networkblocker.API
+	nwFeaturesMutex            sync.Mutex
+	nwFeatures                 *cluster.NetworkFeatures // Features supported by the network-blocker (nil until asked)
 	hasAgent                   bool
 	agentPort                  int
 	agentContainerID           string
 	agentContainerIP           string
+	agentSecure                bool
 	lastAgentReadyStatus       int32
 	coordinatorPort            int
 	coordinatorContainerID     string
 	coordinatorContainerIP     string
+	coordinatorSecure          bool
 	lastCoordinatorReadyStatus int32
 	dbserverPort               int
 	dbserverContainerID        string
 	dbserverContainerIP        string
+	dbserverSecure             bool
 	lastDBServerReadyStatus    int32
+	hasCoordinator             bool
+	hasDBServer                bool
+	hasSingle                  bool
+	singlePort                 int
+	singleContainerID          string
+	singleContainerIP          string
+	singleSecure               bool
+	lastSingleReadyStatus      int32
 	destroyCallback            func(*arangodb)
+	changeCallback             func(*arangodb) // Called when the containers or image of the machine changed
+	arangoImage                string          // Docker image containing arangod used on this machine (can be empty)
+	recoveryAddress            string          // Address of the lost starter this machine replaces (empty if not a replacement)
+	resourcesMutex             sync.Mutex
+	originalResources          map[cluster.ServerRole]containerResources // Limits of server containers before LimitResources
 }
 
 // ID returns a unique identifier for this machine
 func (m *arangodb) ID() string {
+	if !m.mode.IsCluster() {
+		// Not every machine has a single server, so use the port of the starter
+		return fmt.Sprintf("m%d-%s:%d", m.index, m.dockerHost.IP, m.arangodbPort)
+	}
 	return fmt.Sprintf("m%d-%s:%d", m.index, m.dockerHost.IP, m.coordinatorPort)
 }
 
@@ -90,10 +123,25 @@ func (m *arangodb) HasAgent() bool {
 	return m.hasAgent
 }
 
+// HasDBServer returns true if there is a dbserver on this machine
+func (m *arangodb) HasDBServer() bool {
+	return m.hasDBServer
+}
+
+// HasCoordinator returns true if there is a coordinator on this machine
+func (m *arangodb) HasCoordinator() bool {
+	return m.hasCoordinator
+}
+
+// HasSingle returns true if there is a single server on this machine
+func (m *arangodb) HasSingle() bool {
+	return m.hasSingle
+}
+
 // AgentURL returns the URL of the agent on this machine.
 func (m *arangodb) AgentURL() url.URL {
 	return url.URL{
-		Scheme: "http",
+		Scheme: scheme(m.agentSecure),
 		Host:   net.JoinHostPort(m.dockerHost.IP, strconv.Itoa(m.agentPort)),
 	}
 }
@@ -101,7 +149,7 @@ func (m *arangodb) AgentURL() url.URL {
 // DBServerURL returns the URL of the DBServer on this machine.
 func (m *arangodb) DBServerURL() url.URL {
 	return url.URL{
-		Scheme: "http",
+		Scheme: scheme(m.dbserverSecure),
 		Host:   net.JoinHostPort(m.dockerHost.IP, strconv.Itoa(m.dbserverPort)),
 	}
 }
@@ -109,11 +157,27 @@ func (m *arangodb) DBServerURL() url.URL {
 // CoordinatorURL returns the URL of the Coordinator on this machine.
 func (m *arangodb) CoordinatorURL() url.URL {
 	return url.URL{
-		Scheme: "http",
+		Scheme: scheme(m.coordinatorSecure),
 		Host:   net.JoinHostPort(m.dockerHost.IP, strconv.Itoa(m.coordinatorPort)),
 	}
 }
 
+// SingleURL returns the URL of the single server on this machine.
+func (m *arangodb) SingleURL() url.URL {
+	return url.URL{
+		Scheme: scheme(m.singleSecure),
+		Host:   net.JoinHostPort(m.dockerHost.IP, strconv.Itoa(m.singlePort)),
+	}
+}
+
+// scheme returns the URL scheme used to reach a server (or starter).
+func scheme(secure bool) string {
+	if secure {
+		return "https"
+	}
+	return "http"
+}
+
 // TestAgentStatus checks if the agent on this machine is ready (with a reasonable timeout). If returns nil on ready, error on not ready.
 func (m *arangodb) TestAgentStatus() error {
 	return maskAny(m.testInstance(nil, m.AgentURL(), "agent", testStatusTimeout, &m.lastAgentReadyStatus))
@@ -129,6 +193,14 @@ func (m *arangodb) TestCoordinatorStatus() error {
 	return maskAny(m.testInstance(nil, m.CoordinatorURL(), "coordinator", testStatusTimeout, &m.lastCoordinatorReadyStatus))
 }
 
+// TestSingleStatus checks if the single server on this machine is ready (with a reasonable timeout). If returns nil on ready, error on not ready.
+func (m *arangodb) TestSingleStatus() error {
+	if !m.HasSingle() {
+		return maskAny(fmt.Errorf("no single server on this machine"))
+	}
+	return maskAny(m.testInstance(nil, m.SingleURL(), "single", testStatusTimeout, &m.lastSingleReadyStatus))
+}
+
 // LastAgentReadyStatus returns true if the last known agent ready check succeeded.
 func (m *arangodb) LastAgentReadyStatus() bool {
 	return m.lastAgentReadyStatus != 0
@@ -144,6 +216,11 @@ func (m *arangodb) LastCoordinatorReadyStatus() bool {
 	return m.lastCoordinatorReadyStatus != 0
 }
 
+// LastSingleReadyStatus returns true if the last known single server ready check succeeded.
+func (m *arangodb) LastSingleReadyStatus() bool {
+	return m.lastSingleReadyStatus != 0
+}
+
 // Perform a graceful restart of the agent. This function does NOT wait until the agent is ready again.
 func (m *arangodb) RestartAgent() error {
 	if err := m.updateServerInfo(); err != nil {
@@ -192,6 +269,25 @@ func (m *arangodb) RestartCoordinator() error {
 	return nil
 }
 
+// Perform a graceful restart of the single server. This function does NOT wait until the single server is ready again.
+func (m *arangodb) RestartSingle() error {
+	if err := m.updateServerInfo(); err != nil {
+		return maskAny(err)
+	}
+	if !m.HasSingle() {
+		return maskAny(fmt.Errorf("no single server on this machine"))
+	}
+	if err := m.dockerHost.Client.StopContainer(m.singleContainerID, stopContainerTimeout); err != nil {
+		return maskAny(err)
+	}
+	if m.collectMetrics {
+		if err := m.startMetricsCollectionFromSingle(); err != nil {
+			return maskAny(err)
+		}
+	}
+	return nil
+}
+
 // Perform a forced restart of the agent. This function does NOT wait until the agent is ready again.
 func (m *arangodb) KillAgent() error {
 	if err := m.updateServerInfo(); err != nil {
@@ -240,6 +336,125 @@ func (m *arangodb) KillCoordinator() error {
 	return nil
 }
 
+// Perform a forced restart of the single server. This function does NOT wait until the single server is ready again.
+func (m *arangodb) KillSingle() error {
+	if err := m.updateServerInfo(); err != nil {
+		return maskAny(err)
+	}
+	if !m.HasSingle() {
+		return maskAny(fmt.Errorf("no single server on this machine"))
+	}
+	if err := m.dockerHost.Client.KillContainer(dc.KillContainerOptions{ID: m.singleContainerID}); err != nil {
+		return maskAny(err)
+	}
+	if m.collectMetrics {
+		if err := m.startMetricsCollectionFromSingle(); err != nil {
+			return maskAny(err)
+		}
+	}
+	return nil
+}
+
+// Freeze the agent process (docker pause). The agent stays frozen until ResumeAgent is called.
+func (m *arangodb) PauseAgent() error {
+	if !m.HasAgent() {
+		return maskAny(fmt.Errorf("no agent on this machine"))
+	}
+	if err := m.updateServerInfo(); err != nil {
+		return maskAny(err)
+	}
+	if err := m.dockerHost.Client.PauseContainer(m.agentContainerID); err != nil {
+		return maskAny(err)
+	}
+	return nil
+}
+
+// Freeze the dbserver process (docker pause). The dbserver stays frozen until ResumeDBServer is called.
+func (m *arangodb) PauseDBServer() error {
+	if !m.HasDBServer() {
+		return maskAny(fmt.Errorf("no dbserver on this machine"))
+	}
+	if err := m.updateServerInfo(); err != nil {
+		return maskAny(err)
+	}
+	if err := m.dockerHost.Client.PauseContainer(m.dbserverContainerID); err != nil {
+		return maskAny(err)
+	}
+	return nil
+}
+
+// Freeze the coordinator process (docker pause). The coordinator stays frozen until ResumeCoordinator is called.
+func (m *arangodb) PauseCoordinator() error {
+	if !m.HasCoordinator() {
+		return maskAny(fmt.Errorf("no coordinator on this machine"))
+	}
+	if err := m.updateServerInfo(); err != nil {
+		return maskAny(err)
+	}
+	if err := m.dockerHost.Client.PauseContainer(m.coordinatorContainerID); err != nil {
+		return maskAny(err)
+	}
+	return nil
+}
+
+// Freeze the single server process (docker pause). The single server stays frozen until ResumeSingle is called.
+func (m *arangodb) PauseSingle() error {
+	if !m.HasSingle() {
+		return maskAny(fmt.Errorf("no single server on this machine"))
+	}
+	if err := m.updateServerInfo(); err != nil {
+		return maskAny(err)
+	}
+	if err := m.dockerHost.Client.PauseContainer(m.singleContainerID); err != nil {
+		return maskAny(err)
+	}
+	return nil
+}
+
+// Continue the frozen agent process (docker unpause).
+func (m *arangodb) ResumeAgent() error {
+	if !m.HasAgent() {
+		return maskAny(fmt.Errorf("no agent on this machine"))
+	}
+	if err := m.dockerHost.Client.UnpauseContainer(m.agentContainerID); err != nil {
+		return maskAny(err)
+	}
+	return nil
+}
+
+// Continue the frozen dbserver process (docker unpause).
+func (m *arangodb) ResumeDBServer() error {
+	if !m.HasDBServer() {
+		return maskAny(fmt.Errorf("no dbserver on this machine"))
+	}
+	if err := m.dockerHost.Client.UnpauseContainer(m.dbserverContainerID); err != nil {
+		return maskAny(err)
+	}
+	return nil
+}
+
+// Continue the frozen coordinator process (docker unpause).
+func (m *arangodb) ResumeCoordinator() error {
+	if !m.HasCoordinator() {
+		return maskAny(fmt.Errorf("no coordinator on this machine"))
+	}
+	if err := m.dockerHost.Client.UnpauseContainer(m.coordinatorContainerID); err != nil {
+		return maskAny(err)
+	}
+	return nil
+}
+
+// Continue the frozen single server process (docker unpause).
+func (m *arangodb) ResumeSingle() error {
+	if !m.HasSingle() {
+		return maskAny(fmt.Errorf("no single server on this machine"))
+	}
+	if err := m.dockerHost.Client.UnpauseContainer(m.singleContainerID); err != nil {
+		return maskAny(err)
+	}
+	return nil
+}
+
 // Reboot performs a graceful reboot of the machine
 func (m *arangodb) Reboot() error {
 	// Stop the arangodb container  (it will stop the servers )
@@ -261,6 +476,79 @@ func (m *arangodb) Reboot() error {
 	if err := m.start(); err != nil {
 		return maskAny(err)
 	}
+	m.changeCallback(m)
+
+	// Wait for servers ready
+	if err := m.waitUntilServersReady(m.log, serverReadyTimeout); err != nil {
+		return maskAny(err)
+	}
+
+	// Start metrics collection
+	if m.collectMetrics {
+		if err := m.startMetricsCollectionFromAllContainers(); err != nil {
+			return maskAny(err)
+		}
+	}
+
+	return nil
+}
+
+// ArangoImage returns the arango (database) docker image currently used on this machine
+func (m *arangodb) ArangoImage() string {
+	return m.arangoImage
+}
+
+// Upgrade restarts the machine with the given arango (database) docker image and runs the database auto-upgrade.
+// This function returns when all servers are ready again.
+// If the servers cannot be upgraded, the machine is restarted with its previous image.
+func (m *arangodb) Upgrade(image string) error {
+	if image == m.arangoImage {
+		return nil
+	}
+
+	// Pull new image first to keep the downtime short
+	if err := m.pullImageIfNeeded(image); err != nil {
+		return maskAny(err)
+	}
+
+	// Stop arangodb gracefully (it will stop the servers)
+	if err := m.stop(false); err != nil {
+		return maskAny(err)
+	}
+
+	// Relaunch with the new image
+	oldArgs := m.createOptions.Config.Cmd
+	var args []string
+	for _, arg := range oldArgs {
+		if !strings.HasPrefix(arg, "--docker.image=") {
+			args = append(args, arg)
+		}
+	}
+	args = append(args, fmt.Sprintf("--docker.image=%s", image))
+	m.log.Infof("Upgrading machine %s to %s", m.machineID, image)
+	if err := m.upgradeServers(args); err != nil {
+		m.log.Errorf("Failed to upgrade machine %s to %s, restarting it with %s: %v", m.machineID, image, m.arangoImage, err)
+		if m.state == cluster.MachineStateStarted {
+			if err := m.stop(false); err != nil {
+				m.log.Errorf("Failed to stop machine %s: %v", m.machineID, err)
+			}
+		}
+		m.createOptions.Config.Cmd = oldArgs
+		if err := m.start(); err != nil {
+			return maskAny(err)
+		}
+		m.changeCallback(m)
+		if err := m.waitUntilServersReady(m.log, serverReadyTimeout); err != nil {
+			m.log.Errorf("Machine %s did not get ready after restarting it with %s: %v", m.machineID, m.arangoImage, err)
+		}
+		return maskAny(err)
+	}
+	m.createOptions.Config.Cmd = args
+	if err := m.start(); err != nil {
+		return maskAny(err)
+	}
+	m.arangoImage = image
+	m.changeCallback(m)
 
 	// Wait for servers ready
 	if err := m.waitUntilServersReady(m.log, serverReadyTimeout); err != nil {
@@ -277,6 +565,71 @@ func (m *arangodb) Reboot() error {
 	return nil
 }
 
+// upgradeServers starts the (stopped) machine once with given starter arguments and lets all servers
+// perform a database auto-upgrade.
+// The servers terminate after the upgrade, so this function waits for that and stops the machine again.
+// Only the servers must upgrade, so the option is passed through the starter (the starter itself rejects it).
+func (m *arangodb) upgradeServers(args []string) error {
+	m.createOptions.Config.Cmd = append(append([]string{}, args...), "--args.all.database.auto-upgrade=true")
+	err := m.start()
+	m.createOptions.Config.Cmd = args
+	if err != nil {
+		return maskAny(err)
+	}
+
+	// Find the containers of the upgrading servers
+	client, err := arangostarter.NewArangoStarterClient(m.StarterEndpoint())
+	if err != nil {
+		return maskAny(err)
+	}
+	expected := 0
+	for _, has := range []bool{m.hasAgent, m.hasDBServer, m.hasCoordinator, m.hasSingle} {
+		if has {
+			expected++
+		}
+	}
+	var containerIDs []string
+	op := func() error {
+		list, err := client.Processes(context.Background())
+		if err != nil {
+			return maskAny(err)
+		}
+		containerIDs = nil
+		for _, s := range list.Servers {
+			if s.ContainerID != "" {
+				containerIDs = append(containerIDs, s.ContainerID)
+			}
+		}
+		if len(containerIDs) < expected {
+			return maskAny(fmt.Errorf("Only %d of %d servers started", len(containerIDs), expected))
+		}
+		return nil
+	}
+	if err := retry.Retry(op, serverReadyTimeout); err != nil {
+		return maskAny(err)
+	}
+
+	// Wait until all servers have finished their upgrade
+	ctx, cancel := context.WithTimeout(context.Background(), serverReadyTimeout)
+	defer cancel()
+	for _, id := range containerIDs {
+		m.log.Infof("Waiting for database upgrade in container %s", id)
+		exitCode, err := m.dockerHost.Client.WaitContainerWithContext(id, ctx)
+		if err != nil {
+			return maskAny(err)
+		}
+		if exitCode != 0 {
+			return maskAny(fmt.Errorf("Database upgrade in container %s failed with exit code %d", id, exitCode))
+		}
+	}
+
+	// Stop arangodb, it will be started without the auto-upgrade option
+	if err := m.stop(false); err != nil {
+		return maskAny(err)
+	}
+	return nil
+}
+
 // DestroyAllowed returns true if it is allowed to destroy this machine
 func (m *arangodb) DestroyAllowed() bool {
 	return m.index > 0
@@ -303,8 +656,53 @@ func (m *arangodb) Destroy() error {
 	return nil
 }
 
+// ReplaceAllowed returns true if it is allowed to replace this machine.
+// The master cannot be replaced, since all other machines join it.
+func (m *arangodb) ReplaceAllowed() bool {
+	return m.index > 0
+}
+
+// lose removes the machine abruptly, as if its hardware is lost.
+// All containers are removed without a graceful shutdown, together with the data volume.
+func (m *arangodb) lose() error {
+	m.log.Infof("Losing machine %s", m.machineID)
+	m.state = cluster.MachineStateDestroyed
+
+	// Remove the arangodb container first, so it cannot restart the servers
+	for _, id := range []string{m.containerID, m.agentContainerID, m.dbserverContainerID, m.coordinatorContainerID, m.singleContainerID} {
+		if id == "" {
+			continue
+		}
+		m.log.Infof("Removing container %s", id)
+		if err := m.dockerHost.Client.RemoveContainer(dc.RemoveContainerOptions{
+			Force:         true,
+			ID:            id,
+			RemoveVolumes: true,
+		}); err != nil {
+			m.log.Errorf("Failed to remove container %s: %v", id, err)
+		}
+	}
+
+	// Remove volume
+	m.log.Infof("Removing volume %s", m.volumeID)
+	if err := m.dockerHost.Client.RemoveVolume(m.volumeID); err != nil {
+		m.log.Errorf("Failed to remove volume %s: %v", m.volumeID, err)
+	}
+
+	// Terminate network-blocker
+	if err := m.stopNetworkBlocker(); err != nil {
+		return maskAny(err)
+	}
+
+	// Remove machine from list
+	m.destroyCallback(m)
+
+	return nil
+}
+
 // createMachine creates a volume and all configuration needed to start arangodb.
-func (c *arangodbCluster) createMachine(index int) (*arangodb, error) {
+// If replaces is set, the new machine is configured to take over the place of that (lost) machine.
+func (c *arangodbCluster) createMachine(index int, replaces *arangodb) (*arangodb, error) {
 	// Create machine ID
 	// Create random ID
 	b := make([]byte, 4)
@@ -315,22 +713,41 @@ func (c *arangodbCluster) createMachine(index int) (*arangodb, error) {
 
 	// Pick a docker host
 	dockerHost := c.dockerHosts[index%len(c.dockerHosts)]
+	if replaces != nil {
+		// The replacement must be reachable on the same address
+		dockerHost = replaces.dockerHost
+	}
 
 	// Create volume
 	name := fmt.Sprintf("arangodb-%s-%d-%s", c.id, index, machineID)
 	volName := name + "-vol"
 	c.log.Debugf("Creating docker volume for arangodb %d on %s", index, dockerHost.IP)
-	_, err := dockerHost.Client.CreateVolume(dc.CreateVolumeOptions{
-		Name: volName,
-	})
+	volOpts := dc.CreateVolumeOptions{
+		Name:   volName,
+		Labels: c.machineLabels(machineID),
+	}
+	if c.DataVolumeSize > 0 {
+		// Size-limited volume, such that it can be filled by chaos
+		volOpts.DriverOpts = map[string]string{
+			"type":   "tmpfs",
+			"device": "tmpfs",
+			"o":      fmt.Sprintf("size=%d", c.DataVolumeSize),
+		}
+	}
+	_, err := dockerHost.Client.CreateVolume(volOpts)
 	if err != nil {
 		return nil, maskAny(err)
 	}
 
 	var arangodbPort int
+	var recoveryAddress string
 	if index == 0 {
 		// Master
 		arangodbPort = c.MasterPort
+	} else if replaces != nil {
+		// Take over the port of the machine we replace
+		arangodbPort = c.ports.Transfer(replaces.machineID, machineID)
+		recoveryAddress = net.JoinHostPort(replaces.dockerHost.IP, strconv.Itoa(replaces.arangodbPort))
 	} else {
 		// Allocate a port
 		arangodbPort = c.ports.Allocate(machineID)
@@ -344,6 +761,7 @@ func (c *arangodbCluster) createMachine(index int) (*arangodb, error) {
 		"--log.rotate-interval=2h0m0s",
 		fmt.Sprintf("--docker.endpoint=%s", dockerHost.Endpoint),
 		fmt.Sprintf("--starter.address=%s", dockerHost.IP),
+		fmt.Sprintf("--starter.mode=%s", c.ArangodbConfig.Mode),
 		"--args.agents.log.level=communication=debug",
 		"--args.agents.log.level=requests=debug",
 		"--args.coordinators.log.level=agencycomm=debug",
@@ -356,6 +774,12 @@ func (c *arangodbCluster) createMachine(index int) (*arangodb, error) {
 		"--args.dbservers.log.level=communication=debug",
 		"--args.dbservers.log.level=requests=debug",
 	}
+	if !c.ArangodbConfig.Mode.IsCluster() {
+		args = append(args,
+			"--args.singles.log.level=replication=debug",
+			"--args.singles.log.level=requests=debug",
+		)
+	}
 	if c.Verbose {
 		args = append(args, "--verbose")
 	}
@@ -382,14 +806,27 @@ func (c *arangodbCluster) createMachine(index int) (*arangodb, error) {
 			fmt.Sprintf("--starter.join=%s:%d", c.dockerHosts[0].IP, c.MasterPort),
 		)
 	}
+	// The keyfile & JWT secret are mounted at the same path in the starter container
+	var secretBinds []string
+	if c.SSLKeyFile != "" {
+		args = append(args, fmt.Sprintf("--ssl.keyfile=%s", c.SSLKeyFile))
+		secretBinds = append(secretBinds, fmt.Sprintf("%s:%s:ro", c.SSLKeyFile, c.SSLKeyFile))
+	} else if c.SSLAutoKey {
+		args = append(args, "--ssl.auto-key")
+	}
+	if c.JWTSecretFile != "" {
+		args = append(args, fmt.Sprintf("--auth.jwt-secret=%s", c.JWTSecretFile))
+		secretBinds = append(secretBinds, fmt.Sprintf("%s:%s:ro", c.JWTSecretFile, c.JWTSecretFile))
+	}
 	dockerCertPath := os.Getenv("DOCKER_CERT_PATH")
 	opts := dc.CreateContainerOptions{
 		Name: name,
 		Config: &dc.Config{
-			Image: c.ArangodbConfig.ArangodbImage,
-			Cmd:   args,
-			Tty:   true,
-			Env:   os.Environ(),
+			Image:  c.ArangodbConfig.ArangodbImage,
+			Cmd:    args,
+			Tty:    true,
+			Env:    os.Environ(),
+			Labels: c.machineLabels(machineID),
 			ExposedPorts: map[dc.Port]struct{}{
 				dc.Port(fmt.Sprintf("%d/tcp", arangodbPort)): struct{}{},
 			},
@@ -411,6 +848,7 @@ func (c *arangodbCluster) createMachine(index int) (*arangodb, error) {
 			Privileged:      c.Privileged,
 		},
 	}
+	opts.HostConfig.Binds = append(opts.HostConfig.Binds, secretBinds...)
 	if c.DockerNetHost {
 		opts.HostConfig.NetworkMode = "host"
 	}
@@ -428,12 +866,18 @@ func (c *arangodbCluster) createMachine(index int) (*arangodb, error) {
 		collectMetrics:  c.collectMetrics,
 		metricsDir:      c.metricsDir,
 		index:           index,
+		mode:            c.ArangodbConfig.Mode,
 		createdAt:       time.Now(),
 		state:           cluster.MachineStateNew,
 		arangodbPort:    arangodbPort,
 		nwBlockerPort:   arangodbPort + 4,
+		secure:          c.secure(),
+		authHeader:      c.authHeader,
 		volumeID:        volName,
 		destroyCallback: c.destroyCallback,
+		changeCallback:  c.changeCallback,
+		arangoImage:     c.ArangodbConfig.ArangoImage,
+		recoveryAddress: recoveryAddress,
 	}, nil
 }
 
@@ -443,8 +887,6 @@ func (c *arangodbCluster) destroyCallback(m *arangodb) {
 
 	// Remove machine from list
 	c.mutex.Lock()
-	defer c.mutex.Unlock()
-
 	newList := []*arangodb{}
 	for _, x := range c.machines {
 		if m != x {
@@ -452,12 +894,19 @@ func (c *arangodbCluster) destroyCallback(m *arangodb) {
 		}
 	}
 	c.machines = newList
+	c.mutex.Unlock()
+
+	c.saveState()
+}
+
+func (c *arangodbCluster) changeCallback(m *arangodb) {
+	c.saveState()
 }
 
 // StarterEndpoint returns an URL to the starter (this machine)
 func (m *arangodb) StarterEndpoint() url.URL {
 	return url.URL{
-		Scheme: "http",
+		Scheme: scheme(m.secure),
 		Host:   net.JoinHostPort(m.dockerHost.IP, strconv.Itoa(m.arangodbPort)),
 	}
 }
@@ -488,6 +937,13 @@ func (m *arangodb) start() error {
 		return maskAny(err)
 	}
 	m.containerID = cont.ID
+	if m.recoveryAddress != "" {
+		// Let the starter take over the place of a lost starter
+		if err := m.uploadRecoveryFile(cont.ID); err != nil {
+			return maskAny(err)
+		}
+		m.recoveryAddress = ""
+	}
 	m.log.Debugf("Starting arangodb container %s (%s)", m.createOptions.Name, cont.ID)
 	if err := m.dockerHost.Client.StartContainer(cont.ID, m.createOptions.HostConfig); err != nil {
 		return maskAny(err)
@@ -547,20 +1003,47 @@ func (m *arangodb) stop(destroy bool) error {
 	return nil
 }
 
+// uploadRecoveryFile writes a RECOVERY file into the data directory of the given (not yet started)
+// arangodb container. It contains the address of the lost starter this machine replaces.
+func (m *arangodb) uploadRecoveryFile(containerID string) error {
+	content := []byte(m.recoveryAddress)
+	buf := &bytes.Buffer{}
+	tw := tar.NewWriter(buf)
+	if err := tw.WriteHeader(&tar.Header{
+		Name:    "RECOVERY",
+		Mode:    0644,
+		Size:    int64(len(content)),
+		ModTime: time.Now(),
+	}); err != nil {
+		return maskAny(err)
+	}
+	if _, err := tw.Write(content); err != nil {
+		return maskAny(err)
+	}
+	if err := tw.Close(); err != nil {
+		return maskAny(err)
+	}
+	m.log.Infof("Creating RECOVERY file for %s in container %s", m.recoveryAddress, containerID)
+	if err := m.dockerHost.Client.UploadToContainer(containerID, dc.UploadToContainerOptions{
+		InputStream: buf,
+		Path:        "/data",
+	}); err != nil {
+		return maskAny(err)
+	}
+	return nil
+}
+
 // startNetworkBlocker creates & starts the network-blocker for the machine
 func (m *arangodb) startNetworkBlocker(image string) error {
 	name := m.createOptions.Name + "-netblk"
-	ep, err := url.Parse(fmt.Sprintf("http://%s:%d", m.dockerHost.IP, m.nwBlockerPort))
-	if err != nil {
-		return maskAny(err)
-	}
 	m.log.Debugf("Creating network-blocker container %s", name)
 	cont, err := m.dockerHost.Client.CreateContainer(dc.CreateContainerOptions{
 		Name: name,
 		Config: &dc.Config{
-			Image: image,
-			Cmd:   []string{"--port", strconv.Itoa(m.nwBlockerPort)},
-			Tty:   true,
+			Image:  image,
+			Cmd:    []string{"--port", strconv.Itoa(m.nwBlockerPort)},
+			Tty:    true,
+			Labels: m.createOptions.Config.Labels, // Same labels as the arangodb container
 		},
 		HostConfig: &dc.HostConfig{
 			NetworkMode: "host",
@@ -576,11 +1059,19 @@ func (m *arangodb) startNetworkBlocker(image string) error {
 	if err := m.dockerHost.Client.StartContainer(cont.ID, m.createOptions.HostConfig); err != nil {
 		return maskAny(err)
 	}
-	m.nwBlocker = networkblocker.NewClient(*ep)
+	m.nwBlocker = networkblocker.NewClient(m.networkBlockerEndpoint())
 	m.log.Debugf("Started network-blocker container %s (%s)", name, cont.ID)
 	return nil
 }
 
+// networkBlockerEndpoint returns an URL to the network-blocker of this machine
+func (m *arangodb) networkBlockerEndpoint() url.URL {
+	return url.URL{
+		Scheme: "http",
+		Host:   net.JoinHostPort(m.dockerHost.IP, strconv.Itoa(m.nwBlockerPort)),
+	}
+}
+
 func (m *arangodb) stopNetworkBlocker() error {
 	// Stop the network-block container
 	m.log.Infof("Stopping container %s", m.nwBlockerContainerID)
@@ -606,7 +1097,7 @@ type ProcessListResponse struct {
 }
 
 type ServerProcess struct {
-	Type        string `json:"type"`                   // agent | coordinator | dbserver
+	Type        string `json:"type"`                   // agent | coordinator | dbserver | single | resilientsingle
 	IP          string `json:"ip"`                     // IP address needed to reach the server
 	Port        int    `json:"port"`                   // Port needed to reach the server
 	ProcessID   int    `json:"pid,omitempty"`          // PID of the process (0 when running in docker)
@@ -632,25 +1123,39 @@ func (m *arangodb) updateServerInfo() error {
 		if !plResp.ServersStarted {
 			return maskAny(fmt.Errorf("Servers not yet started"))
 		}
-		hasAgent := false
+		hasAgent, hasCoordinator, hasDBServer, hasSingle := false, false, false, false
 		for _, s := range plResp.Servers {
 			switch s.Type {
 			case "agent":
 				m.agentPort = s.Port
 				m.agentContainerID = s.ContainerID
 				m.agentContainerIP = s.ContainerIP
+				m.agentSecure = s.IsSecure
 				hasAgent = true
 			case "coordinator":
 				m.coordinatorPort = s.Port
 				m.coordinatorContainerID = s.ContainerID
 				m.coordinatorContainerIP = s.ContainerIP
+				m.coordinatorSecure = s.IsSecure
+				hasCoordinator = true
 			case "dbserver":
 				m.dbserverPort = s.Port
 				m.dbserverContainerID = s.ContainerID
 				m.dbserverContainerIP = s.ContainerIP
+				m.dbserverSecure = s.IsSecure
+				hasDBServer = true
+			case "single", "resilientsingle":
+				m.singlePort = s.Port
+				m.singleContainerID = s.ContainerID
+				m.singleContainerIP = s.ContainerIP
+				m.singleSecure = s.IsSecure
+				hasSingle = true
 			}
 		}
 		m.hasAgent = hasAgent
+		m.hasCoordinator = hasCoordinator
+		m.hasDBServer = hasDBServer
+		m.hasSingle = hasSingle
 		return nil
 	}
 	if err := retry.Retry(op, time.Minute*20); err != nil {
@@ -672,12 +1177,21 @@ func (m *arangodb) waitUntilServersReady(log *logging.Logger, timeout time.Durat
 			return m.testInstance(m.log, m.AgentURL(), "agent", timeout, &m.lastAgentReadyStatus)
 		})
 	}
-	g.Go(func() error {
-		return m.testInstance(m.log, m.CoordinatorURL(), "coordinator", timeout, &m.lastCoordinatorReadyStatus)
-	})
-	g.Go(func() error {
-		return m.testInstance(m.log, m.DBServerURL(), "dbserver", timeout, &m.lastDBServerReadyStatus)
-	})
+	if m.hasCoordinator {
+		g.Go(func() error {
+			return m.testInstance(m.log, m.CoordinatorURL(), "coordinator", timeout, &m.lastCoordinatorReadyStatus)
+		})
+	}
+	if m.hasDBServer {
+		g.Go(func() error {
+			return m.testInstance(m.log, m.DBServerURL(), "dbserver", timeout, &m.lastDBServerReadyStatus)
+		})
+	}
+	if m.hasSingle {
+		g.Go(func() error {
+			return m.testInstance(m.log, m.SingleURL(), "single", timeout, &m.lastSingleReadyStatus)
+		})
+	}
 	if err := g.Wait(); err != nil {
 		return maskAny(err)
 	}
@@ -694,11 +1208,11 @@ func (m *arangodb) testInstance(log *logging.Logger, url url.URL, name string, t
 		log.Debugf("Waiting for %s-%d on %s to get ready", name, m.index, url.String())
 	}
 	start := time.Now()
-	client := &http.Client{Timeout: time.Second * 5}
+	client := &http.Client{Timeout: time.Second * 5, Transport: serverTransport}
 	for {
 		versionURL := url
 		versionURL.Path = "/_api/version"
-		r, e := client.Get(versionURL.String())
+		r, e := m.get(client, versionURL.String())
 		if e == nil && r != nil && r.StatusCode == 200 {
 			atomic.StoreInt32(activeVar, 1)
 			if log != nil {
@@ -715,35 +1229,63 @@ func (m *arangodb) testInstance(log *logging.Logger, url url.URL, name string, t
 	}
 }
 
+// get performs a GET request on the given URL, authenticated when the servers require it.
+func (m *arangodb) get(client *http.Client, url string) (*http.Response, error) {
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		return nil, maskAny(err)
+	}
+	if m.authHeader != "" {
+		req.Header.Set("Authorization", m.authHeader)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, maskAny(err)
+	}
+	return resp, nil
+}
+
 // watchdog monitors all servers and updates the last ready flag.
+// Which servers run on the machine is only known once they are started, so every
+// loop checks whether its server exists.
 func (m *arangodb) watchdog() {
 	timeout := time.Minute
-	monitorLoop := func(urlGetter func() url.URL, name string, activeVar *int32) {
+	monitorLoop := func(exists func() bool, urlGetter func() url.URL, name string, activeVar *int32) {
 		for {
 			switch m.state {
 			case cluster.MachineStateStarted:
 				m.waitUntilServersReady(nil, time.Minute)
 			case cluster.MachineStateReady:
-				m.testInstance(nil, urlGetter(), name, timeout, activeVar)
+				if exists() {
+					m.testInstance(nil, urlGetter(), name, timeout, activeVar)
+				}
 			case cluster.MachineStateDestroyed:
 				return // We're done
 			}
 			time.Sleep(time.Second * 15)
 		}
 	}
-	if m.HasAgent() {
-		go monitorLoop(func() url.URL { return m.AgentURL() }, "agent", &m.lastAgentReadyStatus)
-	}
-	go monitorLoop(func() url.URL { return m.DBServerURL() }, "dbserver", &m.lastDBServerReadyStatus)
-	go monitorLoop(func() url.URL { return m.CoordinatorURL() }, "coordinator", &m.lastCoordinatorReadyStatus)
+	go monitorLoop(m.HasAgent, m.AgentURL, "agent", &m.lastAgentReadyStatus)
+	go monitorLoop(m.HasDBServer, m.DBServerURL, "dbserver", &m.lastDBServerReadyStatus)
+	go monitorLoop(m.HasCoordinator, m.CoordinatorURL, "coordinator", &m.lastCoordinatorReadyStatus)
+	go monitorLoop(m.HasSingle, m.SingleURL, "single", &m.lastSingleReadyStatus)
 }
 
 func (m *arangodb) startMetricsCollectionFromAllContainers() error {
-	if err := m.startMetricsCollectionFromDbServer(); err != nil {
-		return err
+	if m.HasDBServer() {
+		if err := m.startMetricsCollectionFromDbServer(); err != nil {
+			return err
+		}
 	}
-	if err := m.startMetricsCollectionFromCoordinator(); err != nil {
-		return err
+	if m.HasCoordinator() {
+		if err := m.startMetricsCollectionFromCoordinator(); err != nil {
+			return err
+		}
+	}
+	if m.HasSingle() {
+		if err := m.startMetricsCollectionFromSingle(); err != nil {
+			return err
+		}
 	}
 	if m.HasAgent() {
 		if err := m.startMetricsCollectionFromAgent(); err != nil {
@@ -767,6 +1309,13 @@ func (m *arangodb) startMetricsCollectionFromDbServer() error {
 	return m.startMetricsCollectionForContainer(m.dbserverContainerID, "DBSERVER", m.dockerHost.IP, m.dbserverPort)
 }
 
+func (m *arangodb) startMetricsCollectionFromSingle() error {
+	if err := m.waitUntilServersReady(m.log, serverReadyTimeout); err != nil {
+		return maskAny(err)
+	}
+	return m.startMetricsCollectionForContainer(m.singleContainerID, "SINGLE", m.dockerHost.IP, m.singlePort)
+}
+
 func (m *arangodb) startMetricsCollectionFromAgent() error {
 	if err := m.waitUntilServersReady(m.log, serverReadyTimeout); err != nil {
 		return maskAny(err)
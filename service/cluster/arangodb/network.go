@@ -3,6 +3,8 @@ package arangodb
 import (
 	"fmt"
 
+	"github.com/arangodb-helper/testagent/pkg/networkblocker"
+	"github.com/arangodb-helper/testagent/service/cluster"
 	"github.com/pkg/errors"
 )
 
@@ -72,6 +74,30 @@ func (m *arangodb) RejectCoordinatorTraffic() error {
 	return nil
 }
 
+// Actively reject all network traffic to the single server
+func (m *arangodb) RejectSingleTraffic() error {
+	if !m.HasSingle() {
+		return maskAny(fmt.Errorf("no single server on this machine"))
+	}
+	if m.createOptions.HostConfig.NetworkMode == "host" {
+		return maskAny(fmt.Errorf("network operations are nt supported on host networking"))
+	}
+	if m.singleContainerIP == "" {
+		return maskAny(fmt.Errorf("single server container IP is unknown"))
+	}
+	if api := m.nwBlocker; api == nil {
+		return maskAny(fmt.Errorf("network-blocker not yet initialized"))
+	} else {
+		if err := api.RejectTCP(m.singlePort); err != nil {
+			return maskAny(errors.Wrap(err, "Failed to reject single server traffic (to)"))
+		}
+		if err := api.RejectAllFrom(m.singleContainerIP, m.dockerHost.Interface); err != nil {
+			return maskAny(errors.Wrap(err, "Failed to reject single server traffic (from)"))
+		}
+	}
+	return nil
+}
+
 // Silently drop all network traffic to the agent
 func (m *arangodb) DropAgentTraffic() error {
 	if !m.HasAgent() {
@@ -138,6 +164,30 @@ func (m *arangodb) DropCoordinatorTraffic() error {
 	return nil
 }
 
+// Silently drop all network traffic to the single server
+func (m *arangodb) DropSingleTraffic() error {
+	if !m.HasSingle() {
+		return maskAny(fmt.Errorf("no single server on this machine"))
+	}
+	if m.createOptions.HostConfig.NetworkMode == "host" {
+		return maskAny(fmt.Errorf("network operations are nt supported on host networking"))
+	}
+	if m.singleContainerIP == "" {
+		return maskAny(fmt.Errorf("single server container IP is unknown"))
+	}
+	if api := m.nwBlocker; api == nil {
+		return maskAny(fmt.Errorf("network-blocker not yet initialized"))
+	} else {
+		if err := api.DropTCP(m.singlePort); err != nil {
+			return maskAny(errors.Wrap(err, "Failed to drop single server traffic (to)"))
+		}
+		if err := api.DropAllFrom(m.singleContainerIP, m.dockerHost.Interface); err != nil {
+			return maskAny(errors.Wrap(err, "Failed to drop single server traffic (from)"))
+		}
+	}
+	return nil
+}
+
 // Accept all network traffic to the agent
 func (m *arangodb) AcceptAgentTraffic() error {
 	if !m.HasAgent() {
@@ -204,6 +254,202 @@ func (m *arangodb) AcceptCoordinatorTraffic() error {
 	return nil
 }
 
+// Accept all network traffic to the single server
+func (m *arangodb) AcceptSingleTraffic() error {
+	if !m.HasSingle() {
+		return maskAny(fmt.Errorf("no single server on this machine"))
+	}
+	if m.createOptions.HostConfig.NetworkMode == "host" {
+		return maskAny(fmt.Errorf("network operations are nt supported on host networking"))
+	}
+	if m.singleContainerIP == "" {
+		return maskAny(fmt.Errorf("single server container IP is unknown"))
+	}
+	if api := m.nwBlocker; api == nil {
+		return maskAny(fmt.Errorf("network-blocker not yet initialized"))
+	} else {
+		if err := api.AcceptTCP(m.singlePort); err != nil {
+			return maskAny(errors.Wrap(err, "Failed to accept single server traffic (to)"))
+		}
+		if err := api.AcceptAllFrom(m.singleContainerIP, m.dockerHost.Interface); err != nil {
+			return maskAny(errors.Wrap(err, "Failed to accept single server traffic (from)"))
+		}
+	}
+	return nil
+}
+
+// ContainerIP returns the IP address of the container running the server with given role on this machine.
+func (m *arangodb) ContainerIP(role cluster.ServerRole) (string, error) {
+	var ip string
+	switch role {
+	case cluster.ServerRoleAgent:
+		if !m.HasAgent() {
+			return "", maskAny(fmt.Errorf("no agent on this machine"))
+		}
+		ip = m.agentContainerIP
+	case cluster.ServerRoleDBServer:
+		ip = m.dbserverContainerIP
+	case cluster.ServerRoleCoordinator:
+		ip = m.coordinatorContainerIP
+	case cluster.ServerRoleSingle:
+		if !m.HasSingle() {
+			return "", maskAny(fmt.Errorf("no single server on this machine"))
+		}
+		ip = m.singleContainerIP
+	default:
+		return "", maskAny(fmt.Errorf("unknown server role '%s'", role))
+	}
+	if ip == "" {
+		return "", maskAny(fmt.Errorf("%s container IP is unknown", role))
+	}
+	return ip, nil
+}
+
+// NetworkFeatures returns the optional network operations that the network-blocker of this machine supports.
+// The network-blocker is asked once, the result is remembered afterwards.
+func (m *arangodb) NetworkFeatures() (cluster.NetworkFeatures, error) {
+	m.nwFeaturesMutex.Lock()
+	defer m.nwFeaturesMutex.Unlock()
+	if m.nwFeatures != nil {
+		return *m.nwFeatures, nil
+	}
+	api := m.nwBlocker
+	if api == nil {
+		return cluster.NetworkFeatures{}, maskAny(fmt.Errorf("network-blocker not yet initialized"))
+	}
+	var features cluster.NetworkFeatures
+	var err error
+	if features.Partition, err = api.SupportsBetween(); err != nil {
+		return cluster.NetworkFeatures{}, maskAny(errors.Wrap(err, "Failed to check network-blocker features"))
+	}
+	if features.Degradation, err = api.SupportsDegrade(); err != nil {
+		return cluster.NetworkFeatures{}, maskAny(errors.Wrap(err, "Failed to check network-blocker features"))
+	}
+	m.nwFeatures = &features
+	return features, nil
+}
+
+// DropTrafficBetween silently drops all network traffic between the server with given role and the given IP addresses (in both directions)
+func (m *arangodb) DropTrafficBetween(role cluster.ServerRole, ips []string) error {
+	if m.createOptions.HostConfig.NetworkMode == "host" {
+		return maskAny(fmt.Errorf("network operations are nt supported on host networking"))
+	}
+	if features, err := m.NetworkFeatures(); err != nil {
+		return maskAny(err)
+	} else if !features.Partition {
+		return maskAny(errors.Wrap(cluster.NotSupportedError, "the network-blocker image does not support rules between servers"))
+	}
+	ip, err := m.ContainerIP(role)
+	if err != nil {
+		return maskAny(err)
+	}
+	if api := m.nwBlocker; api == nil {
+		return maskAny(fmt.Errorf("network-blocker not yet initialized"))
+	} else {
+		for _, other := range ips {
+			if other == ip {
+				continue
+			}
+			if err := api.DropBetween(ip, other, m.dockerHost.Interface); err != nil {
+				return maskAny(errors.Wrapf(err, "Failed to drop %s traffic (between %s and %s)", role, ip, other))
+			}
+		}
+	}
+	return nil
+}
+
+// AcceptTrafficBetween accepts all network traffic between the server with given role and the given IP addresses (in both directions)
+func (m *arangodb) AcceptTrafficBetween(role cluster.ServerRole, ips []string) error {
+	if m.createOptions.HostConfig.NetworkMode == "host" {
+		return maskAny(fmt.Errorf("network operations are nt supported on host networking"))
+	}
+	ip, err := m.ContainerIP(role)
+	if err != nil {
+		return maskAny(err)
+	}
+	if api := m.nwBlocker; api == nil {
+		return maskAny(fmt.Errorf("network-blocker not yet initialized"))
+	} else {
+		for _, other := range ips {
+			if other == ip {
+				continue
+			}
+			if err := api.AcceptBetween(ip, other, m.dockerHost.Interface); err != nil {
+				return maskAny(errors.Wrapf(err, "Failed to accept %s traffic (between %s and %s)", role, ip, other))
+			}
+		}
+	}
+	return nil
+}
+
+// serverPort returns the port of the server with given role on this machine.
+func (m *arangodb) serverPort(role cluster.ServerRole) int {
+	switch role {
+	case cluster.ServerRoleAgent:
+		return m.agentPort
+	case cluster.ServerRoleDBServer:
+		return m.dbserverPort
+	case cluster.ServerRoleSingle:
+		return m.singlePort
+	default:
+		return m.coordinatorPort
+	}
+}
+
+// DegradeTraffic adds latency, jitter and/or packet loss to all network traffic of the server with given role
+func (m *arangodb) DegradeTraffic(role cluster.ServerRole, d cluster.NetworkDegradation) error {
+	if m.createOptions.HostConfig.NetworkMode == "host" {
+		return maskAny(fmt.Errorf("network operations are nt supported on host networking"))
+	}
+	if features, err := m.NetworkFeatures(); err != nil {
+		return maskAny(err)
+	} else if !features.Degradation {
+		return maskAny(errors.Wrap(cluster.NotSupportedError, "the network-blocker image does not support degrading traffic"))
+	}
+	ip, err := m.ContainerIP(role)
+	if err != nil {
+		return maskAny(err)
+	}
+	nd := networkblocker.Degradation{
+		Latency:    d.Latency,
+		Jitter:     d.Jitter,
+		PacketLoss: d.PacketLoss,
+	}
+	if api := m.nwBlocker; api == nil {
+		return maskAny(fmt.Errorf("network-blocker not yet initialized"))
+	} else {
+		if err := api.DegradeTCP(m.serverPort(role), nd); err != nil {
+			return maskAny(errors.Wrapf(err, "Failed to degrade %s traffic (to)", role))
+		}
+		if err := api.DegradeAllFrom(ip, m.dockerHost.Interface, nd); err != nil {
+			return maskAny(errors.Wrapf(err, "Failed to degrade %s traffic (from)", role))
+		}
+	}
+	return nil
+}
+
+// RestoreTraffic removes any degradation from the network traffic of the server with given role
+func (m *arangodb) RestoreTraffic(role cluster.ServerRole) error {
+	if m.createOptions.HostConfig.NetworkMode == "host" {
+		return maskAny(fmt.Errorf("network operations are nt supported on host networking"))
+	}
+	ip, err := m.ContainerIP(role)
+	if err != nil {
+		return maskAny(err)
+	}
+	if api := m.nwBlocker; api == nil {
+		return maskAny(fmt.Errorf("network-blocker not yet initialized"))
+	} else {
+		if err := api.RestoreTCP(m.serverPort(role)); err != nil {
+			return maskAny(errors.Wrapf(err, "Failed to restore %s traffic (to)", role))
+		}
+		if err := api.RestoreAllFrom(ip, m.dockerHost.Interface); err != nil {
+			return maskAny(errors.Wrapf(err, "Failed to restore %s traffic (from)", role))
+		}
+	}
+	return nil
+}
+
 // CollectNetworkRules fetches all network rules that are involve one of the servers
 func (m *arangodb) CollectNetworkRules() ([]string, error) {
 	if api := m.nwBlocker; api == nil {
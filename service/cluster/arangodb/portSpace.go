@@ -39,6 +39,14 @@ func (s *portSpace) Allocate(id string) int {
 	}
 }
 
+// Reserve the given port for given ID (e.g. for a resumed machine)
+func (s *portSpace) Reserve(id string, port int) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.inUse[id] = port
+}
+
 // Release the port allocated for the given ID
 func (s *portSpace) Release(id string) {
 	s.mutex.Lock()
@@ -46,3 +54,14 @@ func (s *portSpace) Release(id string) {
 
 	delete(s.inUse, id)
 }
+
+// Transfer the port allocated for the old ID to the new ID and return it.
+func (s *portSpace) Transfer(oldID, newID string) int {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	port := s.inUse[oldID]
+	delete(s.inUse, oldID)
+	s.inUse[newID] = port
+	return port
+}
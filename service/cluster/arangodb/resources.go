@@ -0,0 +1,143 @@
+package arangodb
+
+import (
+	"fmt"
+
+	"github.com/arangodb-helper/testagent/service/cluster"
+	dc "github.com/fsouza/go-dockerclient"
+)
+
+const (
+	// defaultCPUPeriod is the default CFS period (in microseconds) of docker containers.
+	defaultCPUPeriod = 100000
+)
+
+// containerResources are the CPU & memory limits of a container as reported by docker.
+type containerResources struct {
+	cpuPeriod  int64
+	cpuQuota   int64
+	memory     int64
+	memorySwap int64
+}
+
+// serverContainerID returns the ID of the container running the server with given role on this machine.
+func (m *arangodb) serverContainerID(role cluster.ServerRole) (string, error) {
+	if err := m.updateServerInfo(); err != nil {
+		return "", maskAny(err)
+	}
+	var id string
+	switch role {
+	case cluster.ServerRoleAgent:
+		if !m.HasAgent() {
+			return "", maskAny(fmt.Errorf("no agent on this machine"))
+		}
+		id = m.agentContainerID
+	case cluster.ServerRoleDBServer:
+		id = m.dbserverContainerID
+	case cluster.ServerRoleCoordinator:
+		id = m.coordinatorContainerID
+	case cluster.ServerRoleSingle:
+		if !m.HasSingle() {
+			return "", maskAny(fmt.Errorf("no single server on this machine"))
+		}
+		id = m.singleContainerID
+	default:
+		return "", maskAny(fmt.Errorf("unknown server role '%s'", role))
+	}
+	if id == "" {
+		return "", maskAny(fmt.Errorf("%s container ID is unknown", role))
+	}
+	return id, nil
+}
+
+// LimitResources changes the CPU and/or memory limits of the container of the server with given role.
+// The original limits are remembered until RestoreResources is called.
+func (m *arangodb) LimitResources(role cluster.ServerRole, l cluster.ResourceLimits) error {
+	id, err := m.serverContainerID(role)
+	if err != nil {
+		return maskAny(err)
+	}
+
+	m.resourcesMutex.Lock()
+	defer m.resourcesMutex.Unlock()
+
+	original, found := m.originalResources[role]
+	if !found {
+		c, err := m.dockerHost.Client.InspectContainer(id)
+		if err != nil {
+			return maskAny(err)
+		}
+		original = containerResources{
+			cpuPeriod:  c.HostConfig.CPUPeriod,
+			cpuQuota:   c.HostConfig.CPUQuota,
+			memory:     c.HostConfig.Memory,
+			memorySwap: c.HostConfig.MemorySwap,
+		}
+	}
+	opts := dc.UpdateContainerOptions{}
+	if l.CPUs > 0 {
+		opts.CPUPeriod = defaultCPUPeriod
+		opts.CPUQuota = int(l.CPUs * defaultCPUPeriod)
+	}
+	if l.Memory > 0 {
+		opts.Memory = int(l.Memory)
+		if original.memorySwap <= 0 {
+			// Swap must not be limited below the new memory limit
+			opts.MemorySwap = -1
+		}
+	}
+	m.log.Infof("Limiting resources of %s container %s to %s", role, id, l)
+	if err := m.dockerHost.Client.UpdateContainer(id, opts); err != nil {
+		return maskAny(err)
+	}
+	if m.originalResources == nil {
+		m.originalResources = make(map[cluster.ServerRole]containerResources)
+	}
+	m.originalResources[role] = original
+	return nil
+}
+
+// RestoreResources restores the original CPU & memory limits of the container of the server with given role.
+func (m *arangodb) RestoreResources(role cluster.ServerRole) error {
+	m.resourcesMutex.Lock()
+	defer m.resourcesMutex.Unlock()
+
+	original, found := m.originalResources[role]
+	if !found {
+		return nil
+	}
+	id, err := m.serverContainerID(role)
+	if err != nil {
+		return maskAny(err)
+	}
+	opts := dc.UpdateContainerOptions{
+		CPUPeriod:  int(original.cpuPeriod),
+		CPUQuota:   int(original.cpuQuota),
+		Memory:     int(original.memory),
+		MemorySwap: int(original.memorySwap),
+	}
+	if opts.CPUPeriod == 0 {
+		opts.CPUPeriod = defaultCPUPeriod
+	}
+	if opts.CPUQuota == 0 {
+		// Unlimited
+		opts.CPUQuota = -1
+	}
+	if opts.Memory == 0 {
+		// Docker cannot remove a memory limit, so use all memory of the host instead
+		info, err := m.dockerHost.Client.Info()
+		if err != nil {
+			return maskAny(err)
+		}
+		opts.Memory = int(info.MemTotal)
+	}
+	if opts.MemorySwap == 0 {
+		opts.MemorySwap = -1
+	}
+	m.log.Infof("Restoring resources of %s container %s", role, id)
+	if err := m.dockerHost.Client.UpdateContainer(id, opts); err != nil {
+		return maskAny(err)
+	}
+	delete(m.originalResources, role)
+	return nil
+}
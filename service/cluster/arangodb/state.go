@@ -0,0 +1,375 @@
+package arangodb
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/arangodb-helper/testagent/pkg/docker"
+	"github.com/arangodb-helper/testagent/pkg/networkblocker"
+	"github.com/arangodb-helper/testagent/service/cluster"
+	dc "github.com/fsouza/go-dockerclient"
+	"golang.org/x/sync/errgroup"
+)
+
+const (
+	clusterStateFile = "cluster.json"
+)
+
+// clusterState is the persisted topology of a cluster, used to resume the cluster
+// after the testagent was restarted.
+type clusterState struct {
+	ID               string                 `json:"id"`
+	Mode             cluster.DeploymentMode `json:"mode"`
+	AgencySize       int                    `json:"agency-size"`
+	ForceOneShard    bool                   `json:"force-one-shard,omitempty"`
+	LastMachineIndex int32                  `json:"last-machine-index"`
+	Machines         []machineState         `json:"machines"`
+}
+
+// machineState is the persisted state of a single machine.
+type machineState struct {
+	MachineID            string                    `json:"machine-id"`
+	Index                int                       `json:"index"`
+	DockerEndpoint       string                    `json:"docker-endpoint"`
+	DockerHostIP         string                    `json:"docker-host-ip"`
+	ArangodbPort         int                       `json:"arangodb-port"`
+	NwBlockerPort        int                       `json:"network-blocker-port"`
+	VolumeID             string                    `json:"volume-id"`
+	ContainerID          string                    `json:"container-id"`
+	NwBlockerContainerID string                    `json:"network-blocker-container-id"`
+	ArangoImage          string                    `json:"arango-image,omitempty"`
+	CreatedAt            time.Time                 `json:"created-at"`
+	CreateOptions        dc.CreateContainerOptions `json:"create-options"`
+}
+
+// saveState writes the current topology of the cluster into the state directory (if any).
+func (c *arangodbCluster) saveState() {
+	if c.StateDir == "" {
+		return
+	}
+	c.stateMutex.Lock()
+	defer c.stateMutex.Unlock()
+
+	c.mutex.Lock()
+	state := clusterState{
+		ID:               c.id,
+		Mode:             c.ArangodbConfig.Mode,
+		AgencySize:       c.agencySize,
+		ForceOneShard:    c.forceOneShard,
+		LastMachineIndex: c.lastMachineIndex,
+	}
+	for _, m := range c.machines {
+		opts := m.createOptions
+		if opts.Config != nil {
+			// The environment is taken from the testagent process when resuming
+			config := *opts.Config
+			config.Env = nil
+			opts.Config = &config
+		}
+		opts.Context = nil
+		state.Machines = append(state.Machines, machineState{
+			MachineID:            m.machineID,
+			Index:                m.index,
+			DockerEndpoint:       m.dockerHost.Endpoint,
+			DockerHostIP:         m.dockerHost.IP,
+			ArangodbPort:         m.arangodbPort,
+			NwBlockerPort:        m.nwBlockerPort,
+			VolumeID:             m.volumeID,
+			ContainerID:          m.containerID,
+			NwBlockerContainerID: m.nwBlockerContainerID,
+			ArangoImage:          m.arangoImage,
+			CreatedAt:            m.createdAt,
+			CreateOptions:        opts,
+		})
+	}
+	c.mutex.Unlock()
+
+	if err := writeClusterState(c.StateDir, state); err != nil {
+		c.log.Errorf("Failed to save cluster state: %v", err)
+	}
+}
+
+// removeState removes the persisted topology of the cluster (if any).
+func (c *arangodbCluster) removeState() {
+	if c.StateDir == "" {
+		return
+	}
+	c.stateMutex.Lock()
+	defer c.stateMutex.Unlock()
+
+	if err := os.Remove(filepath.Join(c.StateDir, clusterStateFile)); err != nil && !os.IsNotExist(err) {
+		c.log.Errorf("Failed to remove cluster state: %v", err)
+	}
+}
+
+// writeClusterState writes the given state into the state file in the given directory.
+// The file is replaced atomically, such that a crash never leaves a partial state behind.
+func writeClusterState(dir string, state clusterState) error {
+	content, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return maskAny(err)
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return maskAny(err)
+	}
+	path := filepath.Join(dir, clusterStateFile)
+	if err := os.WriteFile(path+".tmp", content, 0600); err != nil {
+		return maskAny(err)
+	}
+	if err := os.Rename(path+".tmp", path); err != nil {
+		return maskAny(err)
+	}
+	return nil
+}
+
+// readClusterState reads the state file in the given directory.
+func readClusterState(dir string) (clusterState, error) {
+	content, err := os.ReadFile(filepath.Join(dir, clusterStateFile))
+	if err != nil {
+		return clusterState{}, maskAny(err)
+	}
+	var state clusterState
+	if err := json.Unmarshal(content, &state); err != nil {
+		return clusterState{}, maskAny(err)
+	}
+	return state, nil
+}
+
+// Resume re-attaches to the cluster whose topology was saved in the state directory.
+// Stopped containers (e.g. after a reboot of a docker host) are started again and faults
+// introduced by chaos before the testagent stopped are removed.
+// This function returns when the cluster is operational (or an error occurs)
+func (cb *arangodbClusterBuilder) Resume() (cluster.Cluster, error) {
+	if cb.StateDir == "" {
+		return nil, maskAny(fmt.Errorf("StateDir missing"))
+	}
+	state, err := readClusterState(cb.StateDir)
+	if err != nil {
+		return nil, maskAny(fmt.Errorf("Cannot read cluster state: %v", err))
+	}
+	if state.Mode != cb.Mode {
+		return nil, maskAny(fmt.Errorf("Cluster %s was created in %s mode, cannot resume it in %s mode", state.ID, state.Mode, cb.Mode))
+	}
+	if len(state.Machines) == 0 {
+		return nil, maskAny(fmt.Errorf("Cluster %s has no machines", state.ID))
+	}
+
+	// Create docker hosts
+	dockerHosts, err := docker.NewDockerHosts(cb.DockerEndpoints, cb.DockerHostIP, cb.DockerInterface)
+	if err != nil {
+		return nil, maskAny(err)
+	}
+
+	// Instantiate
+	c := &arangodbCluster{
+		log:              cb.log,
+		collectMetrics:   cb.collectMetrics,
+		metricsDir:       cb.metricsDir,
+		ArangodbConfig:   cb.ArangodbConfig,
+		dockerHosts:      dockerHosts,
+		agencySize:       state.AgencySize,
+		forceOneShard:    state.ForceOneShard,
+		authHeader:       cb.authHeader,
+		id:               state.ID,
+		lastMachineIndex: state.LastMachineIndex,
+	}
+	c.ports.Initialize(cb.ArangodbConfig.MasterPort, machinePortDelta)
+	if err := c.createAgentVolume(); err != nil {
+		cb.log.Warningf("Failed to mark cluster %s as in use: %v", state.ID, err)
+	}
+
+	cb.log.Infof("Resuming cluster %s with %d machines", state.ID, len(state.Machines))
+	for _, ms := range state.Machines {
+		m, err := c.resumeMachine(ms)
+		if err != nil {
+			return nil, maskAny(err)
+		}
+		if m.index > 0 {
+			c.ports.Reserve(m.machineID, m.arangodbPort)
+		}
+		c.machines = append(c.machines, m)
+	}
+
+	// Remove left-over chaos first, since a frozen or cut off server never gets ready.
+	// Healing needs the containers & addresses of the servers, which the starters know once they started them.
+	g := errgroup.Group{}
+	for _, m := range c.machines {
+		m := m // Used in nested func
+		g.Go(func() error {
+			if err := m.updateServerInfo(); err != nil {
+				return maskAny(err)
+			}
+			return nil
+		})
+	}
+	if err := g.Wait(); err != nil {
+		return nil, maskAny(err)
+	}
+	c.healFaults()
+
+	// Wait until all servers are reachable
+	g = errgroup.Group{}
+	for _, m := range c.machines {
+		m := m // Used in nested func
+		g.Go(func() error {
+			if err := m.waitUntilServersReady(c.log, serverReadyTimeout); err != nil {
+				return maskAny(err)
+			}
+			return nil
+		})
+	}
+	if err := g.Wait(); err != nil {
+		return nil, maskAny(err)
+	}
+	for _, m := range c.machines {
+		m.watchdog()
+	}
+	c.saveState()
+
+	return c, nil
+}
+
+// resumeMachine re-creates a machine from its persisted state and makes sure its
+// arangodb & network-blocker containers are running.
+func (c *arangodbCluster) resumeMachine(ms machineState) (*arangodb, error) {
+	var dockerHost *docker.DockerHost
+	for _, h := range c.dockerHosts {
+		if h.Endpoint == ms.DockerEndpoint {
+			dockerHost = h
+			break
+		}
+	}
+	if dockerHost == nil {
+		return nil, maskAny(fmt.Errorf("Docker endpoint %s of machine %s is not configured", ms.DockerEndpoint, ms.MachineID))
+	}
+	if dockerHost.IP != ms.DockerHostIP {
+		// The starters have been started with the old address
+		return nil, maskAny(fmt.Errorf("IP of docker host %s changed from %s to %s", ms.DockerEndpoint, ms.DockerHostIP, dockerHost.IP))
+	}
+	opts := ms.CreateOptions
+	if opts.Config == nil || opts.HostConfig == nil {
+		return nil, maskAny(fmt.Errorf("Container options of machine %s are incomplete", ms.MachineID))
+	}
+	opts.Config.Env = os.Environ()
+
+	m := &arangodb{
+		machineID:            ms.MachineID,
+		dockerHost:           dockerHost,
+		createOptions:        opts,
+		log:                  c.log,
+		collectMetrics:       c.collectMetrics,
+		metricsDir:           c.metricsDir,
+		index:                ms.Index,
+		mode:                 c.ArangodbConfig.Mode,
+		createdAt:            ms.CreatedAt,
+		startedAt:            time.Now(),
+		state:                cluster.MachineStateStarted,
+		arangodbPort:         ms.ArangodbPort,
+		nwBlockerPort:        ms.NwBlockerPort,
+		secure:               c.secure(),
+		authHeader:           c.authHeader,
+		volumeID:             ms.VolumeID,
+		containerID:          ms.ContainerID,
+		nwBlockerContainerID: ms.NwBlockerContainerID,
+		destroyCallback:      c.destroyCallback,
+		changeCallback:       c.changeCallback,
+		arangoImage:          ms.ArangoImage,
+	}
+	for _, id := range []string{m.nwBlockerContainerID, m.containerID} {
+		if err := m.ensureContainerRunning(id); err != nil {
+			return nil, maskAny(err)
+		}
+	}
+	m.nwBlocker = networkblocker.NewClient(m.networkBlockerEndpoint())
+	return m, nil
+}
+
+// ensureContainerRunning starts the container with given ID if it is not running.
+func (m *arangodb) ensureContainerRunning(id string) error {
+	cont, err := m.dockerHost.Client.InspectContainerWithOptions(dc.InspectContainerOptions{ID: id})
+	if err != nil {
+		return maskAny(fmt.Errorf("Cannot find container %s of machine %s: %v", id, m.machineID, err))
+	}
+	if cont.State.Running {
+		return nil
+	}
+	m.log.Infof("Starting stopped container %s of machine %s", cont.Name, m.machineID)
+	if err := m.dockerHost.Client.StartContainer(id, nil); err != nil {
+		return maskAny(err)
+	}
+	return nil
+}
+
+// healFaults removes all faults that chaos may have introduced before the testagent stopped.
+// Which faults were active is not known, so all of them are removed (best effort).
+func (c *arangodbCluster) healFaults() {
+	roles := []cluster.ServerRole{cluster.ServerRoleAgent, cluster.ServerRoleDBServer, cluster.ServerRoleCoordinator, cluster.ServerRoleSingle}
+	var allIPs []string
+	for _, m := range c.machines {
+		for _, role := range roles {
+			if ip, err := m.ContainerIP(role); err == nil {
+				allIPs = append(allIPs, ip)
+			}
+		}
+	}
+	for _, m := range c.machines {
+		hostNetwork := m.createOptions.HostConfig.NetworkMode == "host"
+		for _, role := range roles {
+			id, err := m.serverContainerID(role)
+			if err != nil || id == "" {
+				continue
+			}
+			heal := []func() error{
+				func() error { return m.dockerHost.Client.UnpauseContainer(id) },
+				func() error { return m.RestoreClock(role) },
+				func() error {
+					// Server containers are created without limits
+					m.resourcesMutex.Lock()
+					if m.originalResources == nil {
+						m.originalResources = make(map[cluster.ServerRole]containerResources)
+					}
+					m.originalResources[role] = containerResources{}
+					m.resourcesMutex.Unlock()
+					return m.RestoreResources(role)
+				},
+			}
+			if !hostNetwork {
+				heal = append(heal,
+					func() error { return m.acceptTraffic(role) },
+					func() error { return m.RestoreTraffic(role) },
+				)
+				for _, ip := range allIPs {
+					ips := []string{ip} // Accept one by one, since most IPs have no rule to remove
+					heal = append(heal, func() error { return m.AcceptTrafficBetween(role, ips) })
+				}
+			}
+			for _, f := range heal {
+				if err := f(); err != nil {
+					c.log.Debugf("Cannot heal %s on machine %s: %v", role, m.ID(), err)
+				}
+			}
+		}
+		if c.DataVolumeSize > 0 {
+			if err := m.FreeDisk(); err != nil {
+				c.log.Debugf("Cannot free disk of machine %s: %v", m.ID(), err)
+			}
+		}
+	}
+}
+
+// acceptTraffic accepts all network traffic to the server with given role.
+func (m *arangodb) acceptTraffic(role cluster.ServerRole) error {
+	switch role {
+	case cluster.ServerRoleAgent:
+		return m.AcceptAgentTraffic()
+	case cluster.ServerRoleDBServer:
+		return m.AcceptDBServerTraffic()
+	case cluster.ServerRoleCoordinator:
+		return m.AcceptCoordinatorTraffic()
+	default:
+		return m.AcceptSingleTraffic()
+	}
+}
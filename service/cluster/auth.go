@@ -0,0 +1,64 @@
+package cluster
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/tls"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+// ReadJWTSecret reads the JWT secret from the given file (as the starter does).
+func ReadJWTSecret(path string) (string, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return "", maskAny(err)
+	}
+	return strings.TrimSpace(string(content)), nil
+}
+
+// JWTAuthorizationHeader creates the value of an Authorization header that authenticates
+// requests as superuser on servers that use the given JWT secret.
+func JWTAuthorizationHeader(secret string) (string, error) {
+	header, err := json.Marshal(map[string]string{"alg": "HS256", "typ": "JWT"})
+	if err != nil {
+		return "", maskAny(err)
+	}
+	claims, err := json.Marshal(map[string]interface{}{"iss": "arangodb", "server_id": "testagent"})
+	if err != nil {
+		return "", maskAny(err)
+	}
+	encoding := base64.RawURLEncoding
+	token := encoding.EncodeToString(header) + "." + encoding.EncodeToString(claims)
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(token))
+	return "bearer " + token + "." + encoding.EncodeToString(mac.Sum(nil)), nil
+}
+
+// NewHTTPTransport creates an HTTP transport used to talk to the servers of a cluster.
+// Servers with TLS typically use self-signed certificates, so certificates are not verified.
+func NewHTTPTransport() *http.Transport {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = &tls.Config{InsecureSkipVerify: true}
+	return transport
+}
+
+// NewHTTPClient creates an HTTP client (with its own transport) used to talk to the servers of a cluster.
+// A timeout of 0 means no timeout.
+func NewHTTPClient(timeout time.Duration) *http.Client {
+	return &http.Client{
+		Timeout:   timeout,
+		Transport: NewHTTPTransport(),
+	}
+}
+
+// Authenticate adds the authorization header of the given cluster (if any) to the given request.
+func Authenticate(c Cluster, req *http.Request) {
+	if header := c.AuthorizationHeader(); header != "" {
+		req.Header.Set("Authorization", header)
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"io"
 	"net/url"
+	"strings"
 	"time"
 )
 
@@ -14,13 +15,39 @@ type ClusterBuilder interface {
 	Create(agencySize int, forceOneShard bool) (Cluster, error)
 }
 
+// ClusterResumer is implemented by cluster builders that can re-attach to a cluster
+// created by an earlier testagent process (see --resume).
+type ClusterResumer interface {
+	// Resume re-attaches to the cluster whose state was stored by an earlier testagent process.
+	// This function returns when the cluster is operational (or an error occurs)
+	Resume() (Cluster, error)
+}
+
 type Cluster interface {
 	// ID returns a unique identifier for this cluster
 	ID() string
 
+	// Mode returns the deployment mode of this cluster
+	Mode() DeploymentMode
+
+	// VolatileData returns true if the data of a machine is lost when all its containers are stopped
+	// (e.g. when its data volume is a tmpfs).
+	VolatileData() bool
+
 	// ArangoImage returns the arango (database) docker image used on this cluster
 	ArangoImage() string
 
+	// UpgradeImage returns the arango (database) docker image that machines are upgraded to, or empty if no upgrade is configured
+	UpgradeImage() string
+
+	// Managed returns true if the machines & servers of this cluster are created and controlled by
+	// the testagent. Otherwise only the database API can be used (e.g. to introduce chaos).
+	Managed() bool
+
+	// AuthorizationHeader returns the value of the Authorization header needed to talk to the servers
+	// of this cluster, or empty if the servers do not require authentication
+	AuthorizationHeader() string
+
 	// Machines returns all current machines in the cluster.
 	Machines() ([]Machine, error)
 
@@ -30,6 +57,11 @@ type Cluster interface {
 	// Add adds a single machine to the cluster
 	Add() (Machine, error)
 
+	// Replace permanently destroys the given machine (as if its hardware is lost) and brings up
+	// a new machine that takes over its place in the cluster, including its agent (if any).
+	// This function returns when all servers of the new machine are ready.
+	Replace(m Machine) (Machine, error)
+
 	// Start collecting metrics from machines
 	StartMetricsCollection() error
 
@@ -37,6 +69,30 @@ type Cluster interface {
 	Destroy() error
 }
 
+// DeploymentMode is the kind of ArangoDB deployment that is created.
+type DeploymentMode string
+
+const (
+	DeploymentModeCluster        = DeploymentMode("cluster")        // Agents, coordinators & dbservers
+	DeploymentModeSingle         = DeploymentMode("single")         // A single server on a single machine
+	DeploymentModeActiveFailover = DeploymentMode("activefailover") // Agents & a leader/follower pair of single servers
+)
+
+// Validate returns an error if the mode is not a known deployment mode.
+func (m DeploymentMode) Validate() error {
+	switch m {
+	case DeploymentModeCluster, DeploymentModeSingle, DeploymentModeActiveFailover:
+		return nil
+	default:
+		return fmt.Errorf("Unknown deployment mode '%s', expected cluster, single or activefailover", string(m))
+	}
+}
+
+// IsCluster returns true if the mode deploys coordinators & dbservers.
+func (m DeploymentMode) IsCluster() bool {
+	return m == DeploymentModeCluster || m == ""
+}
+
 type MachineState int
 
 const (
@@ -64,7 +120,78 @@ func (s MachineState) String() string {
 	}
 }
 
+// ServerRole is the role of a server on a machine.
+type ServerRole string
+
+const (
+	ServerRoleAgent       = ServerRole("agent")
+	ServerRoleDBServer    = ServerRole("dbserver")
+	ServerRoleCoordinator = ServerRole("coordinator")
+	ServerRoleSingle      = ServerRole("single")
+)
+
+// ResourceLimits describes the CPU and memory limits of a server container.
+type ResourceLimits struct {
+	CPUs   float64 // Number of CPUs the server can use (0 = unchanged)
+	Memory int64   // Number of bytes of memory the server can use (0 = unchanged)
+}
+
+// String returns a human readable description of the limits.
+func (l ResourceLimits) String() string {
+	var parts []string
+	if l.CPUs > 0 {
+		parts = append(parts, fmt.Sprintf("%g CPUs", l.CPUs))
+	}
+	if l.Memory > 0 {
+		parts = append(parts, fmt.Sprintf("%dMB memory", l.Memory/(1024*1024)))
+	}
+	if len(parts) == 0 {
+		return "no limits"
+	}
+	return strings.Join(parts, ", ")
+}
+
+// DiskUsage describes the size & free space (in bytes) of a volume.
+type DiskUsage struct {
+	Total int64
+	Free  int64
+}
+
+// NetworkDegradation describes netem style shaping of the network traffic of a server.
+type NetworkDegradation struct {
+	Latency    time.Duration // Delay added to every packet
+	Jitter     time.Duration // Random variation of the delay (+/-)
+	PacketLoss float64       // Percentage (0-100) of packets that are lost
+}
+
+// String returns a human readable description of the degradation.
+func (d NetworkDegradation) String() string {
+	var parts []string
+	if d.Latency > 0 {
+		if d.Jitter > 0 {
+			parts = append(parts, fmt.Sprintf("%s±%s latency", d.Latency, d.Jitter))
+		} else {
+			parts = append(parts, fmt.Sprintf("%s latency", d.Latency))
+		}
+	}
+	if d.PacketLoss > 0 {
+		parts = append(parts, fmt.Sprintf("%g%% packet loss", d.PacketLoss))
+	}
+	if len(parts) == 0 {
+		return "no degradation"
+	}
+	return strings.Join(parts, ", ")
+}
+
+// NetworkFeatures describes the optional network operations that depend on the version of the network-blocker.
+type NetworkFeatures struct {
+	Partition   bool // DropTrafficBetween & AcceptTrafficBetween are supported
+	Degradation bool // DegradeTraffic & RestoreTraffic are supported
+}
+
 // Machine represents a single "computer" on which an optional agent, a coordinator and a dbserver runs.
+// Outside of cluster mode, a machine runs an optional agent and a single server instead of the
+// coordinator and dbserver.
 type Machine interface {
 	// ID returns a unique identifier for this machine
 	ID() string
@@ -77,12 +204,20 @@ type Machine interface {
 
 	// HasAgent returns true if there is an agent on this machine
 	HasAgent() bool
+	// HasDBServer returns true if there is a dbserver on this machine
+	HasDBServer() bool
+	// HasCoordinator returns true if there is a coordinator on this machine
+	HasCoordinator() bool
+	// HasSingle returns true if there is a single server on this machine
+	HasSingle() bool
 	// AgentURL returns the URL of the agent on this machine.
 	AgentURL() url.URL
 	// DBServerURL returns the URL of the DBServer on this machine.
 	DBServerURL() url.URL
 	// CoordinatorURL returns the URL of the Coordinator on this machine.
 	CoordinatorURL() url.URL
+	// SingleURL returns the URL of the single server on this machine.
+	SingleURL() url.URL
 
 	// LastAgentReadyStatus returns true if the last known agent ready check succeeded.
 	LastAgentReadyStatus() bool
@@ -90,6 +225,8 @@ type Machine interface {
 	LastDBServerReadyStatus() bool
 	// LastCoordinatorReadyStatus returns true if the last known coordinator ready check succeeded.
 	LastCoordinatorReadyStatus() bool
+	// LastSingleReadyStatus returns true if the last known single server ready check succeeded.
+	LastSingleReadyStatus() bool
 
 	// TestAgentStatus checks if the agent on this machine is ready (with a reasonable timeout). If returns nil on ready, error on not ready.
 	TestAgentStatus() error
@@ -97,6 +234,8 @@ type Machine interface {
 	TestDBServerStatus() error
 	// TestCoordinatorStatus checks if the coordinator on this machine is ready (with a reasonable timeout). If returns nil on ready, error on not ready.
 	TestCoordinatorStatus() error
+	// TestSingleStatus checks if the single server on this machine is ready (with a reasonable timeout). If returns nil on ready, error on not ready.
+	TestSingleStatus() error
 
 	// Perform a graceful restart of the agent. This function does NOT wait until the agent is ready again.
 	RestartAgent() error
@@ -104,6 +243,8 @@ type Machine interface {
 	RestartDBServer() error
 	// Perform a graceful restart of the coordinator. This function does NOT wait until the coordinator is ready again.
 	RestartCoordinator() error
+	// Perform a graceful restart of the single server. This function does NOT wait until the single server is ready again.
+	RestartSingle() error
 
 	// Perform a forced restart of the agent. This function does NOT wait until the agent is ready again.
 	KillAgent() error
@@ -111,6 +252,25 @@ type Machine interface {
 	KillDBServer() error
 	// Perform a forced restart of the coordinator. This function does NOT wait until the coordinator is ready again.
 	KillCoordinator() error
+	// Perform a forced restart of the single server. This function does NOT wait until the single server is ready again.
+	KillSingle() error
+
+	// Freeze the agent process (docker pause). The agent stays frozen until ResumeAgent is called.
+	PauseAgent() error
+	// Freeze the dbserver process (docker pause). The dbserver stays frozen until ResumeDBServer is called.
+	PauseDBServer() error
+	// Freeze the coordinator process (docker pause). The coordinator stays frozen until ResumeCoordinator is called.
+	PauseCoordinator() error
+	// Freeze the single server process (docker pause). The single server stays frozen until ResumeSingle is called.
+	PauseSingle() error
+	// Continue the frozen agent process (docker unpause).
+	ResumeAgent() error
+	// Continue the frozen dbserver process (docker unpause).
+	ResumeDBServer() error
+	// Continue the frozen coordinator process (docker unpause).
+	ResumeCoordinator() error
+	// Continue the frozen single server process (docker unpause).
+	ResumeSingle() error
 
 	// Actively reject all network traffic to the agent
 	RejectAgentTraffic() error
@@ -118,6 +278,8 @@ type Machine interface {
 	RejectDBServerTraffic() error
 	// Actively reject all network traffic to the coordinator
 	RejectCoordinatorTraffic() error
+	// Actively reject all network traffic to the single server
+	RejectSingleTraffic() error
 
 	// Silently drop all network traffic to the agent
 	DropAgentTraffic() error
@@ -125,6 +287,8 @@ type Machine interface {
 	DropDBServerTraffic() error
 	// Silently drop all network traffic to the coordinator
 	DropCoordinatorTraffic() error
+	// Silently drop all network traffic to the single server
+	DropSingleTraffic() error
 
 	// Accept all network traffic to the agent
 	AcceptAgentTraffic() error
@@ -132,6 +296,40 @@ type Machine interface {
 	AcceptDBServerTraffic() error
 	// Accept all network traffic to the coordinator
 	AcceptCoordinatorTraffic() error
+	// Accept all network traffic to the single server
+	AcceptSingleTraffic() error
+
+	// ContainerIP returns the IP address of the container running the server with given role on this machine.
+	ContainerIP(role ServerRole) (string, error)
+	// NetworkFeatures returns the optional network operations that the network(-blocker) of this machine supports.
+	NetworkFeatures() (NetworkFeatures, error)
+	// DropTrafficBetween silently drops all network traffic between the server with given role and the given IP addresses (in both directions)
+	DropTrafficBetween(role ServerRole, ips []string) error
+	// AcceptTrafficBetween accepts all network traffic between the server with given role and the given IP addresses (in both directions)
+	AcceptTrafficBetween(role ServerRole, ips []string) error
+	// DegradeTraffic adds latency, jitter and/or packet loss to all network traffic of the server with given role
+	DegradeTraffic(role ServerRole, d NetworkDegradation) error
+	// RestoreTraffic removes any degradation from the network traffic of the server with given role
+	RestoreTraffic(role ServerRole) error
+
+	// LimitResources changes the CPU and/or memory limits of the container of the server with given role.
+	// The original limits are remembered until RestoreResources is called.
+	LimitResources(role ServerRole, l ResourceLimits) error
+	// RestoreResources restores the original CPU & memory limits of the container of the server with given role.
+	RestoreResources(role ServerRole) error
+
+	// SkewClock shifts the time seen by the server with given role by the given offset (forward or backward).
+	// This requires a server image that preloads libfaketime (see Dockerfile.faketime).
+	SkewClock(role ServerRole, offset time.Duration) error
+	// RestoreClock removes any clock skew from the server with given role.
+	RestoreClock(role ServerRole) error
+
+	// DiskUsage returns the size & free space of the data volume of this machine.
+	DiskUsage() (DiskUsage, error)
+	// FillDisk writes a file of given size (in bytes) on the data volume of this machine.
+	FillDisk(size int64) error
+	// FreeDisk removes the file written by FillDisk.
+	FreeDisk() error
 
 	// CollectMachineLogs collects recent logs from the machine running the servers and writes them to the given writer.
 	CollectMachineLogs(w io.Writer) error
@@ -143,6 +341,8 @@ type Machine interface {
 	CollectDBServerLogs(w io.Writer) error
 	// CollectCoordinatorLogs collects recent logs from the coordinator and writes them to the given writer.
 	CollectCoordinatorLogs(w io.Writer) error
+	// CollectSingleLogs collects recent logs from the single server and writes them to the given writer.
+	CollectSingleLogs(w io.Writer) error
 
 	// CollectNetworkRules fetches all network rules that are involve one of the servers
 	CollectNetworkRules() ([]string, error)
@@ -150,8 +350,30 @@ type Machine interface {
 	// Reboot performs a graceful reboot of the machine
 	Reboot() error
 
+	// ArangoImage returns the arango (database) docker image currently used on this machine
+	ArangoImage() string
+	// Upgrade restarts the machine with the given arango (database) docker image and runs the database auto-upgrade.
+	// This function returns when all servers are ready again.
+	Upgrade(image string) error
+
 	// DestroyAllowed returns true if it is allowed to destroy this machine
 	DestroyAllowed() bool
 	// Remove the machine without the ability to recover it
 	Destroy() error
+	// ReplaceAllowed returns true if it is allowed to replace this machine (see Cluster.Replace)
+	ReplaceAllowed() bool
+}
+
+// ClientURL returns the URL that clients use to reach the database on the given machine.
+// That is the coordinator in cluster mode and the single server otherwise.
+func ClientURL(m Machine) url.URL {
+	if m.HasCoordinator() {
+		return m.CoordinatorURL()
+	}
+	return m.SingleURL()
+}
+
+// HasClientServer returns true if the given machine runs a server that clients can use.
+func HasClientServer(m Machine) bool {
+	return m.HasCoordinator() || m.HasSingle()
 }
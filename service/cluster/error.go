@@ -7,4 +7,11 @@ import (
 var (
 	maskAny      = errors.WithStack
 	TimeoutError = errors.New("timeout")
+	// NotSupportedError is returned by operations that the cluster implementation does not support.
+	NotSupportedError = errors.New("not supported")
 )
+
+// IsNotSupported returns true if the cause of the given error is NotSupportedError.
+func IsNotSupported(err error) bool {
+	return errors.Cause(err) == NotSupportedError
+}
@@ -0,0 +1,357 @@
+package external
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"net"
+	"net/url"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"github.com/arangodb-helper/testagent/pkg/arangostarter"
+	"github.com/arangodb-helper/testagent/service/cluster"
+	logging "github.com/op/go-logging"
+	"golang.org/x/sync/errgroup"
+)
+
+const (
+	serverReadyTimeout = time.Minute * 5
+	discoveryTimeout   = time.Minute
+)
+
+type ExternalConfig struct {
+	StarterEndpoint      string                 // URL of a starter of the deployment. All servers are discovered through the starters
+	AgentEndpoints       []string               // URLs of the agents (used when there is no starter endpoint)
+	DBServerEndpoints    []string               // URLs of the dbservers (used when there is no starter endpoint)
+	CoordinatorEndpoints []string               // URLs of the coordinators (used when there is no starter endpoint)
+	Mode                 cluster.DeploymentMode // Expected deployment mode (defaults to cluster)
+	JWTSecretFile        string                 // If set, all requests are authenticated with the JWT secret in this file
+}
+
+// externalClusterBuilder implements a ClusterBuilder that attaches to an existing deployment.
+type externalClusterBuilder struct {
+	log        *logging.Logger
+	authHeader string // Authorization header derived from the JWT secret (empty without authentication)
+	ExternalConfig
+}
+
+type externalCluster struct {
+	log        *logging.Logger
+	id         string
+	mode       cluster.DeploymentMode
+	authHeader string
+	machines   []*machine
+}
+
+// NewExternalClusterBuilder creates a new ClusterBuilder that attaches to the existing deployment
+// reachable at the configured endpoints.
+// The testagent does not control the servers of such a deployment, so only the database API can be used.
+func NewExternalClusterBuilder(log *logging.Logger, config ExternalConfig) (cluster.ClusterBuilder, error) {
+	hasServerEndpoints := len(config.AgentEndpoints)+len(config.DBServerEndpoints)+len(config.CoordinatorEndpoints) > 0
+	if config.StarterEndpoint == "" && !hasServerEndpoints {
+		return nil, maskAny(fmt.Errorf("StarterEndpoint or server endpoints missing"))
+	}
+	if config.StarterEndpoint != "" && hasServerEndpoints {
+		return nil, maskAny(fmt.Errorf("StarterEndpoint and server endpoints cannot be used together"))
+	}
+	if config.Mode == "" {
+		config.Mode = cluster.DeploymentModeCluster
+	}
+	if err := config.Mode.Validate(); err != nil {
+		return nil, maskAny(err)
+	}
+	if !config.Mode.IsCluster() && hasServerEndpoints {
+		return nil, maskAny(fmt.Errorf("Servers of a %s deployment can only be discovered through a starter", config.Mode))
+	}
+	var authHeader string
+	if config.JWTSecretFile != "" {
+		secret, err := cluster.ReadJWTSecret(config.JWTSecretFile)
+		if err != nil {
+			return nil, maskAny(fmt.Errorf("Cannot read JWT secret: %v", err))
+		}
+		if authHeader, err = cluster.JWTAuthorizationHeader(secret); err != nil {
+			return nil, maskAny(err)
+		}
+	}
+	return &externalClusterBuilder{
+		log:            log,
+		authHeader:     authHeader,
+		ExternalConfig: config,
+	}, nil
+}
+
+// Create attaches to the existing deployment.
+// The agency size & one shard setting are determined by the deployment, so the given values are ignored.
+// This function returns when all servers of the deployment are ready (or an error occurs)
+func (cb *externalClusterBuilder) Create(agencySize int, forceOneShard bool) (cluster.Cluster, error) {
+	// Create random ID
+	b := make([]byte, 4)
+	if _, err := rand.Read(b); err != nil {
+		return nil, maskAny(err)
+	}
+
+	var machines []*machine
+	var err error
+	if cb.StarterEndpoint != "" {
+		machines, err = cb.discoverStarterMachines()
+	} else {
+		machines, err = cb.endpointMachines()
+	}
+	if err != nil {
+		return nil, maskAny(err)
+	}
+	if mode := deploymentMode(machines); mode != cb.Mode {
+		return nil, maskAny(fmt.Errorf("The deployment at the given endpoints is in %s mode, expected %s", mode, cb.Mode))
+	}
+	c := &externalCluster{
+		log:        cb.log,
+		id:         hex.EncodeToString(b),
+		mode:       cb.Mode,
+		authHeader: cb.authHeader,
+		machines:   machines,
+	}
+	if err := c.WaitUntilReady(); err != nil {
+		return nil, maskAny(err)
+	}
+	for _, m := range machines {
+		m.watchdog()
+	}
+	return c, nil
+}
+
+// Resume attaches to the deployment again.
+// Nothing needs to be stored about an external deployment, so this is the same as Create.
+func (cb *externalClusterBuilder) Resume() (cluster.Cluster, error) {
+	c, err := cb.Create(0, false)
+	if err != nil {
+		return nil, maskAny(err)
+	}
+	return c, nil
+}
+
+// discoverStarterMachines fetches the servers launched by all starters of the deployment.
+// Every starter results in a single machine.
+func (cb *externalClusterBuilder) discoverStarterMachines() ([]*machine, error) {
+	ep, err := parseEndpoint(cb.StarterEndpoint)
+	if err != nil {
+		return nil, maskAny(err)
+	}
+	client, err := arangostarter.NewArangoStarterClient(ep)
+	if err != nil {
+		return nil, maskAny(err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), discoveryTimeout)
+	defer cancel()
+	endpoints, err := client.Endpoints(ctx)
+	if err != nil {
+		return nil, maskAny(fmt.Errorf("Cannot fetch endpoints from starter %s: %v", ep.String(), err))
+	}
+	var machines []*machine
+	for i, starterEndpoint := range endpoints.Starters {
+		starterURL, err := parseEndpoint(starterEndpoint)
+		if err != nil {
+			return nil, maskAny(err)
+		}
+		client, err := arangostarter.NewArangoStarterClient(starterURL)
+		if err != nil {
+			return nil, maskAny(err)
+		}
+		processes, err := client.Processes(ctx)
+		if err != nil {
+			return nil, maskAny(fmt.Errorf("Cannot fetch processes from starter %s: %v", starterURL.String(), err))
+		}
+		if !processes.ServersStarted {
+			return nil, maskAny(fmt.Errorf("Servers of starter %s are not yet started", starterURL.String()))
+		}
+		m := cb.newMachine(i)
+		m.starter = &starterURL
+		for _, sp := range processes.Servers {
+			var role cluster.ServerRole
+			switch sp.Type {
+			case arangostarter.ServerTypeAgent:
+				role = cluster.ServerRoleAgent
+			case arangostarter.ServerTypeDBServer:
+				role = cluster.ServerRoleDBServer
+			case arangostarter.ServerTypeCoordinator:
+				role = cluster.ServerRoleCoordinator
+			case arangostarter.ServerTypeSingle, arangostarter.ServerTypeResilientSingle:
+				role = cluster.ServerRoleSingle
+			default:
+				continue // Sync masters & workers are not tested
+			}
+			scheme := "http"
+			if sp.IsSecure {
+				scheme = "https"
+			}
+			m.servers[role] = &server{url: url.URL{
+				Scheme: scheme,
+				Host:   net.JoinHostPort(sp.IP, strconv.Itoa(sp.Port)),
+			}}
+		}
+		machines = append(machines, m)
+	}
+	if len(machines) == 0 {
+		return nil, maskAny(fmt.Errorf("Starter %s does not know any starters", ep.String()))
+	}
+	return machines, nil
+}
+
+// endpointMachines groups the configured server endpoints into machines.
+// Machine i contains the i-th agent, dbserver & coordinator (as far as they exist), just like
+// the servers launched by a single starter.
+func (cb *externalClusterBuilder) endpointMachines() ([]*machine, error) {
+	count := max(len(cb.AgentEndpoints), len(cb.DBServerEndpoints), len(cb.CoordinatorEndpoints))
+	machines := make([]*machine, 0, count)
+	for i := 0; i < count; i++ {
+		m := cb.newMachine(i)
+		for role, endpoints := range map[cluster.ServerRole][]string{
+			cluster.ServerRoleAgent:       cb.AgentEndpoints,
+			cluster.ServerRoleDBServer:    cb.DBServerEndpoints,
+			cluster.ServerRoleCoordinator: cb.CoordinatorEndpoints,
+		} {
+			if i < len(endpoints) {
+				u, err := parseEndpoint(endpoints[i])
+				if err != nil {
+					return nil, maskAny(err)
+				}
+				m.servers[role] = &server{url: u}
+			}
+		}
+		machines = append(machines, m)
+	}
+	return machines, nil
+}
+
+// newMachine creates a machine (without servers) with given index.
+func (cb *externalClusterBuilder) newMachine(index int) *machine {
+	return &machine{
+		index:      index,
+		log:        cb.log,
+		authHeader: cb.authHeader,
+		servers:    make(map[cluster.ServerRole]*server),
+		createdAt:  time.Now(),
+		state:      int32(cluster.MachineStateStarted),
+	}
+}
+
+// parseEndpoint parses an endpoint URL. The arangod notation (tcp://, ssl://) is accepted as well.
+func parseEndpoint(endpoint string) (url.URL, error) {
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return url.URL{}, maskAny(err)
+	}
+	switch u.Scheme {
+	case "http", "https":
+	case "tcp":
+		u.Scheme = "http"
+	case "ssl":
+		u.Scheme = "https"
+	default:
+		return url.URL{}, maskAny(fmt.Errorf("Invalid endpoint '%s', expected http(s)://<host>:<port>", endpoint))
+	}
+	u.Path = ""
+	return *u, nil
+}
+
+// deploymentMode returns the mode of a deployment consisting of the given machines.
+func deploymentMode(machines []*machine) cluster.DeploymentMode {
+	hasAgent, hasSingle := false, false
+	for _, m := range machines {
+		hasAgent = hasAgent || m.HasAgent()
+		hasSingle = hasSingle || m.HasSingle()
+	}
+	switch {
+	case hasSingle && hasAgent:
+		return cluster.DeploymentModeActiveFailover
+	case hasSingle:
+		return cluster.DeploymentModeSingle
+	default:
+		return cluster.DeploymentModeCluster
+	}
+}
+
+// ID returns a unique identifier for this cluster
+func (c *externalCluster) ID() string {
+	return c.id
+}
+
+// Mode returns the deployment mode of this cluster
+func (c *externalCluster) Mode() cluster.DeploymentMode {
+	return c.mode
+}
+
+// ArangoImage returns an empty string, since the image used by an external cluster is unknown.
+func (c *externalCluster) ArangoImage() string {
+	return ""
+}
+
+// UpgradeImage returns an empty string, since external clusters are not upgraded.
+func (c *externalCluster) UpgradeImage() string {
+	return ""
+}
+
+// Managed returns false, since the servers of an external cluster are not controlled by the testagent.
+func (c *externalCluster) Managed() bool {
+	return false
+}
+
+// VolatileData returns false, since the machines of an external cluster are never stopped by the testagent.
+func (c *externalCluster) VolatileData() bool {
+	return false
+}
+
+// AuthorizationHeader returns the value of the Authorization header needed to talk to the servers
+// of this cluster, or empty if the servers do not require authentication
+func (c *externalCluster) AuthorizationHeader() string {
+	return c.authHeader
+}
+
+// Machines returns all current machines in the cluster.
+func (c *externalCluster) Machines() ([]cluster.Machine, error) {
+	result := make([]cluster.Machine, 0, len(c.machines))
+	for _, m := range c.machines {
+		result = append(result, m)
+	}
+	return result, nil
+}
+
+// WaitUntilReady blocks until all servers on all machines are ready.
+func (c *externalCluster) WaitUntilReady() error {
+	g := errgroup.Group{}
+	for _, m := range c.machines {
+		m := m // Used in nested func
+		g.Go(func() error {
+			return m.waitUntilServersReady(c.log, serverReadyTimeout)
+		})
+	}
+	if err := g.Wait(); err != nil {
+		return maskAny(err)
+	}
+	return nil
+}
+
+// Add is not supported, since the machines of an external cluster are not managed by the testagent.
+func (c *externalCluster) Add() (cluster.Machine, error) {
+	return nil, maskAny(errNotSupported)
+}
+
+// Replace is not supported, since the machines of an external cluster are not managed by the testagent.
+func (c *externalCluster) Replace(m cluster.Machine) (cluster.Machine, error) {
+	return nil, maskAny(errNotSupported)
+}
+
+// StartMetricsCollection is not supported, since metrics are collected from docker containers.
+func (c *externalCluster) StartMetricsCollection() error {
+	return maskAny(errNotSupported)
+}
+
+// Destroy detaches from the cluster. The deployment itself keeps running.
+func (c *externalCluster) Destroy() error {
+	for _, m := range c.machines {
+		atomic.StoreInt32(&m.state, int32(cluster.MachineStateDestroyed))
+	}
+	return nil
+}
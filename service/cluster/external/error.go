@@ -0,0 +1,9 @@
+package external
+
+import (
+	"github.com/pkg/errors"
+)
+
+var (
+	maskAny = errors.WithStack
+)
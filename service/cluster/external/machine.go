@@ -0,0 +1,500 @@
+package external
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sync/atomic"
+	"time"
+
+	"github.com/arangodb-helper/testagent/service/cluster"
+	logging "github.com/op/go-logging"
+	"github.com/pkg/errors"
+	"golang.org/x/sync/errgroup"
+)
+
+const (
+	testStatusTimeout = time.Second * 20
+)
+
+var (
+	// serverTransport is shared by all requests to servers & starters, such that connections are reused.
+	serverTransport = cluster.NewHTTPTransport()
+	// errNotSupported is returned by all operations that need control over the machines & servers.
+	errNotSupported = errors.Wrap(cluster.NotSupportedError, "the servers of an external cluster are not managed by the testagent")
+)
+
+// server describes a single server of an external machine.
+type server struct {
+	url       url.URL
+	lastReady int32
+}
+
+// machine is a group of servers of an external cluster, typically those launched by a single starter.
+type machine struct {
+	index      int
+	log        *logging.Logger
+	authHeader string
+	starter    *url.URL // URL of the starter that launched the servers (nil if unknown)
+	servers    map[cluster.ServerRole]*server
+	createdAt  time.Time
+	state      int32 // cluster.MachineState
+}
+
+// ID returns a unique identifier for this machine
+func (m *machine) ID() string {
+	for _, role := range []cluster.ServerRole{cluster.ServerRoleCoordinator, cluster.ServerRoleSingle, cluster.ServerRoleDBServer, cluster.ServerRoleAgent} {
+		if s, found := m.servers[role]; found {
+			return fmt.Sprintf("m%d-%s", m.index, s.url.Host)
+		}
+	}
+	return fmt.Sprintf("m%d", m.index)
+}
+
+// State returns the current state of the machine
+func (m *machine) State() cluster.MachineState {
+	return cluster.MachineState(atomic.LoadInt32(&m.state))
+}
+
+// CreatedAt returns the time when the testagent attached to this machine
+func (m *machine) CreatedAt() time.Time {
+	return m.createdAt
+}
+
+// StartedAt returns the time when the testagent attached to this machine
+func (m *machine) StartedAt() time.Time {
+	return m.createdAt
+}
+
+// HasAgent returns true if there is an agent on this machine
+func (m *machine) HasAgent() bool {
+	return m.has(cluster.ServerRoleAgent)
+}
+
+// HasDBServer returns true if there is a dbserver on this machine
+func (m *machine) HasDBServer() bool {
+	return m.has(cluster.ServerRoleDBServer)
+}
+
+// HasCoordinator returns true if there is a coordinator on this machine
+func (m *machine) HasCoordinator() bool {
+	return m.has(cluster.ServerRoleCoordinator)
+}
+
+// HasSingle returns true if there is a single server on this machine
+func (m *machine) HasSingle() bool {
+	return m.has(cluster.ServerRoleSingle)
+}
+
+// AgentURL returns the URL of the agent on this machine.
+func (m *machine) AgentURL() url.URL {
+	return m.serverURL(cluster.ServerRoleAgent)
+}
+
+// DBServerURL returns the URL of the DBServer on this machine.
+func (m *machine) DBServerURL() url.URL {
+	return m.serverURL(cluster.ServerRoleDBServer)
+}
+
+// CoordinatorURL returns the URL of the Coordinator on this machine.
+func (m *machine) CoordinatorURL() url.URL {
+	return m.serverURL(cluster.ServerRoleCoordinator)
+}
+
+// SingleURL returns the URL of the single server on this machine.
+func (m *machine) SingleURL() url.URL {
+	return m.serverURL(cluster.ServerRoleSingle)
+}
+
+// LastAgentReadyStatus returns true if the last known agent ready check succeeded.
+func (m *machine) LastAgentReadyStatus() bool {
+	return m.lastReadyStatus(cluster.ServerRoleAgent)
+}
+
+// LastDBServerReadyStatus returns true if the last known dbserver ready check succeeded.
+func (m *machine) LastDBServerReadyStatus() bool {
+	return m.lastReadyStatus(cluster.ServerRoleDBServer)
+}
+
+// LastCoordinatorReadyStatus returns true if the last known coordinator ready check succeeded.
+func (m *machine) LastCoordinatorReadyStatus() bool {
+	return m.lastReadyStatus(cluster.ServerRoleCoordinator)
+}
+
+// LastSingleReadyStatus returns true if the last known single server ready check succeeded.
+func (m *machine) LastSingleReadyStatus() bool {
+	return m.lastReadyStatus(cluster.ServerRoleSingle)
+}
+
+// TestAgentStatus checks if the agent on this machine is ready (with a reasonable timeout). If returns nil on ready, error on not ready.
+func (m *machine) TestAgentStatus() error {
+	return maskAny(m.testServer(nil, cluster.ServerRoleAgent, testStatusTimeout))
+}
+
+// TestDBServerStatus checks if the dbserver on this machine is ready (with a reasonable timeout). If returns nil on ready, error on not ready.
+func (m *machine) TestDBServerStatus() error {
+	return maskAny(m.testServer(nil, cluster.ServerRoleDBServer, testStatusTimeout))
+}
+
+// TestCoordinatorStatus checks if the coordinator on this machine is ready (with a reasonable timeout). If returns nil on ready, error on not ready.
+func (m *machine) TestCoordinatorStatus() error {
+	return maskAny(m.testServer(nil, cluster.ServerRoleCoordinator, testStatusTimeout))
+}
+
+// TestSingleStatus checks if the single server on this machine is ready (with a reasonable timeout). If returns nil on ready, error on not ready.
+func (m *machine) TestSingleStatus() error {
+	return maskAny(m.testServer(nil, cluster.ServerRoleSingle, testStatusTimeout))
+}
+
+// The servers of an external cluster are not managed by the testagent, so none of the operations
+// below that introduce chaos or change the machine are supported.
+
+func (m *machine) RestartAgent() error {
+	return maskAny(errNotSupported)
+}
+
+func (m *machine) RestartDBServer() error {
+	return maskAny(errNotSupported)
+}
+
+func (m *machine) RestartCoordinator() error {
+	return maskAny(errNotSupported)
+}
+
+func (m *machine) RestartSingle() error {
+	return maskAny(errNotSupported)
+}
+
+func (m *machine) KillAgent() error {
+	return maskAny(errNotSupported)
+}
+
+func (m *machine) KillDBServer() error {
+	return maskAny(errNotSupported)
+}
+
+func (m *machine) KillCoordinator() error {
+	return maskAny(errNotSupported)
+}
+
+func (m *machine) KillSingle() error {
+	return maskAny(errNotSupported)
+}
+
+func (m *machine) PauseAgent() error {
+	return maskAny(errNotSupported)
+}
+
+func (m *machine) PauseDBServer() error {
+	return maskAny(errNotSupported)
+}
+
+func (m *machine) PauseCoordinator() error {
+	return maskAny(errNotSupported)
+}
+
+func (m *machine) PauseSingle() error {
+	return maskAny(errNotSupported)
+}
+
+func (m *machine) ResumeAgent() error {
+	return maskAny(errNotSupported)
+}
+
+func (m *machine) ResumeDBServer() error {
+	return maskAny(errNotSupported)
+}
+
+func (m *machine) ResumeCoordinator() error {
+	return maskAny(errNotSupported)
+}
+
+func (m *machine) ResumeSingle() error {
+	return maskAny(errNotSupported)
+}
+
+func (m *machine) RejectAgentTraffic() error {
+	return maskAny(errNotSupported)
+}
+
+func (m *machine) RejectDBServerTraffic() error {
+	return maskAny(errNotSupported)
+}
+
+func (m *machine) RejectCoordinatorTraffic() error {
+	return maskAny(errNotSupported)
+}
+
+func (m *machine) RejectSingleTraffic() error {
+	return maskAny(errNotSupported)
+}
+
+func (m *machine) DropAgentTraffic() error {
+	return maskAny(errNotSupported)
+}
+
+func (m *machine) DropDBServerTraffic() error {
+	return maskAny(errNotSupported)
+}
+
+func (m *machine) DropCoordinatorTraffic() error {
+	return maskAny(errNotSupported)
+}
+
+func (m *machine) DropSingleTraffic() error {
+	return maskAny(errNotSupported)
+}
+
+func (m *machine) AcceptAgentTraffic() error {
+	return maskAny(errNotSupported)
+}
+
+func (m *machine) AcceptDBServerTraffic() error {
+	return maskAny(errNotSupported)
+}
+
+func (m *machine) AcceptCoordinatorTraffic() error {
+	return maskAny(errNotSupported)
+}
+
+func (m *machine) AcceptSingleTraffic() error {
+	return maskAny(errNotSupported)
+}
+
+func (m *machine) ContainerIP(role cluster.ServerRole) (string, error) {
+	return "", maskAny(errNotSupported)
+}
+
+func (m *machine) NetworkFeatures() (cluster.NetworkFeatures, error) {
+	return cluster.NetworkFeatures{}, maskAny(errNotSupported)
+}
+
+func (m *machine) DropTrafficBetween(role cluster.ServerRole, ips []string) error {
+	return maskAny(errNotSupported)
+}
+
+func (m *machine) AcceptTrafficBetween(role cluster.ServerRole, ips []string) error {
+	return maskAny(errNotSupported)
+}
+
+func (m *machine) DegradeTraffic(role cluster.ServerRole, d cluster.NetworkDegradation) error {
+	return maskAny(errNotSupported)
+}
+
+func (m *machine) RestoreTraffic(role cluster.ServerRole) error {
+	return maskAny(errNotSupported)
+}
+
+func (m *machine) LimitResources(role cluster.ServerRole, l cluster.ResourceLimits) error {
+	return maskAny(errNotSupported)
+}
+
+func (m *machine) RestoreResources(role cluster.ServerRole) error {
+	return maskAny(errNotSupported)
+}
+
+func (m *machine) SkewClock(role cluster.ServerRole, offset time.Duration) error {
+	return maskAny(errNotSupported)
+}
+
+func (m *machine) RestoreClock(role cluster.ServerRole) error {
+	return maskAny(errNotSupported)
+}
+
+func (m *machine) DiskUsage() (cluster.DiskUsage, error) {
+	return cluster.DiskUsage{}, maskAny(errNotSupported)
+}
+
+func (m *machine) FillDisk(size int64) error {
+	return maskAny(errNotSupported)
+}
+
+func (m *machine) FreeDisk() error {
+	return maskAny(errNotSupported)
+}
+
+func (m *machine) Reboot() error {
+	return maskAny(errNotSupported)
+}
+
+func (m *machine) Upgrade(image string) error {
+	return maskAny(errNotSupported)
+}
+
+func (m *machine) DestroyAllowed() bool {
+	return false
+}
+
+func (m *machine) Destroy() error {
+	return maskAny(errNotSupported)
+}
+
+func (m *machine) ReplaceAllowed() bool {
+	return false
+}
+
+func (m *machine) CollectNetworkRules() ([]string, error) {
+	return nil, maskAny(errNotSupported)
+}
+
+// ArangoImage returns an empty string, since the image used by an external cluster is unknown.
+func (m *machine) ArangoImage() string {
+	return ""
+}
+
+// CollectMachineLogs is not supported, since the machine is not managed by the testagent.
+func (m *machine) CollectMachineLogs(w io.Writer) error {
+	return maskAny(errNotSupported)
+}
+
+// CollectNetworkLogs is not supported, since there is no network-blocker on an external machine.
+func (m *machine) CollectNetworkLogs(w io.Writer) error {
+	return maskAny(errNotSupported)
+}
+
+// CollectAgentLogs collects recent logs from the agent and writes them to the given writer.
+func (m *machine) CollectAgentLogs(w io.Writer) error {
+	return maskAny(m.collectServerLogs(w, cluster.ServerRoleAgent))
+}
+
+// CollectDBServerLogs collects recent logs from the dbserver and writes them to the given writer.
+func (m *machine) CollectDBServerLogs(w io.Writer) error {
+	return maskAny(m.collectServerLogs(w, cluster.ServerRoleDBServer))
+}
+
+// CollectCoordinatorLogs collects recent logs from the coordinator and writes them to the given writer.
+func (m *machine) CollectCoordinatorLogs(w io.Writer) error {
+	return maskAny(m.collectServerLogs(w, cluster.ServerRoleCoordinator))
+}
+
+// CollectSingleLogs collects recent logs from the single server and writes them to the given writer.
+func (m *machine) CollectSingleLogs(w io.Writer) error {
+	return maskAny(m.collectServerLogs(w, cluster.ServerRoleSingle))
+}
+
+// has returns true if there is a server with given role on this machine.
+func (m *machine) has(role cluster.ServerRole) bool {
+	_, found := m.servers[role]
+	return found
+}
+
+// serverURL returns the URL of the server with given role on this machine (empty if there is none).
+func (m *machine) serverURL(role cluster.ServerRole) url.URL {
+	if s, found := m.servers[role]; found {
+		return s.url
+	}
+	return url.URL{}
+}
+
+// lastReadyStatus returns true if the last ready check of the server with given role succeeded.
+func (m *machine) lastReadyStatus(role cluster.ServerRole) bool {
+	if s, found := m.servers[role]; found {
+		return atomic.LoadInt32(&s.lastReady) == 1
+	}
+	return false
+}
+
+// collectServerLogs fetches the logs of the server with given role through the starter that launched it.
+func (m *machine) collectServerLogs(w io.Writer, role cluster.ServerRole) error {
+	if !m.has(role) {
+		return maskAny(fmt.Errorf("There is no %s on machine %s", role, m.ID()))
+	}
+	if m.starter == nil {
+		// Logs are only available through the starter
+		return maskAny(errNotSupported)
+	}
+	ep := *m.starter
+	ep.Path = "/logs/" + string(role)
+	resp, err := m.get(&http.Client{Transport: serverTransport}, ep.String())
+	if err != nil {
+		return maskAny(err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return maskAny(fmt.Errorf("Invalid status; expected %d, got %d", http.StatusOK, resp.StatusCode))
+	}
+	if _, err := io.Copy(w, resp.Body); err != nil {
+		return maskAny(err)
+	}
+	return nil
+}
+
+// get performs a GET request on the given URL, authenticated when the servers require it.
+func (m *machine) get(client *http.Client, url string) (*http.Response, error) {
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		return nil, maskAny(err)
+	}
+	if m.authHeader != "" {
+		req.Header.Set("Authorization", m.authHeader)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, maskAny(err)
+	}
+	return resp, nil
+}
+
+// testServer waits until the server with given role responds to a version request, or the timeout expires.
+func (m *machine) testServer(log *logging.Logger, role cluster.ServerRole, timeout time.Duration) error {
+	s, found := m.servers[role]
+	if !found {
+		return maskAny(fmt.Errorf("There is no %s on machine %s", role, m.ID()))
+	}
+	if log != nil {
+		log.Debugf("Waiting for %s-%d on %s to get ready", role, m.index, s.url.String())
+	}
+	start := time.Now()
+	client := &http.Client{Timeout: time.Second * 5, Transport: serverTransport}
+	versionURL := s.url
+	versionURL.Path = "/_api/version"
+	for {
+		r, e := m.get(client, versionURL.String())
+		if e == nil {
+			r.Body.Close()
+		}
+		if e == nil && r.StatusCode == http.StatusOK {
+			atomic.StoreInt32(&s.lastReady, 1)
+			if log != nil {
+				log.Debugf("%s-%d on %s is ready", role, m.index, s.url.String())
+			}
+			return nil
+		}
+
+		atomic.StoreInt32(&s.lastReady, 0)
+		if time.Since(start) > timeout {
+			return maskAny(errors.Wrapf(cluster.TimeoutError, "%s-%d on %s is not ready in time", role, m.index, s.url.String()))
+		}
+		time.Sleep(time.Millisecond * 500)
+	}
+}
+
+// waitUntilServersReady blocks until all servers on the machine are ready.
+func (m *machine) waitUntilServersReady(log *logging.Logger, timeout time.Duration) error {
+	g := errgroup.Group{}
+	for role := range m.servers {
+		role := role // Used in nested func
+		g.Go(func() error {
+			return m.testServer(log, role, timeout)
+		})
+	}
+	if err := g.Wait(); err != nil {
+		return maskAny(err)
+	}
+	atomic.CompareAndSwapInt32(&m.state, int32(cluster.MachineStateStarted), int32(cluster.MachineStateReady))
+	return nil
+}
+
+// watchdog monitors all servers and updates their last ready flag until the testagent detaches.
+func (m *machine) watchdog() {
+	for role := range m.servers {
+		role := role // Used in nested func
+		go func() {
+			for m.State() != cluster.MachineStateDestroyed {
+				m.testServer(nil, role, time.Minute)
+				time.Sleep(time.Second * 15)
+			}
+		}()
+	}
+}
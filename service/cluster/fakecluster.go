@@ -49,6 +49,18 @@ func (m *FakeMachine) HasAgent() bool {
 	return m.index < m.fc.fcb.NrAgents
 }
 
+func (m *FakeMachine) HasDBServer() bool {
+	return m.index < m.fc.fcb.NrDBServers
+}
+
+func (m *FakeMachine) HasCoordinator() bool {
+	return m.index < m.fc.fcb.NrCoordinators
+}
+
+func (m *FakeMachine) HasSingle() bool {
+	return false
+}
+
 func (m *FakeMachine) AgentURL() url.URL {
 	return url.URL{
 		Scheme: "http",
@@ -70,6 +82,10 @@ func (m *FakeMachine) CoordinatorURL() url.URL {
 	}
 }
 
+func (m *FakeMachine) SingleURL() url.URL {
+	return url.URL{}
+}
+
 func (m *FakeMachine) LastAgentReadyStatus() bool {
 	return true
 }
@@ -82,6 +98,10 @@ func (m *FakeMachine) LastCoordinatorReadyStatus() bool {
 	return true
 }
 
+func (m *FakeMachine) LastSingleReadyStatus() bool {
+	return false
+}
+
 func (m *FakeMachine) TestAgentStatus() error {
 	return nil
 }
@@ -94,6 +114,10 @@ func (m *FakeMachine) TestCoordinatorStatus() error {
 	return nil
 }
 
+func (m *FakeMachine) TestSingleStatus() error {
+	return errors.New("No single server")
+}
+
 func (m *FakeMachine) RestartAgent() error {
 	return nil
 }
@@ -106,6 +130,10 @@ func (m *FakeMachine) RestartCoordinator() error {
 	return nil
 }
 
+func (m *FakeMachine) RestartSingle() error {
+	return nil
+}
+
 func (m *FakeMachine) KillAgent() error {
 	return nil
 }
@@ -118,6 +146,42 @@ func (m *FakeMachine) KillCoordinator() error {
 	return nil
 }
 
+func (m *FakeMachine) KillSingle() error {
+	return nil
+}
+
+func (m *FakeMachine) PauseAgent() error {
+	return nil
+}
+
+func (m *FakeMachine) PauseDBServer() error {
+	return nil
+}
+
+func (m *FakeMachine) PauseCoordinator() error {
+	return nil
+}
+
+func (m *FakeMachine) PauseSingle() error {
+	return nil
+}
+
+func (m *FakeMachine) ResumeAgent() error {
+	return nil
+}
+
+func (m *FakeMachine) ResumeDBServer() error {
+	return nil
+}
+
+func (m *FakeMachine) ResumeCoordinator() error {
+	return nil
+}
+
+func (m *FakeMachine) ResumeSingle() error {
+	return nil
+}
+
 func (m *FakeMachine) RejectAgentTraffic() error {
 	return nil
 }
@@ -130,6 +194,10 @@ func (m *FakeMachine) RejectCoordinatorTraffic() error {
 	return nil
 }
 
+func (m *FakeMachine) RejectSingleTraffic() error {
+	return nil
+}
+
 func (m *FakeMachine) DropAgentTraffic() error {
 	return nil
 }
@@ -142,6 +210,10 @@ func (m *FakeMachine) DropCoordinatorTraffic() error {
 	return nil
 }
 
+func (m *FakeMachine) DropSingleTraffic() error {
+	return nil
+}
+
 func (m *FakeMachine) AcceptAgentTraffic() error {
 	return nil
 }
@@ -154,6 +226,62 @@ func (m *FakeMachine) AcceptCoordinatorTraffic() error {
 	return nil
 }
 
+func (m *FakeMachine) AcceptSingleTraffic() error {
+	return nil
+}
+
+func (m *FakeMachine) ContainerIP(role ServerRole) (string, error) {
+	return "127.0.0.1", nil
+}
+
+func (m *FakeMachine) NetworkFeatures() (NetworkFeatures, error) {
+	return NetworkFeatures{Partition: true, Degradation: true}, nil
+}
+
+func (m *FakeMachine) DropTrafficBetween(role ServerRole, ips []string) error {
+	return nil
+}
+
+func (m *FakeMachine) AcceptTrafficBetween(role ServerRole, ips []string) error {
+	return nil
+}
+
+func (m *FakeMachine) DegradeTraffic(role ServerRole, d NetworkDegradation) error {
+	return nil
+}
+
+func (m *FakeMachine) RestoreTraffic(role ServerRole) error {
+	return nil
+}
+
+func (m *FakeMachine) LimitResources(role ServerRole, l ResourceLimits) error {
+	return nil
+}
+
+func (m *FakeMachine) RestoreResources(role ServerRole) error {
+	return nil
+}
+
+func (m *FakeMachine) SkewClock(role ServerRole, offset time.Duration) error {
+	return nil
+}
+
+func (m *FakeMachine) RestoreClock(role ServerRole) error {
+	return nil
+}
+
+func (m *FakeMachine) DiskUsage() (DiskUsage, error) {
+	return DiskUsage{}, nil
+}
+
+func (m *FakeMachine) FillDisk(size int64) error {
+	return nil
+}
+
+func (m *FakeMachine) FreeDisk() error {
+	return nil
+}
+
 func (m *FakeMachine) CollectMachineLogs(w io.Writer) error {
 	_, err := w.Write([]byte("FakeLog\n"))
 	return err
@@ -179,6 +307,11 @@ func (m *FakeMachine) CollectCoordinatorLogs(w io.Writer) error {
 	return err
 }
 
+func (m *FakeMachine) CollectSingleLogs(w io.Writer) error {
+	_, err := w.Write([]byte("FakeLog\n"))
+	return err
+}
+
 func (m *FakeMachine) CollectNetworkRules() ([]string, error) {
 	return []string{}, nil
 }
@@ -187,6 +320,14 @@ func (m *FakeMachine) Reboot() error {
 	return nil
 }
 
+func (m *FakeMachine) ArangoImage() string {
+	return "none"
+}
+
+func (m *FakeMachine) Upgrade(image string) error {
+	return errors.New("Cannot upgrade")
+}
+
 func (m *FakeMachine) DestroyAllowed() bool {
 	return false
 }
@@ -195,6 +336,10 @@ func (m *FakeMachine) Destroy() error {
 	return errors.New("Cannot destroy")
 }
 
+func (m *FakeMachine) ReplaceAllowed() bool {
+	return false
+}
+
 type FakeCluster struct {
 	id  string
 	fcb *FakeClusterBuilder
@@ -206,14 +351,38 @@ func (fc *FakeCluster) ID() string {
 	return fc.id
 }
 
+func (fc *FakeCluster) Mode() DeploymentMode {
+	return DeploymentModeCluster
+}
+
+func (fc *FakeCluster) Managed() bool {
+	return true
+}
+
+func (fc *FakeCluster) VolatileData() bool {
+	return false
+}
+
+func (fc *FakeCluster) AuthorizationHeader() string {
+	return ""
+}
+
 func (fc *FakeCluster) Add() (Machine, error) {
 	return nil, errors.New("Cannot add machines to fake clusters")
 }
 
+func (fc *FakeCluster) Replace(m Machine) (Machine, error) {
+	return nil, errors.New("Cannot replace machines in fake clusters")
+}
+
 func (fc *FakeCluster) ArangoImage() string {
 	return "none"
 }
 
+func (fc *FakeCluster) UpgradeImage() string {
+	return ""
+}
+
 func (fc *FakeCluster) WaitUntilReady() error {
 	return nil
 }
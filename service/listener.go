@@ -0,0 +1,29 @@
+package service
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/arangodb-helper/testagent/service/chaos"
+	"github.com/arangodb-helper/testagent/service/reporter"
+	"github.com/arangodb-helper/testagent/service/test"
+)
+
+// testListener is the listener passed to all test scripts.
+// It reports failures to the reporter and forwards fault requests to the chaos monkey.
+type testListener struct {
+	reporter.Reporter
+	service *Service
+}
+
+// InjectFault injects a fault requested by a test script and blocks until it has been applied.
+func (l testListener) InjectFault(ctx context.Context, req test.FaultRequest) error {
+	cm := l.service.ChaosMonkey()
+	if cm == nil {
+		return maskAny(fmt.Errorf("%w: chaos is not enabled", chaos.ErrSkipped))
+	}
+	if err := cm.InjectFault(ctx, req); err != nil {
+		return maskAny(err)
+	}
+	return nil
+}
@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"io"
 	"io/ioutil"
+	"math"
 	"net/http"
 	"net/url"
 	"os"
@@ -142,7 +143,7 @@ func (s *reporter) ReportFailure(f test.Failure) {
 	}
 
 	// Collect recent chaos
-	if err := s.createRecentChaosFile(folder, fileNames); err != nil {
+	if err := s.createRecentChaosFile(folder, fileNames, f.Timestamp); err != nil {
 		s.log.Fatalf("Failed to create chaos monkey log: %#v", err)
 	}
 
@@ -234,46 +235,72 @@ func (s *reporter) collectServerLogs(folder string, fileNames chan string, machi
 				return nil
 			})
 		}
-		g.Go(func() error {
-			// Collect dbserver logs
-			if fileName, err := func() (string, error) {
-				f, err := os.Create(filepath.Join(folder, fmt.Sprintf("%s-dbserver.log", filePrefix)))
-				if err != nil {
-					return "", maskAny(err)
-				}
-				defer f.Close()
-				if err := m.CollectDBServerLogs(f); err != nil {
-					fmt.Fprintf(f, "\nError fetching logs: %#v\n", err)
-					s.log.Errorf("Error fetching dbserver logs: %#v", err)
+		if m.HasDBServer() {
+			g.Go(func() error {
+				// Collect dbserver logs
+				if fileName, err := func() (string, error) {
+					f, err := os.Create(filepath.Join(folder, fmt.Sprintf("%s-dbserver.log", filePrefix)))
+					if err != nil {
+						return "", maskAny(err)
+					}
+					defer f.Close()
+					if err := m.CollectDBServerLogs(f); err != nil {
+						fmt.Fprintf(f, "\nError fetching logs: %#v\n", err)
+						s.log.Errorf("Error fetching dbserver logs: %#v", err)
+					}
+					return f.Name(), nil
+				}(); err != nil {
+					return maskAny(err)
+				} else {
+					fileNames <- fileName
 				}
-				return f.Name(), nil
-			}(); err != nil {
-				return maskAny(err)
-			} else {
-				fileNames <- fileName
-			}
-			return nil
-		})
-		g.Go(func() error {
-			// Collect coordinator logs
-			if fileName, err := func() (string, error) {
-				f, err := os.Create(filepath.Join(folder, fmt.Sprintf("%s-coordinator.log", filePrefix)))
-				if err != nil {
-					return "", maskAny(err)
+				return nil
+			})
+		}
+		if m.HasCoordinator() {
+			g.Go(func() error {
+				// Collect coordinator logs
+				if fileName, err := func() (string, error) {
+					f, err := os.Create(filepath.Join(folder, fmt.Sprintf("%s-coordinator.log", filePrefix)))
+					if err != nil {
+						return "", maskAny(err)
+					}
+					defer f.Close()
+					if err := m.CollectCoordinatorLogs(f); err != nil {
+						fmt.Fprintf(f, "\nError fetching logs: %#v\n", err)
+						s.log.Errorf("Error fetching coordinator logs: %#v", err)
+					}
+					return f.Name(), nil
+				}(); err != nil {
+					return maskAny(err)
+				} else {
+					fileNames <- fileName
 				}
-				defer f.Close()
-				if err := m.CollectCoordinatorLogs(f); err != nil {
-					fmt.Fprintf(f, "\nError fetching logs: %#v\n", err)
-					s.log.Errorf("Error fetching coordinator logs: %#v", err)
+				return nil
+			})
+		}
+		if m.HasSingle() {
+			g.Go(func() error {
+				// Collect single server logs
+				if fileName, err := func() (string, error) {
+					f, err := os.Create(filepath.Join(folder, fmt.Sprintf("%s-single.log", filePrefix)))
+					if err != nil {
+						return "", maskAny(err)
+					}
+					defer f.Close()
+					if err := m.CollectSingleLogs(f); err != nil {
+						fmt.Fprintf(f, "\nError fetching logs: %#v\n", err)
+						s.log.Errorf("Error fetching single server logs: %#v", err)
+					}
+					return f.Name(), nil
+				}(); err != nil {
+					return maskAny(err)
+				} else {
+					fileNames <- fileName
 				}
-				return f.Name(), nil
-			}(); err != nil {
-				return maskAny(err)
-			} else {
-				fileNames <- fileName
-			}
-			return nil
-		})
+				return nil
+			})
+		}
 		g.Go(func() error {
 			// Collect machine logs
 			if fileName, err := func() (string, error) {
@@ -362,7 +389,8 @@ func (s *reporter) collectTestLogs(folder string, fileNames chan string, tests [
 func (s *reporter) agencyDump(folder string, fileNames chan string, machines []cluster.Machine) error {
 
 	g := errgroup.Group{}
-	client := &http.Client{Timeout: time.Second * 5}
+	client := cluster.NewHTTPClient(time.Second * 5)
+	clus := s.service.Cluster()
 
 	for _, m := range machines {
 		m := m // Used in nested func
@@ -379,7 +407,12 @@ func (s *reporter) agencyDump(folder string, fileNames chan string, machines []c
 
 					configURL := m.AgentURL()
 					configURL.Path = "/_api/agency/state"
-					r, e := client.Get(configURL.String())
+					var r *http.Response
+					req, e := http.NewRequest("GET", configURL.String(), nil)
+					if e == nil {
+						cluster.Authenticate(clus, req)
+						r, e = client.Do(req)
+					}
 
 					if e == nil && r != nil && r.StatusCode == 200 {
 						io.Copy(f, r.Body)
@@ -426,11 +459,22 @@ func (s *reporter) createClusterStateFile(folder string, fileNames chan string,
 				"This machine has no agent",
 			)
 		}
-		lines = append(lines,
-			fmt.Sprintf("DBServer url=%v lastReady=%v", urlStr(m.DBServerURL()), m.LastDBServerReadyStatus()),
-			fmt.Sprintf("Coordinator url=%v lastReady=%v", urlStr(m.CoordinatorURL()), m.LastCoordinatorReadyStatus()),
-			"",
-		)
+		if m.HasDBServer() {
+			lines = append(lines,
+				fmt.Sprintf("DBServer url=%v lastReady=%v", urlStr(m.DBServerURL()), m.LastDBServerReadyStatus()),
+			)
+		}
+		if m.HasCoordinator() {
+			lines = append(lines,
+				fmt.Sprintf("Coordinator url=%v lastReady=%v", urlStr(m.CoordinatorURL()), m.LastCoordinatorReadyStatus()),
+			)
+		}
+		if m.HasSingle() {
+			lines = append(lines,
+				fmt.Sprintf("Single server url=%v lastReady=%v", urlStr(m.SingleURL()), m.LastSingleReadyStatus()),
+			)
+		}
+		lines = append(lines, "")
 
 		lines = append(lines, "Network rules")
 		rules, err := m.CollectNetworkRules()
@@ -449,14 +493,16 @@ func (s *reporter) createClusterStateFile(folder string, fileNames chan string,
 	return nil
 }
 
-// createRecentChaosFile dumps the recent chaos events in a file and adds in the fileNames
-func (s *reporter) createRecentChaosFile(folder string, fileNames chan string) error {
+// createRecentChaosFile dumps the recent chaos events (and the chaos that was active at the
+// time of the failure) in a file and adds in the fileNames
+func (s *reporter) createRecentChaosFile(folder string, fileNames chan string, failureTime time.Time) error {
 	lines := []string{
 		fmt.Sprintf("Recent chaos events at %s", time.Now()),
 		"",
 	}
 	if cm := s.service.ChaosMonkey(); cm != nil {
 		lines = append(lines, fmt.Sprintf("Chaos monkey on=%v", cm.Active()))
+		lines = append(lines, fmt.Sprintf("Chaos seed=%d", cm.Seed()))
 
 		actions := cm.Actions()
 		lines = append(lines, "", "Statistics:")
@@ -465,9 +511,19 @@ func (s *reporter) createRecentChaosFile(folder string, fileNames chan string) e
 				fmt.Sprintf("%s succeeded: %d", a.Name(), a.Succeeded()),
 				fmt.Sprintf("%s failed: %d", a.Name(), a.Failed()),
 				fmt.Sprintf("%s skipped: %d", a.Name(), a.Skipped()),
+				fmt.Sprintf("%s recovery: %s", a.Name(), a.Recovery()),
 			)
 		}
 
+		lines = append(lines, "", fmt.Sprintf("Chaos active at failure time (%s):", failureTime))
+		active := chaos.ActiveEvents(cm.GetRecentEvents(math.MaxInt32), failureTime)
+		if len(active) == 0 {
+			lines = append(lines, "None")
+		}
+		for _, e := range active {
+			lines = append(lines, e.String())
+		}
+
 		lines = append(lines, "", "Recent events:")
 		events := cm.GetRecentEvents(maxChaosEvents)
 		for _, e := range events {
@@ -3,6 +3,7 @@ package server
 import (
 	"net/http"
 	"strconv"
+	"time"
 
 	logging "github.com/op/go-logging"
 	macaron "gopkg.in/macaron.v1"
@@ -48,6 +49,65 @@ func chaosActionDisablePage(ctx *macaron.Context, log *logging.Logger, service S
 	ctx.Redirect("/chaos", http.StatusFound)
 }
 
+func chaosActionWeightPage(ctx *macaron.Context, log *logging.Logger, service Service) {
+	id := ctx.Params("id")
+	weight, err := strconv.Atoi(ctx.Params("weight"))
+	if err != nil {
+		ctx.Redirect("/chaos", http.StatusBadRequest)
+		return
+	}
+	if cm := service.ChaosMonkey(); cm != nil {
+		for _, a := range cm.Actions() {
+			if a.ID() == id {
+				if err := a.SetWeight(weight); err != nil {
+					ctx.Redirect("/chaos", http.StatusBadRequest)
+					return
+				}
+				break
+			}
+		}
+	}
+	ctx.Redirect("/chaos", http.StatusFound)
+}
+
+// chaosSetPacing changes the pacing of chaos.
+// Query parameters that are not given leave the corresponding setting unchanged.
+func chaosSetPacing(ctx *macaron.Context, log *logging.Logger, service Service) {
+	cm := service.ChaosMonkey()
+	if cm == nil {
+		ctx.Redirect("/chaos", http.StatusFound)
+		return
+	}
+	p := cm.Pacing()
+	for name, target := range map[string]*time.Duration{
+		"min-pause":         &p.MinPause,
+		"max-pause":         &p.MaxPause,
+		"skip-pause":        &p.SkipPause,
+		"min-network-fault": &p.MinNetworkFault,
+		"max-network-fault": &p.MaxNetworkFault,
+		"min-fault":         &p.MinFault,
+		"max-fault":         &p.MaxFault,
+	} {
+		value := ctx.Query(name)
+		if value == "" {
+			continue
+		}
+		d, err := time.ParseDuration(value)
+		if err != nil {
+			log.Warningf("Invalid %s '%s': %v", name, value, err)
+			ctx.Redirect("/chaos", http.StatusBadRequest)
+			return
+		}
+		*target = d
+	}
+	if err := cm.SetPacing(p); err != nil {
+		log.Warningf("Failed to set chaos pacing: %v", err)
+		ctx.Redirect("/chaos", http.StatusBadRequest)
+		return
+	}
+	ctx.Redirect("/chaos", http.StatusFound)
+}
+
 func tryGetChaosLevel(ctx *macaron.Context) (int, error) {
 	level, err := strconv.Atoi(ctx.Params("level"))
 	if err != nil {
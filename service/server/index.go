@@ -23,6 +23,7 @@ func indexPage(ctx *macaron.Context, log *logging.Logger, service Service) {
 	cluster := service.Cluster()
 	if cluster != nil {
 		ctx.Data["ID"] = cluster.ID()
+		ctx.Data["Mode"] = cluster.Mode()
 		cms, err := service.Cluster().Machines()
 		if err != nil {
 			showError(ctx, err)
@@ -49,6 +50,9 @@ func indexPage(ctx *macaron.Context, log *logging.Logger, service Service) {
 	chaos := chaosFromCluster(service.ChaosMonkey(), 20)
 	log.Debugf("Showing %d chaos events", len(chaos.Events))
 	ctx.Data["Chaos"] = chaos
+	if scheduler := service.ChaosScheduler(); scheduler != nil {
+		ctx.Data["Schedule"] = scheduler.Status()
+	}
 
 	// Failure reports
 	creports := service.Reports()
@@ -28,6 +28,8 @@ func logsPage(ctx *macaron.Context, log *logging.Logger, service Service) {
 					err = m.CollectDBServerLogs(ctx.Resp)
 				case "coordinator":
 					err = m.CollectCoordinatorLogs(ctx.Resp)
+				case "single":
+					err = m.CollectSingleLogs(ctx.Resp)
 				case "machine":
 					err = m.CollectMachineLogs(ctx.Resp)
 				case "network":
@@ -23,6 +23,7 @@ type Service interface {
 	Cluster() cluster.Cluster
 	Tests() []test.TestScript
 	ChaosMonkey() chaos.ChaosMonkey
+	ChaosScheduler() *chaos.Scheduler
 	Reports() []reporter.FailureReport
 }
 
@@ -80,6 +81,8 @@ func StartHTTPServer(log *logging.Logger, port int, reportDir string, service Se
 	m.Get("/chaos/resume", chaosResumePage)
 	m.Get("/chaos/:id/enable", chaosActionEnablePage)
 	m.Get("/chaos/:id/disable", chaosActionDisablePage)
+	m.Get("/chaos/:id/weight/:weight", chaosActionWeightPage)
+	m.Get("/chaos/pacing", chaosSetPacing)
 	m.Get("/chaos/level/:level", chaosSetLevel)
 	m.Get("/api/reportMessage/:idx", reportMessage)
 
@@ -19,10 +19,15 @@ type Machine struct {
 	AgentURL                   string
 	DBServerURL                string
 	CoordinatorURL             string
+	SingleURL                  string
 	HasAgent                   bool
+	HasDBServer                bool
+	HasCoordinator             bool
+	HasSingle                  bool
 	LastAgentReadyStatus       bool
 	LastDBServerReadyStatus    bool
 	LastCoordinatorReadyStatus bool
+	LastSingleReadyStatus      bool
 }
 
 type Test struct {
@@ -39,8 +44,11 @@ type Chaos struct {
 	Active  bool
 	State   string
 	Level   int
+	Seed    int64
 	Events  []chaos.Event
 	Actions []ChaosAction
+	Faults  []chaos.Fault
+	Pacing  chaos.ChaosPacing
 }
 
 type ChaosAction struct {
@@ -50,6 +58,8 @@ type ChaosAction struct {
 	Failed    int
 	Skipped   int
 	Enabled   bool
+	Weight    int
+	Recovery  chaos.RecoveryStats
 }
 
 type FailureReport struct {
@@ -70,17 +80,23 @@ func machineFromCluster(cm cluster.Machine) Machine {
 	aURL := cm.AgentURL()
 	dURL := cm.DBServerURL()
 	cURL := cm.CoordinatorURL()
+	sURL := cm.SingleURL()
 	return Machine{
 		ID:                         cm.ID(),
 		CreatedAt:                  humanize.Time(cm.CreatedAt()),
 		StartedAt:                  humanize.Time(cm.StartedAt()),
 		HasAgent:                   cm.HasAgent(),
+		HasDBServer:                cm.HasDBServer(),
+		HasCoordinator:             cm.HasCoordinator(),
+		HasSingle:                  cm.HasSingle(),
 		AgentURL:                   aURL.String(),
 		DBServerURL:                dURL.String(),
 		CoordinatorURL:             cURL.String(),
+		SingleURL:                  sURL.String(),
 		LastAgentReadyStatus:       cm.LastAgentReadyStatus(),
 		LastDBServerReadyStatus:    cm.LastDBServerReadyStatus(),
 		LastCoordinatorReadyStatus: cm.LastCoordinatorReadyStatus(),
+		LastSingleReadyStatus:      cm.LastSingleReadyStatus(),
 	}
 }
 
@@ -138,6 +154,9 @@ func chaosFromCluster(cm chaos.ChaosMonkey, maxEvents int) Chaos {
 		chaos.State = cm.State()
 		chaos.Events = cm.GetRecentEvents(maxEvents)
 		chaos.Level = cm.Level()
+		chaos.Seed = cm.Seed()
+		chaos.Faults = cm.Faults()
+		chaos.Pacing = cm.Pacing()
 		for _, a := range cm.Actions() {
 			chaos.Actions = append(chaos.Actions, ChaosAction{
 				ID:        a.ID(),
@@ -146,6 +165,8 @@ func chaosFromCluster(cm chaos.ChaosMonkey, maxEvents int) Chaos {
 				Failed:    a.Failed(),
 				Skipped:   a.Skipped(),
 				Enabled:   a.Enabled(),
+				Weight:    a.Weight(),
+				Recovery:  a.Recovery(),
 			})
 		}
 	}
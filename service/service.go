@@ -1,10 +1,14 @@
 package service
 
 import (
+	"context"
 	"encoding/json"
+	"fmt"
 	"net/http"
 	"os"
+	"path/filepath"
 	"strings"
+	"sync/atomic"
 	"time"
 
 	"github.com/coreos/go-semver/semver"
@@ -28,7 +32,10 @@ type ServiceConfig struct {
 	MetricsDir     string
 	CollectMetrics bool
 	ChaosConfig    chaos.ChaosMonkeyConfig
+	ChaosSchedule  string // Path of a chaos schedule file. If set, the chaos level changes over time according to this schedule
 	EnableTests    []string
+	StateDir       string // If set, the state of the cluster & tests is stored in this directory, such that the run can be resumed
+	Resume         bool   // If set, the cluster & tests of an earlier run (stored in StateDir) are resumed instead of creating a new cluster
 }
 
 type ServiceDependencies struct {
@@ -43,8 +50,10 @@ type Service struct {
 
 	cluster     cluster.Cluster
 	chaosMonkey chaos.ChaosMonkey
+	scheduler   *chaos.Scheduler
 	reporter    reporter.Reporter
 	startedAt   time.Time
+	keepCluster int32 // If set, the cluster is kept (together with its state) when the service stops
 }
 
 // NewService instantiates a new Service from the given config
@@ -63,15 +72,43 @@ func NewService(config ServiceConfig, deps ServiceDependencies) (*Service, error
 // Run performs the tests
 func (s *Service) Run(stopChan chan struct{}, withChaos bool) error {
 	s.startedAt = time.Now()
+
+	// Load chaos schedule (if any)
+	var schedule *chaos.Schedule
+	if withChaos && s.ChaosSchedule != "" {
+		var err error
+		if schedule, err = chaos.LoadSchedule(s.ChaosSchedule); err != nil {
+			return maskAny(err)
+		}
+	}
+	schedulerCtx, stopScheduler := context.WithCancel(context.Background())
+	defer stopScheduler()
+	schedulerDone := make(chan struct{})
+
 	// Start our HTTP server
 	server.StartHTTPServer(s.Logger, s.ServerPort, s.ReportDir, s)
 
-	// Create the cluster
-	s.Logger.Infof("Creating initial cluster (size %d)", s.AgencySize)
-	c, err := s.ClusterBuilder.Create(s.AgencySize, s.ForceOneShard)
-	if err != nil {
+	// Create (or resume) the cluster
+	var c cluster.Cluster
+	if err := s.checkStateDir(); err != nil {
 		return maskAny(err)
 	}
+	if s.Resume {
+		s.Logger.Info("Resuming cluster")
+		var err error
+		if c, err = s.resumeCluster(); err != nil {
+			return maskAny(err)
+		}
+		if err := s.restoreTestStates(); err != nil {
+			return maskAny(err)
+		}
+	} else {
+		s.Logger.Infof("Creating initial cluster (size %d)", s.AgencySize)
+		var err error
+		if c, err = s.ClusterBuilder.Create(s.AgencySize, s.ForceOneShard); err != nil {
+			return maskAny(err)
+		}
+	}
 	s.cluster = c
 
 	// Wait for cluster to become ready
@@ -91,10 +128,14 @@ func (s *Service) Run(stopChan chan struct{}, withChaos bool) error {
 	enterpriseLicense := os.Getenv("ARANGO_ENTERPRISE_LICENSE")
 	if enterpriseLicense != "" {
 		m, _ := c.Machines()
-		host := "http://" + m[0].DBServerURL().Host // Get address of DBServer
+		clientURL := cluster.ClientURL(m[0]) // Get address of coordinator or single server
+		host := clientURL.Scheme + "://" + clientURL.Host
+		client := cluster.NewHTTPClient(0)
 
 		// Perform request to get Arango version
-		response, err := http.Get(host + "/_api/version")
+		versionReq, _ := http.NewRequest("GET", host+"/_api/version", nil)
+		cluster.Authenticate(c, versionReq)
+		response, err := client.Do(versionReq)
 
 		if err != nil {
 			s.Logger.Error("ERROR making request for getting version")
@@ -122,9 +163,12 @@ func (s *Service) Run(stopChan chan struct{}, withChaos bool) error {
 		if supportedVersion.LessThan(*currVersion) {
 
 			// Try to update license
-			client := &http.Client{}
 			req, _ := http.NewRequest("PUT", host+"/_admin/license", strings.NewReader(enterpriseLicense))
-			req.SetBasicAuth("root", "")
+			if c.AuthorizationHeader() != "" {
+				cluster.Authenticate(c, req)
+			} else {
+				req.SetBasicAuth("root", "")
+			}
 			req.ContentLength = int64(len(enterpriseLicense))
 
 			// Perform request to update license
@@ -169,24 +213,51 @@ func (s *Service) Run(stopChan chan struct{}, withChaos bool) error {
 	// Create & start a chaos monkey
 	if withChaos {
 		s.Logger.Info("Creating chaos monkey")
-		s.chaosMonkey = chaos.NewChaosMonkey(s.Logger, s.cluster, s.ChaosConfig)
-		s.chaosMonkey.Start()
+		if s.ChaosConfig.JournalPath == "" {
+			os.MkdirAll(s.ReportDir, 0755)
+			s.ChaosConfig.JournalPath = filepath.Join(s.ReportDir, fmt.Sprintf("chaos-journal-%s.jsonl", c.ID()))
+		}
+		cm, err := chaos.NewChaosMonkey(s.Logger, s.cluster, s.reporter, s.ChaosConfig)
+		if err != nil {
+			return maskAny(err)
+		}
+		s.chaosMonkey = cm
+		if schedule != nil {
+			s.Logger.Infof("Running chaos schedule '%s'", schedule.Name)
+			s.scheduler = chaos.NewScheduler(s.Logger, cm, *schedule)
+			go func() {
+				defer close(schedulerDone)
+				s.scheduler.Run(schedulerCtx)
+			}()
+		} else {
+			close(schedulerDone)
+			s.chaosMonkey.Start()
+		}
 	}
 
 	// Run tests
 	for _, t := range s.Tests() {
 		s.Logger.Infof("Starting test %s", t.Name())
-		if err := t.Start(s.cluster, s.reporter); err != nil {
+		if err := t.Start(s.cluster, testListener{s.reporter, s}); err != nil {
 			return maskAny(err)
 		}
 	}
 
+	// Store the state of the tests regularly
+	stateLoopDone := make(chan struct{})
+	if s.StateDir != "" {
+		go s.saveTestStatesLoop(stateLoopDone)
+	}
+
 	// Wait until stop
 	s.Logger.Info("All tests started, waiting until termination")
 	<-stopChan
+	close(stateLoopDone)
 
 	// Stop introducting chaos
 	if withChaos {
+		stopScheduler()
+		<-schedulerDone
 		s.Logger.Info("Stopping chaos")
 		s.chaosMonkey.Stop()
 		s.chaosMonkey.WaitUntilInactive()
@@ -208,15 +279,32 @@ func (s *Service) Run(stopChan chan struct{}, withChaos bool) error {
 		s.Logger.Errorf("Failed to stop tests: %#v", err)
 	}
 
+	// Keep the cluster for a later resume (if requested)
+	if s.StateDir != "" && atomic.LoadInt32(&s.keepCluster) != 0 {
+		s.saveTestStates()
+		s.Logger.Infof("Keeping cluster %s, use --resume to continue", s.cluster.ID())
+		return nil
+	}
+
 	// Destroy cluster
 	s.Logger.Info("Destroying cluster")
 	if err := s.cluster.Destroy(); err != nil {
 		return maskAny(err)
 	}
+	if s.StateDir != "" {
+		s.removeTestStates()
+	}
 
 	return nil
 }
 
+// KeepCluster lets the service keep the cluster running when it stops, instead of destroying it.
+// The state of the cluster & tests stays in the state directory, such that the run can be resumed.
+// Without a state directory, the cluster is destroyed anyway.
+func (s *Service) KeepCluster() {
+	atomic.StoreInt32(&s.keepCluster, 1)
+}
+
 func (s *Service) StartedAt() time.Time {
 	return s.startedAt
 }
@@ -241,6 +329,11 @@ func (s *Service) ChaosMonkey() chaos.ChaosMonkey {
 	return s.chaosMonkey
 }
 
+// ChaosScheduler returns the scheduler that drives the chaos monkey (nil if there is no schedule).
+func (s *Service) ChaosScheduler() *chaos.Scheduler {
+	return s.scheduler
+}
+
 func (s *Service) Reports() []reporter.FailureReport {
 	return s.reporter.Reports()
 }
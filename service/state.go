@@ -0,0 +1,143 @@
+package service
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	cluster "github.com/arangodb-helper/testagent/service/cluster"
+	"github.com/arangodb-helper/testagent/service/test"
+)
+
+const (
+	stateSaveInterval = time.Second * 30 // Interval at which the state of the tests is stored
+	testStatePrefix   = "test-"
+	testStateSuffix   = ".json"
+)
+
+// checkStateDir returns an error if the state directory contains the state of an earlier run
+// that would be overwritten by a new run.
+func (s *Service) checkStateDir() error {
+	if s.StateDir == "" || s.Resume {
+		return nil
+	}
+	entries, err := os.ReadDir(s.StateDir)
+	if os.IsNotExist(err) {
+		return nil
+	} else if err != nil {
+		return maskAny(err)
+	}
+	if len(entries) > 0 {
+		return maskAny(fmt.Errorf("State directory %s contains the state of an earlier run. Use --resume to continue it or remove the directory", s.StateDir))
+	}
+	return nil
+}
+
+// resumeCluster re-attaches to the cluster of an earlier run.
+func (s *Service) resumeCluster() (cluster.Cluster, error) {
+	resumer, ok := s.ClusterBuilder.(cluster.ClusterResumer)
+	if !ok {
+		return nil, maskAny(fmt.Errorf("The cluster backend cannot resume a cluster"))
+	}
+	c, err := resumer.Resume()
+	if err != nil {
+		return nil, maskAny(err)
+	}
+	return c, nil
+}
+
+// testStatePath returns the path of the file that contains the state of the given test.
+func (s *Service) testStatePath(t test.TestScript) string {
+	return filepath.Join(s.StateDir, testStatePrefix+t.Name()+testStateSuffix)
+}
+
+// restoreTestStates loads the state of all tests that can be resumed.
+// Tests without a stored state start from scratch.
+func (s *Service) restoreTestStates() error {
+	for _, t := range s.Tests() {
+		st, ok := t.(test.StatefulTestScript)
+		if !ok {
+			s.Logger.Warningf("Test %s cannot be resumed, it starts from scratch", t.Name())
+			continue
+		}
+		f, err := os.Open(s.testStatePath(t))
+		if os.IsNotExist(err) {
+			s.Logger.Warningf("No state found for test %s, it starts from scratch", t.Name())
+			continue
+		} else if err != nil {
+			return maskAny(err)
+		}
+		err = st.RestoreState(f)
+		f.Close()
+		if err != nil {
+			return maskAny(fmt.Errorf("Cannot restore state of test %s: %v", t.Name(), err))
+		}
+		s.Logger.Infof("Restored state of test %s", t.Name())
+	}
+	return nil
+}
+
+// saveTestStates stores the state of all tests that can be resumed.
+func (s *Service) saveTestStates() {
+	for _, t := range s.Tests() {
+		if st, ok := t.(test.StatefulTestScript); ok {
+			if err := s.saveTestState(st); err != nil {
+				s.Logger.Errorf("Failed to save state of test %s: %v", t.Name(), err)
+			}
+		}
+	}
+}
+
+// saveTestState stores the state of the given test.
+// The file is replaced atomically, such that a crash never leaves a partial state behind.
+func (s *Service) saveTestState(t test.StatefulTestScript) error {
+	if err := os.MkdirAll(s.StateDir, 0755); err != nil {
+		return maskAny(err)
+	}
+	path := s.testStatePath(t)
+	f, err := os.Create(path + ".tmp")
+	if err != nil {
+		return maskAny(err)
+	}
+	if err := t.SaveState(f); err != nil {
+		f.Close()
+		return maskAny(err)
+	}
+	if err := f.Close(); err != nil {
+		return maskAny(err)
+	}
+	if err := os.Rename(path+".tmp", path); err != nil {
+		return maskAny(err)
+	}
+	return nil
+}
+
+// removeTestStates removes the stored state of all tests and the state directory (if empty).
+func (s *Service) removeTestStates() {
+	entries, err := os.ReadDir(s.StateDir)
+	if err != nil {
+		return
+	}
+	for _, e := range entries {
+		if strings.HasPrefix(e.Name(), testStatePrefix) {
+			os.Remove(filepath.Join(s.StateDir, e.Name()))
+		}
+	}
+	os.Remove(s.StateDir)
+}
+
+// saveTestStatesLoop stores the state of all tests every stateSaveInterval until the given channel is closed.
+func (s *Service) saveTestStatesLoop(done chan struct{}) {
+	ticker := time.NewTicker(stateSaveInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			s.saveTestStates()
+		case <-done:
+			return
+		}
+	}
+}
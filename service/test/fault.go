@@ -0,0 +1,35 @@
+package test
+
+import (
+	"context"
+
+	"github.com/arangodb-helper/testagent/service/cluster"
+)
+
+// FaultKind is a kind of fault a test script can request.
+type FaultKind string
+
+const (
+	FaultRestart     = FaultKind("restart")      // Restart the server
+	FaultKill        = FaultKind("kill")         // Kill the server
+	FaultFreeze      = FaultKind("freeze")       // Freeze the server for a while
+	FaultDropTraffic = FaultKind("drop-traffic") // Drop all network traffic of the server for a while
+)
+
+// FaultRequest asks the chaos monkey to inject a fault on a specific server now.
+type FaultRequest struct {
+	Test     string             // Name of the requesting test
+	Kind     FaultKind          // Kind of fault to inject
+	Role     cluster.ServerRole // Role of the target server
+	Endpoint string             // URL of the target server (e.g. the coordinator serving a cursor)
+	Reason   string             // Why the fault is requested (recorded in the chaos event)
+}
+
+// FaultInjector is implemented by test listeners that can inject faults on request of a test script.
+// Test scripts should check for it using a type assertion on their TestListener.
+type FaultInjector interface {
+	// InjectFault injects the requested fault and blocks until it has been applied.
+	// It returns an error if the fault could not be injected (e.g. because chaos is not active
+	// or the fault budget is exhausted).
+	InjectFault(ctx context.Context, req FaultRequest) error
+}
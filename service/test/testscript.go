@@ -37,6 +37,23 @@ type TestScript interface {
 	CollectLogs(io.Writer) error
 }
 
+// StatefulTestScript is implemented by test scripts that can store their state, such that
+// a restarted testagent can continue them on a resumed cluster (see --resume).
+// The state is a snapshot the test takes in between its actions, and it is saved periodically.
+// After a crash it can therefore miss the changes of the last save interval (including an
+// interrupted action), so a restored test brings its state in line with the database when
+// it starts, before it relies on it.
+type StatefulTestScript interface {
+	TestScript
+
+	// SaveState writes the last snapshot of the state (e.g. the model of the existing documents) to the given writer.
+	// It does not wait for a running test action.
+	SaveState(w io.Writer) error
+
+	// RestoreState reads a state written by SaveState. It must be called before Start.
+	RestoreState(r io.Reader) error
+}
+
 type Failure struct {
 	Timestamp time.Time
 	Message   string
@@ -113,7 +113,7 @@ func baseHeadTmpl() (*asset, error) {
 	return a, nil
 }
 
-var _chaosTmpl = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x9c\x56\xc1\x6e\xe3\x36\x10\xbd\xeb\x2b\x06\xc4\xde\x02\x98\xb1\x93\xd3\x96\x12\xb0\xed\xa6\x45\x81\xa2\x28\x36\xfb\x03\x34\x39\x8e\x08\x53\xa4\x2a\x8e\xbc\x08\x54\xfd\x7b\x41\x4a\x72\x14\x5b\x72\x77\xab\x93\xc8\x79\x9c\x37\x24\xdf\x1b\xa9\xeb\x08\xab\xda\x4a\x42\x60\x7b\x19\x90\x97\x28\x35\x83\x4d\xdf\x67\x99\x90\x50\x36\x78\xc8\x19\x67\xa0\xac\x0c\x21\x67\xad\x81\xbd\x0c\x46\x41\x65\x9c\x81\xc6\xbc\x94\x04\x07\xeb\x25\xa1\x86\x7d\x4b\xe4\x1d\x2b\x7e\x96\xea\x28\xb8\x2c\x32\x51\x6e\x8b\x5f\x4a\xe9\x03\x54\xde\x1d\xf1\x55\xf0\x72\x5b\x64\x99\xa8\x8b\x0c\x00\x60\x1e\x02\x13\xa0\xeb\x36\x69\x6a\xf3\x4c\x92\xb0\xef\x37\x09\xd5\x75\xe6\x00\x63\xe0\x93\x22\x73\xc2\xbe\x4f\x81\xf8\xbc\x55\xa8\x22\x80\xd7\xb2\x0d\x38\x2f\xf6\x46\x99\x7f\x45\x6c\xaa\x73\xa0\x41\x1b\x6e\xa5\x6e\x30\xb4\xd5\xf7\xe6\xfe\x92\xc0\xf3\xe4\x4e\xf7\x7d\x26\x78\x5d\x64\xe2\xe0\x9b\x0a\xa4\x22\xe3\xdd\x39\xbb\xc5\x13\x5a\xce\xa0\x42\x2a\xbd\xce\xd9\x6f\x4f\x5f\x19\x78\x17\xda\x7d\x65\x28\x67\x54\x9a\xb0\x19\x96\x40\x0e\xf3\xd1\xdd\x30\x4a\xeb\x37\x27\x69\x5b\xfc\x89\x45\x52\x61\xe5\x1e\x2d\x1c\x7c\x93\xb3\x14\x64\xe3\x55\xa4\xc1\x47\xc1\x53\x3c\x21\x03\x5a\x54\x04\x4e\x56\x38\x61\xc1\xe8\xf3\xb2\xb4\x03\xe1\xeb\x44\x97\x18\x72\x76\xcf\x86\x7b\xc1\xbf\xa7\xab\xf9\x23\x82\xe1\x1e\xfa\x1e\x86\x7c\xa8\xa7\x6d\x17\xf7\x90\x83\x36\x41\xee\x2d\x42\xda\xae\xe0\x43\xba\xc5\xdc\xdb\x95\xdc\xdb\xc5\xdc\x5b\xc8\xa1\xc1\x40\xb2\x21\xa8\x1b\xaf\x30\x04\x0c\xf0\xd2\x48\x85\x87\xd6\xda\xd7\x9b\x54\xbb\x15\xaa\xdd\x22\xd5\x0e\x72\xd8\xc2\x1d\x1c\x8d\xb5\x6f\x5c\x37\x09\x1e\x56\x08\x1e\x16\x09\x1e\x20\x87\x1d\xdc\x81\xd4\x9a\x37\x58\xf9\x13\xf2\x69\x6b\xdf\x4a\x6f\x11\x2a\xa9\x4a\xe3\xfe\x83\xf3\x71\x85\xf3\x71\x91\xf3\x11\x72\x78\x80\x3b\x30\x8e\x1a\xaf\x5b\x85\xe0\x90\xbe\xf9\xe6\x78\x7d\x53\x82\x0f\xab\xd3\xbb\x71\x75\x4b\x40\xaf\x35\xe6\x6c\x50\x29\x9b\x0a\x78\x46\x62\x45\x26\x78\xd4\x79\xb4\x7b\xb9\x2b\xa2\xa3\x4d\x20\xa3\x82\xe0\xe5\x2e\x4e\xd2\xa0\x86\xb3\x99\x94\xaf\x6a\xa9\x08\x14\x5a\x8b\x1a\x02\x35\xa6\x46\x0d\x09\x36\x69\x90\x62\x6f\x9a\xde\x9b\xe2\xcd\xaa\x54\x16\x9f\x92\x1d\x04\xa7\xf2\xfd\x7c\x64\x6e\xc3\xc2\x7c\xab\x14\xa2\x46\x7d\x1d\xfa\x55\x1a\xbb\x34\xff\x7c\x34\x75\x3d\x0f\x08\x3e\x55\x11\xe7\x52\x6d\x5d\x07\x8d\x74\x2f\x08\x1f\x02\xc1\xc7\x7c\xde\xb8\xbc\x0b\x30\xf6\x97\x8b\xea\x75\xd1\x75\x1f\x02\x6d\xfe\x94\x15\xf6\xbd\xe0\xa4\xdf\x47\xcf\x83\x73\x3f\x8c\xe8\x27\x17\xcf\x46\xf7\x3d\xbc\x8b\xc7\x67\x0c\x5d\xcd\x5f\xf6\xb4\x81\xf6\xf7\xcf\x7d\xcf\x47\x7f\xce\xfb\x1b\x19\xf7\xba\xd2\xdf\x46\xf4\xb9\xc1\xbd\x15\x77\xd1\x45\xa7\xe7\xf3\xb0\xe0\x87\x4a\x42\xf7\x03\x15\x0d\xe0\xa5\x82\x52\xe7\x3d\xd3\x5d\x1d\xee\x40\x78\x96\xc3\xd2\xf9\x0f\x90\x41\x16\xeb\xf1\x51\x1e\x73\xc0\x20\x90\xae\x03\x74\x1a\x52\xff\x4f\x7a\x1e\x4d\xf1\x05\x15\x3a\x9a\x7c\xb6\x62\x8b\xff\x69\x87\xaf\x26\x7e\x7e\x2e\x15\x7c\x69\x92\x9b\x02\xc6\x99\x7e\x9f\x4e\xe8\xe8\xa6\x7c\x71\x13\x29\xe1\x9f\xf8\xc5\xa9\x24\xc5\xc1\xca\x51\xe1\xe8\x86\xef\x3b\xa8\xab\xff\x93\x83\xf7\x84\x4d\xfa\x43\xf9\x37\x00\x00\xff\xff\x89\x78\xb2\xda\xbc\x08\x00\x00")
+var _chaosTmpl = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x02\x03\xbd\x58\x4b\x6f\xe3\x36\x10\xbe\xe7\x57\x10\x42\x0f\x31\xba\x91\xf2\x42\x0f\xa9\x6c\x20\x6d\xb2\x45\x80\x64\xb3\x88\xb3\x2d\xd0\x1b\x23\x51\x16\x11\x89\x72\x49\xca\x89\xeb\xea\xbf\x77\xf8\x90\xac\xb7\x95\x2e\x50\x1d\x12\x91\x33\x9c\x6f\x5e\x9c\x19\x79\xb7\x93\x24\x5d\x27\x58\x12\xe4\xbc\x60\x41\xbc\x98\xe0\xd0\x41\x6e\x51\x1c\x1d\xf9\x18\xc5\x9c\x44\x73\xc7\x73\x50\x90\x60\x21\xe6\x4e\x4e\x11\x70\xd1\x00\xa5\x94\x51\xc4\xe9\x2a\x96\x28\x4a\x32\x38\x1e\xa2\x97\x5c\xca\x8c\x39\x8b\x5f\x70\xf0\xea\x7b\x78\x71\xe4\xc7\x67\x8b\x5f\x63\x9c\x09\x94\x66\xec\x95\x6c\x7d\x0f\x36\x40\xec\x7a\x71\x84\xe0\xa9\x93\x10\x15\x68\xb7\x73\xf5\x96\xbb\x94\x20\xaf\x28\xd0\xb1\x20\x20\x76\xbf\x0d\xab\xa2\x98\xb9\xfa\xf0\x6e\x47\x23\x64\x09\xd7\x81\xa4\x1b\x38\xa0\x09\xea\xd9\x2b\x1e\x28\x06\x6f\x8d\x73\x41\xea\x36\x8c\x68\xff\x55\xf1\x6a\xf5\x0d\x0c\x49\xc4\x98\x68\x4e\x44\x9e\x4e\x95\xfd\xa4\x99\xeb\xc2\x19\x58\x74\xe4\x7b\xe0\x11\x3f\xca\x78\x8a\x30\x98\x92\xb1\x4a\x7a\x42\x36\x24\x01\xef\xa7\x44\xc6\x59\x38\x77\x7e\xbb\x7d\x76\x50\xc6\x44\xfe\x92\x52\x39\x77\x64\x4c\x85\x6b\x8e\xa0\x39\xaa\xaf\x7e\x34\x2b\x7d\xde\xdd\xe0\x24\x27\x3f\x3b\x0a\xd4\x4f\xf0\x0b\x49\x10\x60\xcd\x1d\x4d\x74\x6c\x84\xf4\xe2\xca\xf7\x34\x5d\x73\x0a\x92\x90\x40\x22\x86\x53\x52\xf2\x22\x1a\x56\xc7\xb4\x05\x7e\xb6\xd6\x70\x1a\x61\xee\x9c\x3a\x26\x2e\xe4\xaf\x32\x34\xf7\x8a\x19\x9d\x22\x88\xa6\x91\xa7\x03\xaa\xcd\x5e\x9c\x82\xce\x21\x15\xf8\x25\x21\x48\x9b\xeb\x7b\x46\x5c\xaf\xec\xb3\x01\xd9\x67\xbd\xb2\xcf\x40\x36\x84\x46\x62\x2e\xd1\x9a\x67\x01\x11\x82\x08\xb4\xe2\x38\x20\x51\x9e\x24\xdb\x51\xa8\xf3\x01\xa8\xf3\x5e\xa8\x73\x80\x3a\x03\x87\xbf\xd2\x24\xd9\x63\x8d\x02\x5c\x0c\x00\x5c\xf4\x02\x5c\x00\xc0\x39\x00\xe0\x30\x84\x74\x4b\xb3\x0d\xf1\x4a\xd3\xde\xe2\x0c\x9c\x97\xe2\x20\xa6\xec\x00\xe6\xe5\x00\xe6\x65\x2f\xe6\x25\x60\x5e\x00\x26\x65\x92\x67\x61\x1e\x10\xc4\x88\x7c\xcb\xf8\x6b\x37\x52\xbe\x67\x4e\xeb\x77\xca\xd6\xb9\x44\x72\xbb\x06\x44\x93\xa5\x4e\xa9\xc0\x92\x48\xc8\x1a\xdf\x53\x79\x3e\x90\xee\x6b\x1c\x50\xb6\x6a\xa6\x7b\x3b\x6b\xe1\x7a\x9d\x98\xfb\x6c\xae\x2a\xc2\x91\x24\xdc\xa8\xd5\xc8\xdf\xba\x2a\x92\xbc\x83\x22\x26\x95\xf7\x02\x74\x3a\xd7\x96\x82\xfe\x0d\xf4\x9f\x2a\x8d\xab\xca\xf3\x55\xeb\xe5\x3e\x50\xa6\x21\x8b\xc2\x59\xa0\x93\x51\x0c\xfc\xde\xc0\xd8\x2f\x0f\x62\xe0\xf7\x0a\xa3\x65\xb9\x78\xa5\xeb\xd2\x74\x63\xb4\xda\x99\x64\x73\xed\xa8\x56\xa8\xbe\x3e\xa4\xd1\x12\x78\x87\x54\x52\xce\xb3\x89\x71\x12\xe1\x3c\x81\x08\x7f\xb1\x79\xa2\x97\xd3\x23\xd2\x94\x52\x45\xa6\xb5\x3d\x21\x42\x16\xff\xb3\x3a\x30\x2d\x50\x7d\xd0\xdd\xed\x09\x81\x6b\x43\xf7\x38\xcb\x3a\xe9\x51\xc6\x10\xbe\x0f\xba\xa8\xe5\x9a\xe9\x2e\xf9\x80\x2f\x5a\x3e\x98\x6e\x7b\xc3\xe8\xc9\x55\x00\x46\x84\xf3\x85\x6a\xf7\x54\x48\x1a\x40\x59\x81\x25\x6c\x4a\xd3\x13\xaa\x96\x1a\x64\x29\x94\x06\x89\x02\x92\x24\x50\xa4\x84\xe4\x74\x0d\xff\x35\x5b\xd9\x89\xa4\x1a\x5c\xca\x77\xbe\xd8\x37\x6c\x19\x2f\xae\x75\x99\xf1\x3d\x78\x6d\xec\x2b\xe4\x5c\x74\xf7\xff\x20\xaa\x7d\xf7\xf0\xe7\x41\x00\x43\x08\x09\xbb\xa4\xcf\x98\x26\x7d\xfb\xea\xf6\xac\xfb\x08\x4f\xa0\xee\xd6\x16\xaf\x63\xbc\x59\x79\xe0\xef\x59\x97\xed\x8e\x21\xb1\x65\xc1\x61\xc6\xe5\xfd\x35\xda\xd0\x0c\x26\x39\x30\xb5\x66\x13\xbc\xf1\xea\xcd\xf8\x68\xb7\x43\x1c\xb3\x15\x41\x3f\x08\x89\xae\xe6\xf5\x31\x0a\x8e\x22\x3b\xed\xb4\xbc\x18\x2e\x76\x3b\xe0\x77\xbf\x40\xae\x14\x05\xc8\x0a\x9b\xd4\x6a\x51\x4d\x67\x8a\xfb\x96\xa9\x18\x41\x47\x41\x0d\xba\x7a\x2c\xa9\xb3\xdf\x9e\xb0\x0c\xec\xdd\x4d\x51\x78\x76\x5a\xa8\x4f\x5b\x92\xb2\xed\xc0\xb4\x65\xb9\xab\x71\x6b\xaf\x5c\x6b\xa6\x2b\x9f\x1b\x73\xe0\x43\x2a\x11\xf6\x01\x8d\x0c\x73\x9f\x42\x7a\x0e\xac\xe0\xc6\x9d\xdb\xdb\x3a\x6b\x2a\xbd\xe9\xec\xfd\x8e\xa9\xd1\x08\xa8\x8f\x8d\x2d\x77\x0c\x55\x10\x73\xb0\xac\x17\x17\xb5\x7a\xa1\x94\x33\xb7\xca\xd6\x88\x11\x89\xbd\x15\xa3\xc1\x6d\xab\xc7\xb0\xbf\x0c\x60\x75\x5d\xfb\xf2\xd5\xb0\x98\x6b\x3b\x4c\xb7\xd7\xb7\x9f\xc1\xe6\xf8\x13\x09\x60\x26\xe3\x5b\xf7\x81\x60\x91\x73\xc5\x6d\x0e\x57\x84\xeb\xcd\x4a\x5f\x77\xb8\x06\x1e\x6a\xd1\xa0\x76\x5a\x5a\x99\x97\x27\x36\x1f\xbe\x17\xf2\x8e\x2d\xa1\x72\x0c\x60\x96\xc4\x36\x68\x17\xe2\x1b\xe3\xe6\x55\x5f\xe3\xe3\x96\xa8\x26\x35\xdf\xaf\x66\x83\x02\x1f\xe1\x6f\x82\x8d\x5b\x3b\xf2\x1a\xc4\xac\x5a\xcc\x46\x7c\xd2\x38\x0f\x65\xf0\xf7\xaa\x0a\xd6\xf9\x4d\x1d\x84\xd2\x07\x72\x90\xfe\xe8\xd2\xed\x03\xda\x4d\xfd\x53\x52\xb7\x31\xa1\xc8\xd0\x89\xcc\x77\xa5\x6d\xd3\xff\x77\x6f\xd2\x9a\xf4\x94\x79\xca\x02\xd2\xdd\xfe\xc6\x24\x4d\x26\x16\xfd\xa8\x56\xf3\x8d\xbd\x63\x25\x3f\xb2\x7d\x61\xc0\xf7\x91\x7b\x43\x44\x00\x36\x8f\xf2\x68\xad\xd1\x3f\x6a\x0e\x4a\xb1\x7c\xa6\xfd\x2d\xc4\xa4\x4a\xe4\x6a\x63\xdc\x3b\xf1\x27\xe1\x59\x51\x08\xc2\x21\xb2\x02\x61\x4e\xe0\x93\x0e\x6e\x4a\x99\xb2\x5a\xb2\xe6\x6d\x49\xee\xe4\xca\x60\xec\xcb\xca\xab\xc3\x0d\x39\x44\x98\x2c\x3f\x70\x06\xa2\xfd\x1f\xa3\xbc\x54\x9f\x6b\xdd\xb0\xdd\xb2\x9e\xc9\x60\x28\x23\x9e\x31\x5f\x91\x3e\x21\x1b\x50\xbb\xbb\xfd\x98\x4b\xc8\x4d\xd2\x37\x79\x98\xbb\x32\x31\x5d\x48\x2d\x5d\x34\xd4\x68\xba\x10\x57\xc5\x60\x5a\xa8\x59\x26\x41\x3c\xcc\x08\x61\x15\x6c\x2d\x01\x36\x0e\x45\xb4\x81\x68\x1c\x36\x34\x98\x68\x96\x07\xf3\x89\x5c\x96\x23\xe2\x3e\xc1\x87\xb3\x2d\x3f\xe5\xa2\x24\xdd\x83\x03\x20\xe1\x62\xba\x2e\x8a\x4f\x48\x33\xd4\xb7\xac\x2e\xb3\x71\x9d\x0e\xde\x0a\x83\x65\x54\x57\x6d\xbb\xdc\xb1\x61\xb3\xae\xa8\xad\x4c\xd2\x53\xa6\x7e\x63\x58\x71\x22\x44\x55\x60\x6b\x75\x16\x9c\xc7\x79\xc6\x8b\xe2\xca\x28\x6e\x57\x07\x3a\x0a\x78\x40\x5d\xac\x6b\x35\x63\x16\x05\xaf\x4d\xa6\xc6\x3d\x35\x62\x0b\xcd\xb4\x11\x4b\xfb\x84\x68\x63\x5a\xd5\x87\x1b\x1c\xad\xd3\x65\x26\x5a\x2d\x4b\xb4\xc6\xe6\xc0\x91\x66\x13\xd9\x37\x0a\xf4\x46\x65\x8c\xb2\xda\xf7\xd5\x6c\x7a\x39\x80\xbd\xd6\xaf\xa1\x51\x96\x81\xe2\xfa\xf7\xd0\x7f\x01\xe4\x64\x5f\xf6\x2a\x15\x00\x00")
 
 func chaosTmplBytes() ([]byte, error) {
 	return bindataRead(
@@ -128,12 +128,12 @@ func chaosTmpl() (*asset, error) {
 		return nil, err
 	}
 
-	info := bindataFileInfo{name: "chaos.tmpl", size: 2236, mode: os.FileMode(436), modTime: time.Unix(1486974991, 0)}
+	info := bindataFileInfo{name: "chaos.tmpl", size: 5418, mode: os.FileMode(436), modTime: time.Unix(1486974991, 0)}
 	a := &asset{bytes: bytes, info: info}
 	return a, nil
 }
 
-var _indexTmpl = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\xb4\x57\x5f\x6f\xa4\x36\x10\x7f\xdf\x4f\x31\x42\x79\x2d\xe8\xee\xf1\x44\x90\xd2\xa4\xa7\x8b\x9a\xbb\x9e\x36\x49\xfb\xec\x85\x61\xb1\x6a\xec\x95\x3d\x24\x8d\x5c\xbe\x7b\x65\x03\x1b\xc0\xec\x8a\xf4\x72\xfb\x84\x3d\xff\x98\xdf\xcc\xfc\x98\xb5\x96\xb0\x3e\x08\x46\x08\xd1\x8e\x19\x4c\x2a\x64\x45\x04\x71\xdb\x6e\x36\x69\xf5\x21\xfb\x0b\x45\xae\x6a\x04\x52\xf0\x80\x86\xae\xf6\x28\x29\x4d\xaa\x0f\xd9\x66\x93\x12\xdb\x09\x84\x5c\x30\x63\x2e\xa3\x86\x43\xae\x84\x60\x07\xc3\xe5\x1e\x9e\x50\xbf\x40\xae\xea\x03\xcb\x09\x0c\x69\x7e\xc0\x02\xbc\x7e\x94\x6d\x00\x00\x52\x72\x81\x86\x67\xdd\x3d\x74\x87\x22\xbb\xbd\x49\x13\x2a\xa6\x77\xd6\xc6\xb7\x37\x6d\xfb\x2a\x48\x93\xc1\x2c\xb0\x7f\x3c\x2c\xda\x3f\x1e\x88\xd7\xb8\xd2\xc7\x9f\xa8\x0d\x57\x72\xd1\x51\x2f\xbb\x95\xa5\x5a\xe9\xed\x4a\x33\xb9\x57\xc0\x6b\xb6\xc7\x45\x97\x9d\xc2\xad\x93\x87\x2e\xd3\xc4\x43\xe7\x30\xaf\x3e\x66\xd7\xa2\x31\x84\x3a\x4d\xaa\x8f\x8b\x55\x40\x21\xb0\x78\x2b\xe8\x55\x07\x7a\x35\xbd\xbb\xd6\xc8\x08\x8b\xe4\x9e\x98\x26\x2c\xe6\x6f\x5e\x65\x7d\x3f\x04\x76\x4a\xe9\x82\x4b\x46\x4a\x87\xc2\x9b\x5f\xef\x51\x3f\xe1\x48\x32\x02\x2e\xe9\x5f\xd1\x5a\x70\x88\x20\x5c\xd4\xf0\xe9\x12\xe2\xaf\x2c\xaf\xb8\x44\x03\x6d\xbb\x8c\xf0\xf1\xe0\x7e\xd6\x5e\xd4\xbe\x5d\x26\xb7\x29\x83\x4a\x63\x79\x19\x25\x42\xed\x4d\x72\x54\x4a\xea\xce\x79\x04\xc4\x49\xe0\x65\x74\xa7\xf6\x26\xca\x52\x3e\xa0\x5a\x72\x81\x40\xf8\x0f\x81\x6a\x48\x70\x89\xc0\x73\x25\xa3\x2c\x4d\x78\x96\x26\x2c\x5b\x17\x45\x22\x3d\x2b\xfd\xf7\x31\xca\xb7\xee\x0c\x62\x16\xed\x99\x97\xfc\x54\x80\xb0\x77\xc2\xbc\xfb\xa2\x5d\xd1\x2c\xfd\x24\x54\xed\xeb\x3a\x51\x9d\x86\xb0\x96\x97\x70\x51\xc7\x5f\x98\xf1\xb5\x1e\x2b\x52\x31\xbc\xb2\xb5\x90\x1b\xb3\x45\x56\xbc\x38\xe5\x3b\xd6\x33\x85\xbf\xb9\x27\x46\x8d\x2b\x5c\xb4\x0c\x94\xb5\xce\xc6\xeb\x3f\x6e\xef\xa0\x6d\x87\xae\x5a\x0b\x2c\x73\xea\x3f\x5e\xbc\x79\xe2\x28\x0c\x4e\xd3\xcd\x7e\x09\x74\x64\xb1\x1a\x91\xd1\x50\xbc\x01\x97\x91\x55\x8f\xce\x64\xb8\xd6\x62\x94\xbf\x1a\xbd\x37\x52\xe7\xb3\x1e\xa6\xfd\x0d\x29\x0f\x26\x7d\xbe\xaf\x7c\xb1\x36\xd9\x62\x67\xbc\xc5\x7b\x66\xda\x71\x94\xb5\x80\xb2\x70\x24\x34\xe5\x64\xf7\x6d\x34\x8b\x8c\x4c\x68\x08\x7e\x80\x96\xbf\xb1\x1a\x43\x0e\xed\x80\x0c\xef\xb7\x68\x1a\x41\x0b\x82\xab\x9c\xb8\x92\x66\x25\xe7\x92\xe7\x5c\x9f\xd4\x88\x70\xa7\x55\x76\xd2\x3f\x7e\x87\x0b\x8a\x3f\x33\x2e\x1a\x8d\xb3\xba\x06\xe4\x74\x2c\x97\x83\xc4\x55\x8b\x62\x97\x9c\x33\x1a\x1d\x4e\x57\x79\x6e\x96\x74\xc4\x39\x51\xf6\x06\xab\xca\x3c\x8d\xb1\xaa\xbb\x0d\x31\xc2\x68\xce\xb8\x8e\x1f\x29\x76\xf8\x3e\xe1\x8c\x71\xc7\x0a\xdf\x59\xe3\x36\xa3\x05\x0d\xf7\xeb\xa5\x71\x1c\x2f\x38\x98\x11\xd1\xf8\xb7\x6d\xa4\xe4\x72\xbf\x28\x7b\x1d\x8f\x00\xb9\x03\x6b\x0c\x46\xa3\xa5\x81\xb8\x7c\xe9\xb6\x13\x10\x6c\x87\x62\x01\xd6\x10\x5e\xef\xe5\x14\xa4\x47\xf5\x79\x3d\x61\x81\x38\xcf\xe6\xe9\xb0\xc1\x22\x2c\xf3\xe9\xf4\x34\x9a\xa6\xfe\x3f\xf9\x8d\x72\x13\xec\xe5\x5c\x6a\x41\x5a\xc1\xb7\x60\x61\xcb\x1b\xcd\x4a\xdb\x42\xd9\x3f\x9e\xd0\xec\x27\x36\xdc\x06\x4f\x71\xd0\xe7\xa3\xbf\xf7\x5d\x0c\x1f\xf8\x12\x03\xb9\xf1\x0f\x6f\xbf\xa2\x31\xdd\x8a\x1b\x10\xd3\x41\x69\x5a\x49\x3f\xda\xd3\x4f\x67\x72\x7a\xe3\xb3\xf6\x42\xc7\x0f\xb3\x8d\x7e\x2a\x44\x43\x27\x85\xfd\xab\xb6\x6d\x37\xa1\xc7\x8b\x07\xdd\xc8\xdc\x2d\x50\x6d\x1b\xc7\xf1\xb1\xcb\xc6\x36\x5f\xb6\x58\x3a\xda\x7a\xe2\xf8\x0c\x65\x23\x04\xd4\x43\xde\x2c\x1b\x3a\x21\x0c\x3b\xf5\x35\x38\xf1\x87\xef\x8c\xaa\x8e\xfb\xd6\x56\x7b\x8b\x39\x4a\x82\xbc\x62\xaa\xaf\x78\x7a\xe8\xec\xba\x6f\xc3\x27\xb0\x36\xbe\x76\x52\xb7\xe5\xd1\x30\x58\x3e\xd9\xfe\x3e\xe0\xac\xd7\x91\xf2\x6e\x43\x9a\xa8\xb9\xe4\xa0\xf9\xbe\x22\x28\x85\x72\x20\xc1\xae\x21\x72\x53\xe2\xa7\xf4\x38\x15\xe1\xf6\x34\x73\x1d\x8e\xe8\x19\xdf\x5b\xaf\x3c\x76\x7e\x1c\xb5\x74\xa7\x93\x1e\xae\x69\x84\x28\xbb\x41\x62\x5c\x18\x6f\x96\x26\x87\x9f\x3f\x12\xdd\xc4\xae\xec\x71\xf4\x3d\xde\x15\xe2\xb7\x27\x94\xe7\x1b\x1d\x7d\xa3\xc3\xbf\x50\x2a\x5d\x33\x3a\xd3\xf5\xd8\x13\xc7\x3a\xde\x08\xfe\xfe\x97\x4a\x91\x5b\x9c\xe2\xb6\xdd\xfc\x17\x00\x00\xff\xff\xa1\x35\xcf\x52\x1c\x10\x00\x00")
+var _indexTmpl = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x02\x03\xcd\x58\x5b\x6f\xdb\x36\x14\x7e\xcf\xaf\x20\x84\x02\x5d\x81\x4e\xc2\xfa\x18\x38\x06\xd2\x64\x45\x83\x25\x6d\xe6\x24\x1b\xd0\x37\x5a\x3a\xb2\x88\x51\xa2\x40\x52\x4e\x03\xd7\xff\x7d\x87\x17\x59\x17\xca\x81\xb2\x6e\x58\x0d\xb4\x11\xcf\x8d\x3c\xdf\xb9\x51\xda\xed\x34\x94\x35\xa7\x1a\x48\xb4\xa6\x0a\x92\x02\x68\x16\x91\x78\xbf\x3f\x39\x59\x14\xbf\x2c\xff\x04\x9e\x8a\x12\x88\x16\xe4\x1e\x94\x3e\xdf\x40\xa5\x17\x09\x32\x90\xad\xe9\x9a\x03\x49\x39\x55\xea\x2c\x6a\x18\x49\x05\xe7\xb4\x56\xac\xda\x90\x2d\xc8\x27\x5c\x97\x35\x4d\x35\x51\x5a\xb2\x1a\x32\x62\xe5\xa3\xe5\x09\xc1\xdf\x42\x9b\x8d\xda\x67\xe9\x1e\xdc\x22\x5b\x5e\x5d\x2e\x12\x9d\x0d\x69\xbb\x5d\x7c\x75\xb9\xdf\x77\x0c\x7c\x92\x47\xf4\x1f\xea\x49\xfd\x87\x5a\xb3\x12\x66\xda\xf8\x03\xa4\x62\xa2\x9a\x34\xe4\x79\x57\x55\x2e\x66\x5a\x3b\x97\xb4\xda\x08\xc2\x4a\xba\x81\x49\x93\x4e\xe0\xca\xf0\x67\x9a\xbc\x11\xd9\xb4\x29\xc3\x08\x6d\xe0\xff\x06\x7e\x13\xb7\xe2\xdd\xf2\x82\x37\x4a\x83\xc4\x48\xbe\x9b\x8c\x24\x70\x8e\x01\x7b\x61\xe0\x0a\x17\xb8\x62\x48\xbb\x90\x80\xd9\x95\x25\x77\x9a\x4a\xfc\x3b\x3e\x72\xb1\xf4\x39\x15\xe8\x09\x21\x33\x56\x51\x2d\x64\xc8\xbc\x7c\x7f\x07\x72\x0b\x13\x9c\x3b\x4c\x3f\x0e\x1d\xbd\x87\x60\xe2\x8f\xbe\xdb\x11\x83\x36\x90\x57\x25\x39\x3d\x23\xf1\x0d\x4d\x0b\x56\x81\x22\x98\xf4\x93\x50\x1f\x16\xe6\xb7\xdb\xbd\x2a\x6d\x2a\x0e\xa8\x0b\x4a\x0a\x09\xf9\x59\x94\x70\xb1\x51\xc9\x41\x28\x29\x9d\xf1\x88\x68\xa6\x39\x9c\x45\xd7\xc8\x8e\x96\x0b\xd6\xa2\x9d\x33\x44\x5e\xc3\x57\x4d\x44\xa3\x39\x4a\x12\x96\x8a\x0a\x25\x12\x86\xff\xe8\x72\xde\x2e\x15\xe8\x47\x21\xff\x3a\xec\xf2\xc9\xad\x09\x1f\xed\xf6\xc8\x72\x76\x6c\x83\x30\x99\x42\xbf\x7d\x30\xcf\xf5\xc8\xfd\x24\x14\xf5\xf1\x1e\x88\x0e\xb7\xd8\xed\x58\x8e\x31\x88\x3f\x52\x65\x73\xa0\x2f\xa8\xb3\xf6\xc8\x18\xad\x54\xa9\x15\x46\xee\xc9\x08\x5f\x53\xdf\x85\x2c\x05\x37\xd1\x8d\x09\x5c\x34\x0d\x14\x2a\xa3\x8e\x95\x7f\x58\x5d\xa3\x5c\x9b\x6d\x73\x81\xa5\x46\xfc\xfb\x83\x37\x76\x1c\xb8\x82\xa1\xbb\xcb\x9f\x03\x99\x2a\xeb\x89\xf4\xc0\xea\x55\xc6\x6c\xc8\x7a\x3a\x2f\x00\xae\xa7\xe5\xe1\x1b\x54\xe5\x5c\x10\xd3\x4e\xe9\x07\x83\xb2\xed\x23\xb3\x71\x6c\x15\x5e\x00\x62\xab\xe2\x11\xec\x5a\xd7\x5c\xf8\xb2\xb5\xb2\x1a\x3f\x18\x76\xae\xd3\xce\x46\xce\x89\xbf\x00\x37\xa7\xe0\x51\x6b\xdb\xfa\x5c\xcc\x94\x95\xff\xbf\x11\x73\xc3\x07\xdd\x41\x92\x99\x2e\xc3\x21\x6c\x2e\x54\x6a\x72\x04\x6b\xe4\x90\xef\x98\xc3\x9f\x68\x09\x13\xa3\xd1\xc2\x1e\xd2\x57\xa0\x1a\xae\x27\x18\xe7\xa9\xc6\x6b\x8e\x9a\x39\x4c\xb5\x1d\xa6\xd6\xa9\xde\x24\x1d\x66\x84\xe1\x7e\xfe\x0d\x65\xe3\x0f\x94\xf1\x46\xc2\x28\x0b\x82\xa9\x73\x08\xb0\x81\xc4\xc4\x57\xc7\xc6\x39\xa3\xd4\x5b\x1c\xcf\x8b\xb1\x5a\xe2\x26\xe2\x40\xd8\x2a\xcc\x4a\x8d\xe1\x1e\xcf\x0e\xcf\xd6\x9c\x42\xd0\x21\x1a\x8f\x52\x53\x44\x3a\x36\xf8\x6e\x61\x34\x4a\xfb\x02\xb7\xb4\x31\x89\x3c\x21\x61\x7e\x9e\x1b\xc7\xf1\x84\x81\x51\xa2\xf6\x7f\xab\xa6\xaa\x50\x6f\x92\xd7\x15\x54\x80\x5c\x8d\xdb\x61\x45\x75\xb7\x44\xcd\xaa\x27\x77\xa5\x25\x9c\xae\x81\x4f\xc0\x1a\xc2\x6b\xad\x1c\x83\x74\x12\xda\x63\xad\xe8\x59\x3f\x0d\x36\x90\x9d\xbc\xc0\x3d\xcc\xc5\xa6\xfc\x27\xfe\xf5\x7c\xe3\xf4\xe9\x39\xd7\x02\xb7\xc6\x2e\x4d\xdd\xe7\x7b\xb5\xb2\xdf\x93\xdc\x3f\x1e\x91\xf4\x15\x1b\x5e\xff\x8f\xf5\xa0\x0f\x07\x7b\xff\xee\x9b\xc0\x3d\x9b\xea\x40\xa6\xfc\x43\xea\x0d\x28\xe5\xde\x8b\x82\xc6\x54\x0b\xa9\x67\xb6\x1f\x69\xdb\x8f\x53\x39\x7e\x95\x47\x94\x64\x7c\x3f\x7a\x0d\x1c\x32\xf1\x8c\x47\x99\xfe\xa8\xfb\xbd\xab\xd0\x03\xe1\x5e\x36\x55\x6a\x6e\xc6\xfb\x3d\x96\xe3\x21\xcb\xfa\x3a\x1f\x57\x90\x9b\xb6\xb5\x65\xf0\x48\xf2\x86\x73\x52\xb6\x7e\xd3\x65\x9b\x09\xe1\xb6\x43\x5b\xad\x11\xbb\xb8\xa5\xba\x70\xbd\x6f\x6e\xb4\x57\x90\xe2\x75\x96\xa4\x05\x15\x3e\xe2\x8b\xda\xe9\xb9\xd9\x70\x8a\xe7\x88\x2f\x0c\xd7\x5c\xdf\x35\xfa\xf9\x96\x70\xd8\x02\xef\xe8\xd7\x66\xe9\xd1\xb5\x20\x78\x7a\xd0\xcb\xba\x52\xb3\xdb\x85\xed\xa3\x64\x15\x23\x92\x6d\x0a\x4d\x72\x2e\x0c\x78\x64\xdd\x68\x6d\xaa\xc7\x56\xef\xa1\x5a\xc2\xa9\x3b\x32\x1d\x96\xee\x33\xb6\x57\x56\xb8\x6f\xbc\x1b\xd7\x6b\x99\xb4\xd4\x47\xa6\x0b\x12\xdf\xa5\x05\x64\xcd\xe0\x96\xd3\x92\x9c\xf3\x9d\x00\x79\x8d\x18\x75\xcb\xd7\xde\xf0\x29\xa9\x0b\x8a\x1d\xcf\x7c\xbf\xa8\x32\xf8\x8a\x82\x3f\xe1\xf3\xad\x21\xfa\x34\xaa\x84\x26\xf1\xef\x0d\x03\x3d\xc0\xdb\x23\xed\xed\xbc\x39\x1c\x00\x7b\x7e\x6a\xed\xdd\xd9\x87\x6f\x24\x17\xb2\xa4\xda\x25\x75\x67\xf0\xa1\xd2\x8c\xc7\x57\xea\x0b\x48\x81\x9b\x36\x66\x69\xb4\x2c\x3d\xd0\x72\x08\xbf\x45\x55\x93\x39\x7e\x4f\x1f\xdf\xa7\x94\x5b\x5e\x6a\x1e\x6c\x26\x38\x4a\xd0\xbd\x7a\xf0\xf5\x40\x1d\x06\x2b\x5a\x5e\x82\xc6\xa6\xa3\x6c\x04\x16\x49\xfd\xdf\x77\x1d\xd7\x14\x67\xb6\x11\xb0\x6d\xc4\xe5\xf4\xaf\x5b\xac\x96\x67\x7b\x09\xd8\x5e\x32\x42\x73\xb2\x77\x40\x7c\x09\x2a\x45\x7f\xcc\x59\xda\xfe\x01\xf1\xe7\x46\x9b\xef\x6b\x2e\x2b\xfa\xeb\x37\x41\x47\x38\x5e\xda\xbb\xf1\x47\xbc\x5c\x08\x6d\xde\x17\xcc\x67\xbc\xbf\x01\x60\xa8\xb4\x59\xe2\x13\x00\x00")
 
 func indexTmplBytes() ([]byte, error) {
 	return bindataRead(
@@ -148,7 +148,7 @@ func indexTmpl() (*asset, error) {
 		return nil, err
 	}
 
-	info := bindataFileInfo{name: "index.tmpl", size: 4124, mode: os.FileMode(436), modTime: time.Unix(1486974991, 0)}
+	info := bindataFileInfo{name: "index.tmpl", size: 5090, mode: os.FileMode(436), modTime: time.Unix(1486974991, 0)}
 	a := &asset{bytes: bytes, info: info}
 	return a, nil
 }
@@ -173,7 +173,7 @@ func publicStyleCss() (*asset, error) {
 	return a, nil
 }
 
-var _testTmpl = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x94\x54\xcb\xae\x9b\x30\x10\xdd\xfb\x2b\x46\x88\x35\xe8\x6e\x23\xc7\x52\x5b\xa9\x9b\xaa\x0f\xf5\xf6\x07\x1c\x7b\x12\xac\x82\x41\xf6\x90\x2e\x46\xfe\xf7\xca\x0e\x04\xd2\xdb\xd7\xcd\x2a\x9e\x39\x3e\x8f\x19\x80\x99\x70\x98\x7a\x4d\x08\xd5\x49\x47\x6c\x3b\xd4\xb6\x82\x26\x25\x21\xa4\x75\x57\x30\xbd\x8e\xf1\x58\x11\x46\xaa\x94\x60\xfe\xe1\xa8\x83\x9a\x0e\x47\x68\xbe\x61\xa4\x94\x84\xd4\xd0\x05\x3c\x1f\xab\xb6\x5a\xd1\xb3\x83\x93\x8e\xce\xc0\xe0\xbc\x83\xe0\x2e\x1d\xc1\xb9\x1f\x35\xa1\x85\xd3\x4c\x34\xfa\x4a\xbd\xd5\xe6\xbb\x6c\xb5\x12\xb2\x7b\x52\xcc\x35\x35\x9f\xf4\x80\x29\xc9\xb6\x7b\x52\x42\x4e\x2b\x57\x24\x4d\x58\x29\x01\x00\xf0\x9c\xff\x1f\x40\x30\xbb\x33\xd4\xd4\xbc\x31\xe4\xae\x98\x52\x69\xae\xc5\x2f\x7a\x8e\xce\x5f\x96\x6a\xfe\x4d\xb7\x4a\xd3\x34\x0b\x10\xfb\x88\xbb\x7e\x98\xbd\x77\xfe\x72\x3f\x6f\x89\x72\xec\x76\x67\xae\xcd\x54\xb8\xcf\xf9\x97\x84\xd9\x08\x96\x88\x8b\xaa\xb7\x29\x89\x07\xf5\x42\x67\xc5\x3f\x44\x03\xc6\x79\xf8\x5f\xd5\xaf\x05\x5c\x64\x57\x49\xd9\x4e\x4a\x08\x49\xfa\xd4\xe3\x8e\xc4\x8c\xc3\xa4\x0d\x81\xc1\xbe\x47\x0b\x91\x82\x9b\xd0\x42\x81\x2d\x03\x97\x14\xd4\x36\x16\xb2\xea\xbd\x76\xfd\x1c\x30\x1e\xa0\x18\x5c\x8f\x79\x6d\x64\x97\x3b\xed\x7a\x89\x39\x68\x7f\x41\xa8\x07\x38\x1c\xf3\x6a\x3e\x62\x8c\xfa\x52\xe0\x14\x54\xe6\x63\xae\x87\x72\x39\xa8\xc2\xb0\x59\x2e\x36\x5e\x6b\x1b\xcc\x38\x7b\xc2\x10\xef\xfe\xf3\xf3\xfc\xdb\x2c\x9d\x7a\x77\xc3\xca\x96\xba\xc7\xc6\xf3\x6c\x0c\xa2\x45\xfb\xb2\x95\x03\xef\xeb\x5b\xd8\x5c\x2b\x5a\xcc\xb0\xc4\x36\x4b\xec\x45\x28\xc2\xb2\x75\x49\x61\xcd\xc3\x0c\x26\xc6\xfc\x26\x7d\xfe\x00\xb5\x69\x6e\xfc\x90\x52\xf5\x38\x77\xe6\xda\xdc\x5f\x90\x75\xd2\x0f\xcd\xbb\xe9\x3f\x22\x6e\xdc\x2f\x57\xc5\x0c\xe8\xb3\xe6\x6e\xea\xdb\x1e\xac\xbb\x96\xf3\x2f\xdf\x89\xf3\x38\x12\x86\xf2\xa5\xf8\x19\x00\x00\xff\xff\x4a\xe0\x06\x19\x44\x04\x00\x00")
+var _testTmpl = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x02\x03\x95\x54\xc9\xae\xdb\x30\x0c\xbc\xfb\x2b\x08\x23\x67\x0b\xbd\x06\x8e\x81\xb6\x40\x2f\x45\x17\xf4\xf5\x07\x14\x99\x89\x85\x7a\x83\x45\xbf\x1e\x84\xfc\x7b\x47\xb2\x95\xd8\x7d\x5d\x7d\x49\x48\x0e\x39\x33\x94\x2d\xef\x85\xbb\xb1\xd5\xc2\x94\x9f\xb5\x63\xd5\xb0\xae\x73\x2a\x6e\xb7\x2c\x2b\x6b\xfb\x4c\xa6\xd5\xce\x9d\x72\x61\x27\x79\x95\x79\xff\xdd\x4a\x43\x07\x39\x9e\xa8\xf8\x8a\x1c\x70\xa5\xa6\x66\xe2\xcb\x29\x57\x79\x42\xcf\x96\x30\xcc\x1a\xea\x6c\x6f\x69\xb2\xd7\x46\xe8\xd2\x0e\x60\xa9\xe9\x3c\x8b\x0c\x7d\x5e\xbd\xd1\xe6\x5b\xa9\x74\x95\x95\xcd\xab\xca\xfb\x83\x14\x1f\x75\xc7\xb7\x5b\xa9\x10\x67\xe5\x98\x66\x39\x41\x1b\xa8\x09\xcf\x53\xf8\x7f\x24\xc8\xb0\x17\x88\x28\x5e\x1b\xb1\xcf\xe8\x89\xc5\x94\xfc\xac\x67\x67\xfb\xeb\x9a\x0d\xcf\xb8\x64\x8a\xa2\x58\x81\xdc\x3a\xde\xd4\xa7\xb9\xef\x51\xbf\xc7\x0f\x47\xc1\xb6\xda\x88\x53\x61\x14\x6f\x7d\xfe\xc1\x61\x10\xc2\xd1\xe2\xca\xda\xd7\x20\xdd\xb1\xc7\x71\x75\xf6\x17\xd2\x89\xdd\xdc\xfd\x2b\xeb\x97\x08\x8e\xb4\x89\xb2\x54\x63\x85\xe3\x14\x7d\x6e\x79\x33\xc4\x0c\xdd\xa8\x8d\x90\xe1\xb6\xc5\x00\x27\x93\x1d\xf1\x1b\x61\xeb\xc2\x4b\x99\xaa\xc7\x5a\xa4\xae\xde\x69\xdb\xce\xd0\x73\xa4\x28\x30\x85\xe1\xd8\x50\x5d\x7a\x54\x6a\xf2\x7e\xd2\xfd\x95\xe9\xd0\x11\xde\x17\xc0\x3f\xb0\x73\xfa\x1a\xe1\xc0\x84\x79\x98\xd2\xc5\x66\x84\x2a\xc6\x49\x72\x94\xf1\xbf\xb2\x51\x9c\x7b\xe1\xc9\xdd\xf5\x87\xf7\xf9\x97\x5e\x9a\xea\xed\x82\x05\x55\xb3\x2f\x3c\xcd\xc6\x30\xd7\x5c\xbf\x2c\x05\xc3\xdb\xfc\xc3\x6c\xc8\x45\x2e\xef\x69\xb5\x6d\x56\xdb\x2b\x91\xa3\xf5\xd4\xa1\x24\xf9\x01\xd8\x38\x17\xbe\xa4\x4f\xef\xd1\x50\x2c\xf3\x01\xcc\xf7\x7b\xc7\x9e\xcc\xfd\x03\x49\x9b\xde\x15\xef\xa2\x7f\x8b\x58\x66\xbf\x3c\x2a\x68\xc0\xd2\x69\xb7\xf5\xc7\x39\xe0\x12\x88\xf1\x4f\xf7\xc4\x65\x18\x60\x29\xde\x14\x3f\x00\x4a\xe0\x06\x19\x44\x04\x00\x00")
 
 func testTmplBytes() ([]byte, error) {
 	return bindataRead(
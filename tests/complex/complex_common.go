@@ -64,6 +64,9 @@ type ComplextTest struct {
 	ComplexTestImpl ComplexTestInt
 	TestName        string
 	stop            chan struct{}
+	stateMutex      sync.Mutex  // Protects savedState
+	savedState      interface{} // Snapshot of the state, taken in between test actions
+	resumed         bool        // Set when the state was restored, until it is synced with the database
 	active          bool
 	pauseRequested  bool
 	paused          bool
@@ -78,6 +81,8 @@ type counter struct {
 
 type ComplexTestInt interface {
 	runTest()
+	currentState() interface{}
+	syncState() error
 }
 
 var (
@@ -138,7 +143,8 @@ func (t *ComplextTest) CollectLogs(w io.Writer) error {
 // setupLogger creates a new logger that is backed by stderr AND a file.
 func (t *ComplextTest) setupLogger(cluster cluster.Cluster) error {
 	t.logPath = filepath.Join(t.reportDir, fmt.Sprintf("%s-%s.log", t.Name(), cluster.ID()))
-	logFile, err := os.Create(t.logPath)
+	// Append, since a resumed test continues the log of the same cluster
+	logFile, err := os.OpenFile(t.logPath, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
 	if err != nil {
 		return maskAny(err)
 	}
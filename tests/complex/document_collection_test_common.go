@@ -5,6 +5,7 @@ import (
 )
 
 type DocumentCollectionTest interface {
+	generateCollectionName(seed int64) string
 	createTestDatabase()
 	createTestCollection()
 	createDocuments()
@@ -38,6 +39,15 @@ func (t *DocColTest) runTest() {
 	t.actions = 0
 	defer func() { t.active = false }()
 
+	if t.resumed {
+		if err := t.ComplexTestImpl.syncState(); err != nil {
+			t.log.Errorf("Failed to sync restored state with the database: %v. Giving up", err)
+			return
+		}
+		t.resumed = false
+		t.snapshotState()
+	}
+
 	var plan []int
 	planIndex := 0
 	for {
@@ -93,6 +103,7 @@ func (t *DocColTest) runTest() {
 			t.DocColTestImpl.dropTestDatabase()
 			planIndex++
 		}
+		t.snapshotState()
 		time.Sleep(t.StepTimeout)
 	}
 }
@@ -10,6 +10,9 @@ import (
 )
 
 type GraphTestInt interface {
+	generateVertexCollectionName(seed int64) string
+	generateEdgeCollectionName(seed int64) string
+	generateGraphName(seed int64) string
 	createGraphAndCollections()
 	createVertexDocs()
 	createEdgeDocs()
@@ -270,6 +273,15 @@ func (t *GraphTest) runTest() {
 	t.actions = 0
 	defer func() { t.active = false }()
 
+	if t.resumed {
+		if err := t.ComplexTestImpl.syncState(); err != nil {
+			t.log.Errorf("Failed to sync restored state with the database: %v. Giving up", err)
+			return
+		}
+		t.resumed = false
+		t.snapshotState()
+	}
+
 	var plan []int
 	planIndex := 0
 	for {
@@ -315,6 +327,7 @@ func (t *GraphTest) runTest() {
 			t.GraphTestImpl.dropGraphAndCollections()
 			planIndex++
 		}
+		t.snapshotState()
 		time.Sleep(t.StepTimeout)
 	}
 }
@@ -0,0 +1,347 @@
+package complex
+
+import (
+	"encoding/json"
+	"io"
+	"sort"
+
+	"github.com/arangodb-helper/testagent/tests/util"
+)
+
+// complexTestState is the persisted state shared by all complex tests.
+type complexTestState struct {
+	Failures          int            `json:"failures"`
+	DocumentIdSeq     int64          `json:"document-id-seq"`
+	CollectionNameSeq int64          `json:"collection-name-seq"`
+	ExistingDocuments []TestDocument `json:"existing-documents,omitempty"`
+}
+
+// docColTestState is the persisted state of a document collection test.
+type docColTestState struct {
+	complexTestState
+	NumberOfExistingDocs     int    `json:"number-of-existing-docs"`
+	NumberOfCreatedDocsTotal int64  `json:"number-of-created-docs-total"`
+	DocCollectionCreated     bool   `json:"doc-collection-created"`
+	DocCollectionName        string `json:"doc-collection-name,omitempty"`
+	ReadOffset               int    `json:"read-offset"`
+	UpdateOffset             int    `json:"update-offset"`
+}
+
+// oneShardTestState is the persisted state of the one shard database test.
+type oneShardTestState struct {
+	docColTestState
+	DatabaseName      string `json:"database-name,omitempty"`
+	DatabaseNameSeq   int64  `json:"database-name-seq"`
+	IsDatabaseCreated bool   `json:"is-database-created"`
+}
+
+// graphTestState is the persisted state of a graph test.
+type graphTestState struct {
+	complexTestState
+	GraphCreated            bool           `json:"graph-created"`
+	EdgeColCreated          bool           `json:"edge-col-created"`
+	VertexColCreated        bool           `json:"vertex-col-created"`
+	GraphIsBroken           bool           `json:"graph-is-broken"`
+	VertexColName           string         `json:"vertex-col-name,omitempty"`
+	EdgeColName             string         `json:"edge-col-name,omitempty"`
+	GraphName               string         `json:"graph-name,omitempty"`
+	NumberOfCreatedVertices int64          `json:"number-of-created-vertices"`
+	NumberOfCreatedEdges    int64          `json:"number-of-created-edges"`
+	VertexCreationOffset    int64          `json:"vertex-creation-offset"`
+	EdgeCreationOffset      int64          `json:"edge-creation-offset"`
+	ExistingEdgeDocuments   []TestDocument `json:"existing-edge-documents,omitempty"`
+	ExistingVertexDocuments []TestDocument `json:"existing-vertex-documents,omitempty"`
+}
+
+// complexState returns the state shared by all complex tests.
+// The documents are copied, since the test keeps updating them.
+func (t *ComplextTest) complexState() complexTestState {
+	return complexTestState{
+		Failures:          t.failures,
+		DocumentIdSeq:     t.documentIdSeq,
+		CollectionNameSeq: t.collectionNameSeq,
+		ExistingDocuments: append([]TestDocument(nil), t.existingDocuments...),
+	}
+}
+
+func (t *ComplextTest) restoreComplexState(state complexTestState) {
+	t.failures = state.Failures
+	t.documentIdSeq = state.DocumentIdSeq
+	t.collectionNameSeq = state.CollectionNameSeq
+	t.existingDocuments = append([]TestDocument(nil), state.ExistingDocuments...)
+	t.resumed = true
+}
+
+// snapshotState stores a copy of the current state, to be written by SaveState.
+// It is called by the test loop in between actions.
+func (t *ComplextTest) snapshotState() {
+	state := t.ComplexTestImpl.currentState()
+	t.stateMutex.Lock()
+	defer t.stateMutex.Unlock()
+	t.savedState = state
+}
+
+// setSavedState sets the given (restored) state as the last snapshot.
+func (t *ComplextTest) setSavedState(state interface{}) {
+	t.stateMutex.Lock()
+	defer t.stateMutex.Unlock()
+	t.savedState = state
+}
+
+// SaveState writes the last snapshot of the state to the given writer.
+func (t *ComplextTest) SaveState(w io.Writer) error {
+	t.stateMutex.Lock()
+	defer t.stateMutex.Unlock()
+	return encodeState(w, t.savedState)
+}
+
+func (t *DocColTest) docColState() docColTestState {
+	return docColTestState{
+		complexTestState:         t.complexState(),
+		NumberOfExistingDocs:     t.numberOfExistingDocs,
+		NumberOfCreatedDocsTotal: t.numberOfCreatedDocsTotal,
+		DocCollectionCreated:     t.docCollectionCreated,
+		DocCollectionName:        t.docCollectionName,
+		ReadOffset:               t.readOffset,
+		UpdateOffset:             t.updateOffset,
+	}
+}
+
+func (t *DocColTest) restoreDocColState(state docColTestState) {
+	t.restoreComplexState(state.complexTestState)
+	t.numberOfExistingDocs = state.NumberOfExistingDocs
+	t.numberOfCreatedDocsTotal = state.NumberOfCreatedDocsTotal
+	t.docCollectionCreated = state.DocCollectionCreated
+	t.docCollectionName = state.DocCollectionName
+	t.readOffset = state.ReadOffset
+	t.updateOffset = state.UpdateOffset
+}
+
+func (t *DocColTest) currentState() interface{} {
+	return t.docColState()
+}
+
+// RestoreState reads a state written by SaveState. It must be called before Start.
+// The restored documents are synced with the database when the test starts.
+func (t *DocColTest) RestoreState(r io.Reader) error {
+	var state docColTestState
+	if err := json.NewDecoder(r).Decode(&state); err != nil {
+		return maskAny(err)
+	}
+	t.setSavedState(state)
+	t.restoreDocColState(state)
+	return nil
+}
+
+// syncState replaces the restored documents with those found in the database.
+// The state may be older than the database (e.g. after a crash of the host), so documents
+// created or updated since then (and a collection created or dropped) are not known otherwise.
+func (t *DocColTest) syncState() error {
+	for {
+		name := t.docCollectionName
+		if !t.docCollectionCreated {
+			name = t.DocColTestImpl.generateCollectionName(t.collectionNameSeq)
+		}
+		var docs []TestDocument
+		found, err := util.ReadAllDocuments(t.client, name, t.OperationTimeout, &docs)
+		if err != nil {
+			return maskAny(err)
+		}
+		if !found {
+			if !t.docCollectionCreated {
+				return nil
+			}
+			// Continue with the collection that may have been created after it
+			t.log.Infof("Collection '%s' was dropped after the state was saved", name)
+			t.docCollectionCreated = false
+			t.numberOfExistingDocs = 0
+			t.existingDocuments = t.existingDocuments[:0]
+			t.readOffset = 0
+			t.updateOffset = 0
+			t.collectionNameSeq++
+			continue
+		}
+
+		if !t.docCollectionCreated {
+			t.log.Infof("Collection '%s' was created after the state was saved", name)
+		}
+		if len(docs) != t.numberOfExistingDocs {
+			t.log.Infof("Collection '%s' contains %d documents instead of %d", name, len(docs), t.numberOfExistingDocs)
+		}
+		// Documents are created in order of their seed
+		sort.Slice(docs, func(i, j int) bool { return docs[i].Seed < docs[j].Seed })
+		for _, d := range docs {
+			if d.Seed >= t.documentIdSeq {
+				t.documentIdSeq = d.Seed + 1
+			}
+		}
+		if created := int64(len(docs) - t.numberOfExistingDocs); created > 0 {
+			t.numberOfCreatedDocsTotal += created
+		}
+		t.docCollectionCreated = true
+		t.docCollectionName = name
+		t.existingDocuments = docs
+		t.numberOfExistingDocs = len(docs)
+		if t.readOffset > len(docs) {
+			t.readOffset = 0
+		}
+		if t.updateOffset > len(docs) {
+			t.updateOffset = 0
+		}
+		return nil
+	}
+}
+
+func (t *OneShardTest) currentState() interface{} {
+	return oneShardTestState{
+		docColTestState:   t.docColState(),
+		DatabaseName:      t.databaseName,
+		DatabaseNameSeq:   t.databaseNameSeq,
+		IsDatabaseCreated: t.isDatabaseCreated,
+	}
+}
+
+// RestoreState reads a state written by SaveState. It must be called before Start.
+// The restored database and documents are synced with the database when the test starts.
+func (t *OneShardTest) RestoreState(r io.Reader) error {
+	var state oneShardTestState
+	if err := json.NewDecoder(r).Decode(&state); err != nil {
+		return maskAny(err)
+	}
+	t.setSavedState(state)
+	t.restoreDocColState(state.docColTestState)
+	t.databaseName = state.DatabaseName
+	t.databaseNameSeq = state.DatabaseNameSeq
+	t.isDatabaseCreated = state.IsDatabaseCreated
+	return nil
+}
+
+// syncState checks the restored database against the existing databases and then
+// syncs the documents of its collection.
+func (t *OneShardTest) syncState() error {
+	for {
+		name := t.databaseName
+		if !t.isDatabaseCreated {
+			name = t.generateDatabaseName(t.databaseNameSeq)
+		}
+		exists, err := t.databaseExists(name)
+		if err != nil {
+			return maskAny(err)
+		}
+		if !exists {
+			if !t.isDatabaseCreated {
+				return nil
+			}
+			// Continue with the database that may have been created after it
+			t.log.Infof("Database '%s' was dropped after the state was saved", name)
+			t.isDatabaseCreated = false
+			t.docCollectionCreated = false
+			t.numberOfExistingDocs = 0
+			t.existingDocuments = t.existingDocuments[:0]
+			t.readOffset = 0
+			t.updateOffset = 0
+			t.collectionNameSeq++
+			t.databaseNameSeq++
+			continue
+		}
+
+		if !t.isDatabaseCreated {
+			t.log.Infof("Database '%s' was created after the state was saved", name)
+			t.isDatabaseCreated = true
+			t.databaseName = name
+		}
+		t.client.UseDatabase(t.databaseName)
+		return maskAny(t.DocColTest.syncState())
+	}
+}
+
+func (t *GraphTest) currentState() interface{} {
+	return graphTestState{
+		complexTestState:        t.complexState(),
+		GraphCreated:            t.graphCreated,
+		EdgeColCreated:          t.edgeColCreated,
+		VertexColCreated:        t.vertexColCreated,
+		GraphIsBroken:           t.graphIsBroken,
+		VertexColName:           t.vertexColName,
+		EdgeColName:             t.edgeColName,
+		GraphName:               t.graphName,
+		NumberOfCreatedVertices: t.numberOfCreatedVertices,
+		NumberOfCreatedEdges:    t.numberOfCreatedEdges,
+		VertexCreationOffset:    t.vertexCreationOffset,
+		EdgeCreationOffset:      t.edgeCreationOffset,
+		ExistingEdgeDocuments:   append([]TestDocument(nil), t.existingEdgeDocuments...),
+		ExistingVertexDocuments: append([]TestDocument(nil), t.existingVertexDocuments...),
+	}
+}
+
+// RestoreState reads a state written by SaveState. It must be called before Start.
+// The graph of the restored state is replaced by a new one when the test starts.
+func (t *GraphTest) RestoreState(r io.Reader) error {
+	var state graphTestState
+	if err := json.NewDecoder(r).Decode(&state); err != nil {
+		return maskAny(err)
+	}
+	t.setSavedState(state)
+	t.restoreComplexState(state.complexTestState)
+	t.graphCreated = state.GraphCreated
+	t.edgeColCreated = state.EdgeColCreated
+	t.vertexColCreated = state.VertexColCreated
+	t.graphIsBroken = state.GraphIsBroken
+	t.vertexColName = state.VertexColName
+	t.edgeColName = state.EdgeColName
+	t.graphName = state.GraphName
+	t.numberOfCreatedVertices = state.NumberOfCreatedVertices
+	t.numberOfCreatedEdges = state.NumberOfCreatedEdges
+	t.vertexCreationOffset = state.VertexCreationOffset
+	t.edgeCreationOffset = state.EdgeCreationOffset
+	t.existingEdgeDocuments = append(t.existingEdgeDocuments[:0], state.ExistingEdgeDocuments...)
+	t.existingVertexDocuments = append(t.existingVertexDocuments[:0], state.ExistingVertexDocuments...)
+	return nil
+}
+
+// syncState drops the graph of the restored state (and the one that may have been created after it).
+// Which vertices and edges were created after the state was saved cannot be recovered from
+// the database in the order the traversals rely on, so the test continues with a new graph.
+func (t *GraphTest) syncState() error {
+	seq := t.collectionNameSeq
+	for _, s := range []int64{seq, seq + 1} {
+		graphName := t.GraphTestImpl.generateGraphName(s)
+		if exists, err := t.graphExists(graphName); err != nil {
+			return maskAny(err)
+		} else if exists {
+			t.log.Infof("Dropping graph '%s' of the restored state", graphName)
+			if err := t.dropGraph(graphName, true); err != nil {
+				return maskAny(err)
+			}
+		}
+		for _, colName := range []string{t.GraphTestImpl.generateVertexCollectionName(s), t.GraphTestImpl.generateEdgeCollectionName(s)} {
+			if exists, err := t.collectionExists(colName); err != nil {
+				return maskAny(err)
+			} else if exists {
+				if err := t.dropCollection(colName); err != nil {
+					return maskAny(err)
+				}
+			}
+		}
+	}
+	t.graphCreated = false
+	t.graphIsBroken = false
+	t.vertexColCreated = false
+	t.edgeColCreated = false
+	t.collectionNameSeq = seq + 2
+	t.numberOfCreatedEdges = 0
+	t.numberOfCreatedVertices = 0
+	t.existingVertexDocuments = t.existingVertexDocuments[:0]
+	t.existingEdgeDocuments = t.existingEdgeDocuments[:0]
+	t.edgeCreationOffset = 0
+	t.vertexCreationOffset = 0
+	return nil
+}
+
+// encodeState writes the given state as JSON to the given writer.
+func encodeState(w io.Writer, state interface{}) error {
+	if err := json.NewEncoder(w).Encode(state); err != nil {
+		return maskAny(err)
+	}
+	return nil
+}
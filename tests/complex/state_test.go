@@ -0,0 +1,119 @@
+package complex
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/arangodb-helper/testagent/service/test"
+	"github.com/arangodb-helper/testagent/tests/util"
+)
+
+var (
+	_ test.StatefulTestScript = &RegularDocColTest{}
+	_ test.StatefulTestScript = &OneShardTest{}
+	_ test.StatefulTestScript = &CommunityGraphTest{}
+	_ test.StatefulTestScript = &SmartGraphTest{}
+	_ test.StatefulTestScript = &EnterpriseGraphTest{}
+)
+
+func TestOneShardStateRoundTrip(t *testing.T) {
+	orig := NewMockTest(nil)
+	orig.failures = 2
+	orig.documentIdSeq = 12
+	orig.collectionNameSeq = 3
+	orig.existingDocuments = append(orig.existingDocuments, TestDocument{Seed: 11, Key: "k11", Rev: "_rev11", UpdateCounter: 4})
+	orig.numberOfExistingDocs = 1
+	orig.docCollectionCreated = true
+	orig.docCollectionName = "oneshard_docs_3"
+	orig.readOffset = 1
+	orig.databaseName = "oneshard_db_1"
+	orig.databaseNameSeq = 1
+	orig.isDatabaseCreated = true
+
+	orig.snapshotState()
+	orig.existingDocuments[0].UpdateCounter++ // After the snapshot
+
+	var buf bytes.Buffer
+	if err := orig.SaveState(&buf); err != nil {
+		t.Fatalf("SaveState failed: %v", err)
+	}
+	restored := NewMockTest(nil)
+	if err := restored.RestoreState(&buf); err != nil {
+		t.Fatalf("RestoreState failed: %v", err)
+	}
+
+	if restored.failures != 2 || restored.documentIdSeq != 12 || restored.collectionNameSeq != 3 {
+		t.Errorf("Counters not restored: %d, %d, %d", restored.failures, restored.documentIdSeq, restored.collectionNameSeq)
+	}
+	if len(restored.existingDocuments) != 1 || restored.existingDocuments[0].UpdateCounter != 4 {
+		t.Errorf("Existing documents not restored: %v", restored.existingDocuments)
+	}
+	if !restored.docCollectionCreated || restored.docCollectionName != "oneshard_docs_3" || restored.readOffset != 1 {
+		t.Errorf("Collection state not restored: %v, %s, %d", restored.docCollectionCreated, restored.docCollectionName, restored.readOffset)
+	}
+	if !restored.isDatabaseCreated || restored.databaseName != "oneshard_db_1" || restored.databaseNameSeq != 1 {
+		t.Errorf("Database state not restored: %v, %s, %d", restored.isDatabaseCreated, restored.databaseName, restored.databaseNameSeq)
+	}
+}
+
+func syncOneShardStateBehaviour(
+	ctx context.Context, t *testing.T,
+	requests chan *util.MockRequest, responses chan *util.MockResponse) {
+
+	// Expect the databases to be listed. The database of the state was dropped, the next one created.
+	for _, result := range []string{"oneshard_db_2", "oneshard_db_2"} {
+		req := next(ctx, t, requests, true)
+		if req == nil {
+			return
+		}
+		if req.Method != "GET" || req.UrlPath != "/_api/database" {
+			t.Errorf("Got wrong request %s %s instead of GET /_api/database", req.Method, req.UrlPath)
+		}
+		req.Result.(*DatabasesResponse).Result = []string{"_system", result}
+		responses <- &util.MockResponse{Resp: util.ArangoResponse{StatusCode: 200}}
+	}
+
+	// Expect the documents of the new collection to be read
+	req := next(ctx, t, requests, true)
+	if req == nil {
+		return
+	}
+	if req.Method != "POST" || req.UrlPath != "/_api/cursor" {
+		t.Errorf("Got wrong request %s %s instead of POST /_api/cursor", req.Method, req.UrlPath)
+	}
+	json.Unmarshal([]byte(`{"result":[{"seed":21,"_key":"k21","_rev":"_rev21","update_counter":1},{"seed":20,"_key":"k20","_rev":"_rev20","update_counter":0}]}`), req.Result)
+	responses <- &util.MockResponse{Resp: util.ArangoResponse{StatusCode: 201}}
+
+	next(ctx, t, requests, false)
+}
+
+func TestOneShardSyncState(t *testing.T) {
+	mockClient := util.NewMockClient(t, syncOneShardStateBehaviour)
+	test := NewMockTest(mockClient)
+	test.documentIdSeq = 20
+	test.collectionNameSeq = 1
+	test.existingDocuments = append(test.existingDocuments, TestDocument{Seed: 11, Key: "k11", Rev: "_rev11"})
+	test.numberOfExistingDocs = 1
+	test.docCollectionCreated = true
+	test.docCollectionName = "oneshard_docs_1"
+	test.databaseName = "oneshard_db_1"
+	test.databaseNameSeq = 1
+	test.isDatabaseCreated = true
+
+	if err := test.syncState(); err != nil {
+		t.Fatalf("syncState failed: %v", err)
+	}
+	mockClient.Shutdown()
+
+	if !test.isDatabaseCreated || test.databaseName != "oneshard_db_2" || test.databaseNameSeq != 2 {
+		t.Errorf("Database not synced: %v, %s, %d", test.isDatabaseCreated, test.databaseName, test.databaseNameSeq)
+	}
+	if !test.docCollectionCreated || test.docCollectionName != "oneshard_docs_2" || test.numberOfExistingDocs != 2 {
+		t.Errorf("Collection not synced: %v, %s, %d", test.docCollectionCreated, test.docCollectionName, test.numberOfExistingDocs)
+	}
+	if test.existingDocuments[0].Seed != 20 || test.existingDocuments[1].UpdateCounter != 1 || test.documentIdSeq != 22 {
+		t.Errorf("Documents not synced: %v, next seed %d", test.existingDocuments, test.documentIdSeq)
+	}
+}
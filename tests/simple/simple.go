@@ -19,10 +19,11 @@ import (
 )
 
 type SimpleConfig struct {
-	MaxDocuments     int
-	MaxCollections   int
-	OperationTimeout time.Duration
-	RetryTimeout     time.Duration
+	MaxDocuments          int
+	MaxCollections        int
+	OperationTimeout      time.Duration
+	RetryTimeout          time.Duration
+	CursorFaultPercentage int // Percentage of cursor tests that request a restart of the coordinator serving the cursor
 }
 
 const (
@@ -47,6 +48,9 @@ type simpleTest struct {
 	actions                             int
 	collections                         map[string]*collection
 	collectionsMutex                    sync.Mutex
+	stateMutex                          sync.Mutex      // Protects savedState
+	savedState                          simpleTestState // Snapshot of the state, taken in between test actions
+	resumed                             bool            // Set when the state was restored, until it is synced with the database
 	lastCollectionIndex                 int32
 	collectionToCleanup                 string
 	readExistingCounter                 counter
@@ -224,7 +228,8 @@ func (t *simpleTest) CollectLogs(w io.Writer) error {
 // setupLogger creates a new logger that is backed by stderr AND a file.
 func (t *simpleTest) setupLogger(cluster cluster.Cluster) error {
 	t.logPath = filepath.Join(t.reportDir, fmt.Sprintf("simple-%s.log", cluster.ID()))
-	logFile, err := os.Create(t.logPath)
+	// Append, since a resumed test continues the log of the same cluster
+	logFile, err := os.OpenFile(t.logPath, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
 	if err != nil {
 		return maskAny(err)
 	}
@@ -273,9 +278,21 @@ func (t *simpleTest) testLoop() {
 	t.actions = 0
 	defer func() { t.active = false }()
 
-	if err := t.createAndInitCollection(); err != nil {
-		t.log.Errorf("Failed to create&init first collection: %v. Giving up", err)
-		return
+	if t.resumed {
+		// A resumed test continues with its restored collections
+		if err := t.syncState(); err != nil {
+			t.log.Errorf("Failed to sync restored state with the database: %v. Giving up", err)
+			return
+		}
+		t.resumed = false
+		t.snapshotState()
+	}
+	if len(t.collections) == 0 {
+		if err := t.createAndInitCollection(); err != nil {
+			t.log.Errorf("Failed to create&init first collection: %v. Giving up", err)
+			return
+		}
+		t.snapshotState()
 	}
 
 	var plan []int
@@ -639,6 +656,7 @@ func (t *simpleTest) testLoop() {
 			}
 			planIndex++
 		}
+		t.snapshotState()
 		time.Sleep(time.Second * 2)
 	}
 }
@@ -684,7 +702,7 @@ func ifMatchHeader(hdr map[string]string, rev string) map[string]string {
 // createNewCollectionName returns a new (unique) collection name
 func (t *simpleTest) createNewCollectionName() string {
 	index := atomic.AddInt32(&t.lastCollectionIndex, 1)
-	return fmt.Sprintf("simple_user_%d", index)
+	return fmt.Sprintf("%s%d", collectionNamePrefix, index)
 }
 
 func (t *simpleTest) selectRandomCollection() *collection {
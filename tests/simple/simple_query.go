@@ -1,9 +1,12 @@
 package simple
 
 import (
+	"context"
 	"fmt"
+	"math/rand"
 	"time"
 
+	"github.com/arangodb-helper/testagent/service/cluster"
 	"github.com/arangodb-helper/testagent/service/test"
 	"github.com/arangodb-helper/testagent/tests/util"
 )
@@ -83,6 +86,18 @@ func (t *simpleTest) queryDocuments(c *collection) error {
 		}
 	}
 
+	// Request a restart of the coordinator serving the cursor (if configured), such that
+	// fetching the next batch hits a new coordinator.
+	// Outside of cluster mode the cursor is served by a single server.
+	if cursorResp.HasMore && t.CursorFaultPercentage > 0 && rand.Intn(100) < t.CursorFaultPercentage {
+		role := cluster.ServerRoleCoordinator
+		if !t.cluster.Mode().IsCluster() {
+			role = cluster.ServerRoleSingle
+		}
+		t.requestFault(test.FaultRestart, role, createResp[0].CoordinatorURL,
+			fmt.Sprintf("between creating a cursor in '%s' and fetching its next batch", c.name))
+	}
+
 	// Now continue fetching results.
 	// This may fail if (and only if) the coordinator has changed.
 
@@ -282,3 +297,24 @@ func (t *simpleTest) getUptime(coordinatorURL string) (time.Duration, error) {
 
 	return time.Second * time.Duration(statsResp.Server.Uptime), nil
 }
+
+// requestFault asks the chaos monkey (if available through the listener) to inject a fault
+// on the server with given role & endpoint and waits until it has been applied.
+func (t *simpleTest) requestFault(kind test.FaultKind, role cluster.ServerRole, endpoint, reason string) {
+	injector, ok := t.listener.(test.FaultInjector)
+	if !ok {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), t.OperationTimeout)
+	defer cancel()
+	t.log.Infof("Requesting %s of %s %s %s", kind, role, endpoint, reason)
+	if err := injector.InjectFault(ctx, test.FaultRequest{
+		Test:     t.Name(),
+		Kind:     kind,
+		Role:     role,
+		Endpoint: endpoint,
+		Reason:   reason,
+	}); err != nil {
+		t.log.Infof("Requested %s of %s %s was not injected: %v", kind, role, endpoint, err)
+	}
+}
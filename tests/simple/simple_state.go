@@ -0,0 +1,147 @@
+package simple
+
+import (
+	"encoding/json"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/arangodb-helper/testagent/tests/util"
+)
+
+const (
+	collectionNamePrefix = "simple_user_"
+)
+
+// simpleTestState is the persisted state of the simple test.
+type simpleTestState struct {
+	Failures            int                                `json:"failures"`
+	LastCollectionIndex int32                              `json:"last-collection-index"`
+	CollectionToCleanup string                             `json:"collection-to-cleanup,omitempty"`
+	Collections         map[string]map[string]UserDocument `json:"collections"` // Existing documents per collection name
+}
+
+// snapshotState stores a copy of the current state, to be written by SaveState.
+// It is called by the test loop in between actions.
+func (t *simpleTest) snapshotState() {
+	state := simpleTestState{
+		Failures:            t.failures,
+		LastCollectionIndex: t.lastCollectionIndex,
+		Collections:         make(map[string]map[string]UserDocument),
+	}
+	t.collectionsMutex.Lock()
+	state.CollectionToCleanup = t.collectionToCleanup
+	for name, c := range t.collections {
+		docs := make(map[string]UserDocument, len(c.existingDocs))
+		for k, v := range c.existingDocs {
+			docs[k] = v
+		}
+		state.Collections[name] = docs
+	}
+	t.collectionsMutex.Unlock()
+
+	t.stateMutex.Lock()
+	defer t.stateMutex.Unlock()
+	t.savedState = state
+}
+
+// SaveState writes the last snapshot of the collections and their documents to the given writer.
+func (t *simpleTest) SaveState(w io.Writer) error {
+	t.stateMutex.Lock()
+	defer t.stateMutex.Unlock()
+
+	if err := json.NewEncoder(w).Encode(t.savedState); err != nil {
+		return maskAny(err)
+	}
+	return nil
+}
+
+// RestoreState reads a state written by SaveState. It must be called before Start.
+// The restored collections are synced with the database when the test starts.
+func (t *simpleTest) RestoreState(r io.Reader) error {
+	var state simpleTestState
+	if err := json.NewDecoder(r).Decode(&state); err != nil {
+		return maskAny(err)
+	}
+
+	t.stateMutex.Lock()
+	t.savedState = state
+	t.stateMutex.Unlock()
+
+	t.failures = state.Failures
+	t.lastCollectionIndex = state.LastCollectionIndex
+	t.resumed = true
+	t.collectionsMutex.Lock()
+	defer t.collectionsMutex.Unlock()
+	t.collectionToCleanup = state.CollectionToCleanup
+	t.collections = make(map[string]*collection)
+	for name, docs := range state.Collections {
+		if docs == nil {
+			docs = make(map[string]UserDocument)
+		}
+		t.collections[name] = &collection{
+			name:         name,
+			existingDocs: docs,
+		}
+	}
+	return nil
+}
+
+// syncState replaces the restored collections and documents with those found in the database.
+// The state may be older than the database (e.g. after a crash of the host), so the
+// collections and documents changed since then are not known otherwise.
+func (t *simpleTest) syncState() error {
+	names, err := util.ListCollections(t.client, collectionNamePrefix, t.OperationTimeout)
+	if err != nil {
+		return maskAny(err)
+	}
+	collections := make(map[string]*collection)
+	toCleanup := ""
+	for _, name := range names {
+		index, err := strconv.ParseInt(strings.TrimPrefix(name, collectionNamePrefix), 10, 32)
+		if err != nil {
+			// Not created by this test
+			continue
+		}
+		if int32(index) > t.lastCollectionIndex {
+			t.log.Infof("Collection '%s' was created after the state was saved", name)
+			t.lastCollectionIndex = int32(index)
+		}
+		if name == t.collectionToCleanup {
+			toCleanup = name
+		}
+		var docs []UserDocument
+		found, err := util.ReadAllDocuments(t.client, name, t.OperationTimeout, &docs)
+		if err != nil {
+			return maskAny(err)
+		} else if !found {
+			// Removed in the meantime
+			if name == toCleanup {
+				toCleanup = ""
+			}
+			continue
+		}
+		c := &collection{
+			name:         name,
+			existingDocs: make(map[string]UserDocument, len(docs)),
+		}
+		for _, d := range docs {
+			c.existingDocs[d.Key] = d
+		}
+		if old, found := t.collections[name]; found && len(old.existingDocs) != len(c.existingDocs) {
+			t.log.Infof("Collection '%s' contains %d documents instead of %d", name, len(c.existingDocs), len(old.existingDocs))
+		}
+		collections[name] = c
+	}
+	for name := range t.collections {
+		if _, found := collections[name]; !found {
+			t.log.Infof("Collection '%s' was removed after the state was saved", name)
+		}
+	}
+
+	t.collectionsMutex.Lock()
+	defer t.collectionsMutex.Unlock()
+	t.collections = collections
+	t.collectionToCleanup = toCleanup
+	return nil
+}
@@ -0,0 +1,124 @@
+package simple
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/arangodb-helper/testagent/service/test"
+	"github.com/arangodb-helper/testagent/tests/util"
+)
+
+func TestStateRoundTrip(t *testing.T) {
+	orig := NewSimpleTest(log, ".", config).(*simpleTest)
+	orig.failures = 3
+	orig.lastCollectionIndex = 7
+	orig.collectionToCleanup = "simple_user_6"
+	orig.registerCollection(&collection{
+		name: "simple_user_7",
+		existingDocs: map[string]UserDocument{
+			"doc00001":      {Key: "doc00001", Rev: "_rev1", Value: 1, Name: "User 1", Odd: true},
+			"newkey0000042": {}, // Recorded before its creation finished
+		},
+	})
+
+	orig.snapshotState()
+	orig.collections["simple_user_7"].existingDocs["doc00002"] = UserDocument{Key: "doc00002"} // After the snapshot
+
+	var buf bytes.Buffer
+	if err := orig.SaveState(&buf); err != nil {
+		t.Fatalf("SaveState failed: %v", err)
+	}
+	restored := NewSimpleTest(log, ".", config)
+	if err := restored.(test.StatefulTestScript).RestoreState(&buf); err != nil {
+		t.Fatalf("RestoreState failed: %v", err)
+	}
+
+	rt := restored.(*simpleTest)
+	if rt.failures != 3 || rt.lastCollectionIndex != 7 || rt.collectionToCleanup != "simple_user_6" {
+		t.Errorf("State not restored: %d, %d, %s", rt.failures, rt.lastCollectionIndex, rt.collectionToCleanup)
+	}
+	c, found := rt.collections["simple_user_7"]
+	if !found {
+		t.Fatalf("Collection simple_user_7 not restored")
+	}
+	if c.name != "simple_user_7" || len(c.existingDocs) != 2 {
+		t.Errorf("Collection not restored: %s, %d documents", c.name, len(c.existingDocs))
+	}
+	if doc := c.existingDocs["doc00001"]; doc.Rev != "_rev1" || !doc.Equals(orig.collections["simple_user_7"].existingDocs["doc00001"]) {
+		t.Errorf("Document not restored: %v", doc)
+	}
+}
+
+func syncStateBehaviour(
+	ctx context.Context, t *testing.T,
+	requests chan *util.MockRequest, responses chan *util.MockResponse) {
+
+	// Expect the collections to be listed
+	req := next(ctx, t, requests, true)
+	if req == nil {
+		return
+	}
+	if req.Method != "GET" || req.UrlPath != "/_api/collection" {
+		t.Errorf("Got wrong request %s %s instead of GET /_api/collection", req.Method, req.UrlPath)
+	}
+	json.Unmarshal([]byte(`{"result":[{"name":"simple_user_7"},{"name":"simple_user_9"},{"name":"simple_user_x"}]}`), req.Result)
+	responses <- &util.MockResponse{Resp: util.ArangoResponse{StatusCode: 200}}
+
+	// Expect the documents of both collections of the test to be read
+	for _, result := range []string{
+		`{"result":[{"_key":"doc00001","_rev":"_rev2","value":2,"name":"User 2","odd":false}]}`,
+		`{"result":[]}`,
+	} {
+		req = next(ctx, t, requests, true)
+		if req == nil {
+			return
+		}
+		if req.Method != "POST" || req.UrlPath != "/_api/cursor" {
+			t.Errorf("Got wrong request %s %s instead of POST /_api/cursor", req.Method, req.UrlPath)
+		}
+		json.Unmarshal([]byte(result), req.Result)
+		responses <- &util.MockResponse{Resp: util.ArangoResponse{StatusCode: 201}}
+	}
+
+	next(ctx, t, requests, false)
+}
+
+func TestSyncState(t *testing.T) {
+	test := NewSimpleTest(log, ".", config).(*simpleTest)
+	test.lastCollectionIndex = 7
+	test.collectionToCleanup = "simple_user_6"
+	test.registerCollection(&collection{name: "simple_user_6", existingDocs: map[string]UserDocument{}})
+	test.registerCollection(&collection{
+		name: "simple_user_7",
+		existingDocs: map[string]UserDocument{
+			"doc00001":      {Key: "doc00001", Rev: "_rev1", Value: 1, Name: "User 1", Odd: true},
+			"newkey0000042": {}, // Removed after the state was saved
+		},
+	})
+
+	mockClient := util.NewMockClient(t, syncStateBehaviour)
+	test.client = mockClient
+	test.listener = util.MockListener{}
+	if err := test.syncState(); err != nil {
+		t.Fatalf("syncState failed: %v", err)
+	}
+	mockClient.Shutdown()
+
+	if test.lastCollectionIndex != 9 || test.collectionToCleanup != "" {
+		t.Errorf("Unexpected state: %d, %s", test.lastCollectionIndex, test.collectionToCleanup)
+	}
+	var names []string
+	for name := range test.collections {
+		names = append(names, name)
+	}
+	if len(names) != 2 || test.collections["simple_user_7"] == nil || test.collections["simple_user_9"] == nil {
+		t.Fatalf("Unexpected collections: %s", strings.Join(names, ", "))
+	}
+	docs := test.collections["simple_user_7"].existingDocs
+	if len(docs) != 1 || docs["doc00001"].Rev != "_rev2" || docs["doc00001"].Value != 2 {
+		t.Errorf("Documents not synced: %v", docs)
+	}
+}
@@ -91,11 +91,17 @@ func (c *ArangoClient) createURL(urlPath string, query url.Values) (string, url.
 		if err != nil {
 			return "", url.URL{}, maskAny(err)
 		}
-		if len(machines) == 0 {
+		var candidates []cluster.Machine
+		for _, m := range machines {
+			if cluster.HasClientServer(m) {
+				candidates = append(candidates, m)
+			}
+		}
+		if len(candidates) == 0 {
 			return "", url.URL{}, maskAny(fmt.Errorf("No machines available"))
 		}
-		index := rand.Intn(len(machines))
-		u := machines[index].CoordinatorURL()
+		index := rand.Intn(len(candidates))
+		u := cluster.ClientURL(candidates[index])
 		c.lastCoordinatorURL = &u
 	}
 	lastCoordinatorURL := *c.lastCoordinatorURL
@@ -208,6 +214,7 @@ func (c *ArangoClient) requestWithRetry(
 		if inputData != nil {
 			req.Header.Set("Content-Type", contentType)
 		}
+		cluster.Authenticate(c.cluster, req)
 		for k, v := range header {
 			req.Header.Set(k, v)
 		}
@@ -251,6 +258,11 @@ func (c *ArangoClient) handleResponse(
 	// Store status code
 	aresp.StatusCode = resp.StatusCode
 
+	// A follower of an active failover deployment refers to its leader, use that for the next request
+	if leader, ok := failoverLeaderURL(resp); ok {
+		defer func() { c.lastCoordinatorURL = leader }()
+	}
+
 	// Read response body into memory
 	defer resp.Body.Close()
 	body, err := ioutil.ReadAll(resp.Body)
@@ -301,6 +313,29 @@ func (c *ArangoClient) handleResponse(
 	return maskAny(fmt.Errorf("Unexpected status %d from %s request to %s (attempt %d, started at %s, after %s, headers\n%s\n\nbody\n%s\n)", resp.StatusCode, method, url, attempt, start.Format(startTSFormat), time.Since(start), headers, string(body)))
 }
 
+// failoverLeaderURL returns the URL of the leader that a follower of an active failover
+// deployment refers to in its response (if any).
+func failoverLeaderURL(resp *http.Response) (*url.URL, bool) {
+	if resp.StatusCode != http.StatusServiceUnavailable {
+		return nil, false
+	}
+	endpoint := resp.Header.Get("X-Arango-Endpoint")
+	if endpoint == "" {
+		return nil, false
+	}
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return nil, false
+	}
+	switch u.Scheme {
+	case "tcp":
+		u.Scheme = "http"
+	case "ssl":
+		u.Scheme = "https"
+	}
+	return u, true
+}
+
 func tryDecodeBody(body []byte, result interface{}) error {
 	if err := json.Unmarshal(body, result); err != nil {
 		return maskAny(err)
@@ -343,8 +378,14 @@ func isSuccessStatusCode(statusCode int) bool {
 	return statusCode >= 200 && statusCode < 300
 }
 
+var (
+	// sharedTransport is used by all clients, such that connections are reused.
+	sharedTransport = cluster.NewHTTPTransport()
+)
+
 func createClient(timeout time.Duration) *http.Client {
 	return &http.Client{
-		Timeout: timeout,
+		Timeout:   timeout,
+		Transport: sharedTransport,
 	}
 }
@@ -0,0 +1,113 @@
+package util
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const (
+	// readAllPageSize is the number of documents ReadAllDocuments fetches per query.
+	readAllPageSize = 1000
+)
+
+type collectionList struct {
+	Result []struct {
+		Name string `json:"name"`
+	} `json:"result"`
+}
+
+type documentPage struct {
+	Result []json.RawMessage `json:"result"`
+}
+
+// ListCollections returns the names of all non-system collections of the current database
+// that start with the given prefix.
+func ListCollections(client ArangoClientInterface, prefix string, operationTimeout time.Duration) ([]string, error) {
+	timeout := time.Now().Add(operationTimeout)
+	q := url.Values{"excludeSystem": {"true"}}
+	backoff := time.Millisecond * 250
+	for {
+		var list collectionList
+		resp, err := client.Get("/_api/collection", q, nil, &list,
+			[]int{0, 1, 200, 410, 500, 503}, []int{400, 404, 409, 307}, operationTimeout, 1)
+		if err[0] != nil {
+			return nil, maskAny(err[0])
+		} else if resp[0].StatusCode == 200 {
+			var result []string
+			for _, c := range list.Result {
+				if strings.HasPrefix(c.Name, prefix) {
+					result = append(result, c.Name)
+				}
+			}
+			return result, nil
+		}
+		if time.Now().After(timeout) {
+			return nil, maskAny(fmt.Errorf("Timed out listing collections (last status %d)", resp[0].StatusCode))
+		}
+		time.Sleep(backoff)
+		if backoff < time.Second*5 {
+			backoff += backoff
+		}
+	}
+}
+
+// ReadAllDocuments reads all documents of the given collection into result (a pointer to a slice).
+// The documents are read in pages by independent queries, such that a change of coordinator
+// in between does no harm.
+// It returns false if the collection does not exist.
+func ReadAllDocuments(client ArangoClientInterface, collectionName string, operationTimeout time.Duration, result interface{}) (bool, error) {
+	var all []json.RawMessage
+	for offset := 0; ; offset += readAllPageSize {
+		page, found, err := readDocumentPage(client, collectionName, offset, operationTimeout)
+		if err != nil {
+			return false, maskAny(err)
+		} else if !found {
+			return false, nil
+		}
+		all = append(all, page...)
+		if len(page) < readAllPageSize {
+			break
+		}
+	}
+	data, err := json.Marshal(all)
+	if err != nil {
+		return false, maskAny(err)
+	}
+	if err := json.Unmarshal(data, result); err != nil {
+		return false, maskAny(err)
+	}
+	return true, nil
+}
+
+// readDocumentPage reads the documents of the given collection, starting at the given offset (ordered by key).
+func readDocumentPage(client ArangoClientInterface, collectionName string, offset int, operationTimeout time.Duration) ([]json.RawMessage, bool, error) {
+	timeout := time.Now().Add(operationTimeout)
+	query := map[string]interface{}{
+		"query":     fmt.Sprintf("FOR d IN `%s` SORT d._key LIMIT %d, %d RETURN d", collectionName, offset, readAllPageSize),
+		"batchSize": readAllPageSize,
+	}
+	backoff := time.Millisecond * 250
+	for {
+		var page documentPage
+		resp, err := client.Post("/_api/cursor", nil, nil, query, "", &page,
+			[]int{0, 1, 201, 404, 410, 500, 503}, []int{200, 202, 400, 409, 307}, operationTimeout, 1)
+		if err[0] != nil {
+			return nil, false, maskAny(err[0])
+		} else if resp[0].StatusCode == 201 {
+			return page.Result, true, nil
+		} else if resp[0].StatusCode == 404 {
+			// Collection (or database) not found
+			return nil, false, nil
+		}
+		if time.Now().After(timeout) {
+			return nil, false, maskAny(fmt.Errorf("Timed out reading documents of '%s' (last status %d)", collectionName, resp[0].StatusCode))
+		}
+		time.Sleep(backoff)
+		if backoff < time.Second*5 {
+			backoff += backoff
+		}
+	}
+}